@@ -1,6 +1,9 @@
 package actions
 
 import (
+	"net/http"
+
+	"github.com/alexmorten/events-api/db"
 	"github.com/alexmorten/events-api/models"
 	"github.com/alexmorten/events-api/search"
 	"github.com/gin-gonic/gin"
@@ -9,15 +12,15 @@ import (
 
 //ActionHandler holds things that are shared between actions
 type ActionHandler struct {
-	dbDriver     neo4j.Driver
-	searchClient *search.Client
+	dbDriver      neo4j.Driver
+	searchBackend search.Backend
 }
 
 //NewActionHandler ...
-func NewActionHandler(dbDriver neo4j.Driver, searchClient *search.Client) *ActionHandler {
+func NewActionHandler(dbDriver neo4j.Driver, searchBackend search.Backend) *ActionHandler {
 	return &ActionHandler{
-		dbDriver:     dbDriver,
-		searchClient: searchClient,
+		dbDriver:      dbDriver,
+		searchBackend: searchBackend,
 	}
 }
 
@@ -32,3 +35,110 @@ func (*ActionHandler) currentUserClaim(c *gin.Context) *models.UserClaim {
 	}
 	return userClaim
 }
+
+//withRouteModel remembers the model the routes of a group are about, e.g. &models.Club{} for /clubs/:uid/...,
+//for the handlers that clubs, groups and events share
+func withRouteModel(prototype db.Model) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("routeModel", prototype)
+	}
+}
+
+//routeModel the route is about, see withRouteModel. Like the prototype of a repository it is only used for its label
+func routeModel(c *gin.Context) db.Model {
+	model, _ := c.Get("routeModel")
+	prototype, _ := model.(db.Model)
+	return prototype
+}
+
+//routeLabel of the nodes the route is about, see withRouteModel
+func routeLabel(c *gin.Context) string {
+	if prototype := routeModel(c); prototype != nil {
+		return prototype.NodeName()
+	}
+	return ""
+}
+
+//routeNodeExists checks that there is a node of the model the route is about with the uid, aborting with 404 otherwise
+func (h *ActionHandler) routeNodeExists(c *gin.Context, uid string) bool {
+	prototype := routeModel(c)
+	exists, err := db.Exists(h.dbDriver, prototype, uid)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+	if !exists {
+		c.AbortWithError(http.StatusNotFound, &db.NotFoundError{Label: prototype.NodeName(), UID: uid})
+		return false
+	}
+	return true
+}
+
+//abortWithFindError responds with 404 if nothing was found and with 500 if finding it failed
+func abortWithFindError(c *gin.Context, err error) {
+	if db.IsNotFound(err) {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
+
+//editableEvent finds the event of the uid param and makes sure the current user can edit it, aborting otherwise
+func (h *ActionHandler) editableEvent(c *gin.Context) (*models.Event, bool) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	event, err := models.FindEvent(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return nil, false
+	}
+	if !event.CanBeEditedBy(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return nil, false
+	}
+	return event, true
+}
+
+//administeredClub finds the club of the uid param and makes sure the current user administers it, aborting otherwise
+func (h *ActionHandler) administeredClub(c *gin.Context) (*models.Club, bool) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	club, err := models.FindClub(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return nil, false
+	}
+	if !club.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return nil, false
+	}
+	return club, true
+}
+
+//administeredGroup finds the group of the uid param and makes sure the current user administers it, aborting otherwise
+func (h *ActionHandler) administeredGroup(c *gin.Context) (*models.Group, bool) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return nil, false
+	}
+	if !group.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return nil, false
+	}
+	return group, true
+}
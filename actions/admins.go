@@ -1,9 +1,100 @@
 package actions
 
 import (
+	"net/http"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type userPromotionAttributes struct {
 	UID uuid.UUID `json:"uid"`
 }
+
+func (h *ActionHandler) deleteAdmins(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	club, err := models.FindClub(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !club.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	userUID, err := uuid.Parse(c.Param("user_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = models.RemoveAdminFromClub(h.dbDriver, club.UID, userUID)
+	h.respondToAdminRemoval(c, err)
+}
+
+func (h *ActionHandler) deleteGroupAdmins(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !group.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	userUID, err := uuid.Parse(c.Param("user_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = models.RemoveAdminFromGroup(h.dbDriver, group.UID, userUID)
+	h.respondToAdminRemoval(c, err)
+}
+
+func (h *ActionHandler) respondToAdminRemoval(c *gin.Context, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusNoContent, nil)
+	case models.ErrNotAnAdmin:
+		c.AbortWithError(http.StatusNotFound, err)
+	case models.ErrLastAdmin:
+		c.AbortWithError(http.StatusConflict, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
+
+//getEffectiveAdmins lists everyone administering the club or group, including the admins of the groups and club above it
+func (h *ActionHandler) getEffectiveAdmins(c *gin.Context) {
+	if h.currentUserClaim(c) == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	groupOrClub := h.findGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	admins, err := models.FindEffectiveAdmins(h.dbDriver, groupOrClub)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, admins)
+}
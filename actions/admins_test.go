@@ -0,0 +1,113 @@
+package actions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Admins(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("club admins can be removed unless they are the last one", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		firstAdmin := testhelpers.CreateSomeUser(dbDriver)
+		secondAdmin := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, firstAdmin.UID))
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, secondAdmin.UID))
+		adminsPath := "/clubs/" + club.UID.String() + "/admins/"
+
+		w := request("DELETE", adminsPath+firstAdmin.UID.String(), testhelpers.CreateSomeUser(dbDriver))
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request("DELETE", adminsPath+secondAdmin.UID.String(), firstAdmin)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = request("DELETE", adminsPath+secondAdmin.UID.String(), firstAdmin)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = request("DELETE", adminsPath+firstAdmin.UID.String(), firstAdmin)
+		require.Equal(t, http.StatusConflict, w.Code)
+		assert.True(t, club.AdministeredByUser(dbDriver, firstAdmin.UID))
+	})
+
+	t.Run("group admins can be removed", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		group := models.NewGroup()
+		_, err := db.Save(dbDriver, group)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToGroup(dbDriver, group.UID, admin.UID))
+
+		w := request("DELETE", "/groups/"+group.UID.String()+"/admins/"+admin.UID.String(), admin)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.False(t, group.AdministeredByUser(dbDriver, admin.UID))
+	})
+
+	t.Run("effective admins include the admins of ancestors", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		clubAdmin := testhelpers.CreateSomeUser(dbDriver)
+		groupAdmin := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		club.Name = "FC Example"
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, clubAdmin.UID))
+		parentGroup := models.NewGroup()
+		_, err = db.Save(dbDriver, parentGroup)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, parentGroup.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, parentGroup.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToGroup(dbDriver, group.UID, groupAdmin.UID))
+		require.NoError(t, models.AddAdminToGroup(dbDriver, group.UID, clubAdmin.UID))
+
+		w := request("GET", "/groups/"+group.UID.String()+"/admins/effective", groupAdmin)
+		require.Equal(t, http.StatusOK, w.Code)
+		admins := []models.EffectiveAdmin{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &admins))
+		require.Len(t, admins, 2)
+		for _, admin := range admins {
+			assert.Nil(t, admin.InheritedFrom)
+		}
+
+		w = request("GET", "/groups/"+parentGroup.UID.String()+"/admins/effective", groupAdmin)
+		require.Equal(t, http.StatusOK, w.Code)
+		admins = []models.EffectiveAdmin{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &admins))
+		require.Len(t, admins, 1)
+		assert.Equal(t, clubAdmin.UID, admins[0].UID)
+		require.NotNil(t, admins[0].InheritedFrom)
+		assert.Equal(t, club.UID, admins[0].InheritedFrom.UID)
+		assert.Equal(t, "Club", admins[0].InheritedFrom.Type)
+	})
+}
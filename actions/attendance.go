@@ -0,0 +1,102 @@
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+type attendanceAttributes struct {
+	Status string `json:"status"`
+}
+
+//postAttendance sets the response of the current user to the event, going by default
+func (h *ActionHandler) postAttendance(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	uid := c.Param("uid")
+	event, err := models.FindEvent(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	attributes := &attendanceAttributes{Status: models.AttendanceGoing}
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(attributes)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+	}
+	if !models.ValidAttendanceStatus(attributes.Status) {
+		c.AbortWithError(http.StatusBadRequest, errors.New("status has to be going, maybe or declined"))
+		return
+	}
+
+	attendance, err := models.Attend(h.dbDriver, event.UID, currentUserClaim.UID, attributes.Status)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
+
+//deleteAttendance removes the response of the current user, freeing their place for the waitlist
+func (h *ActionHandler) deleteAttendance(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	uid := c.Param("uid")
+	event, err := models.FindEvent(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	err = models.CancelAttendance(h.dbDriver, event.UID, currentUserClaim.UID)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+//getAttendees of the event with the status given in the query (going by default), waitlisted users are ordered by their position
+func (h *ActionHandler) getAttendees(c *gin.Context) {
+	uid := c.Param("uid")
+	event, err := models.FindEvent(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	status := c.DefaultQuery("status", models.AttendanceGoing)
+	if !models.ValidAttendanceStatus(status) && status != models.AttendanceWaitlisted {
+		c.AbortWithError(http.StatusBadRequest, errors.New("status has to be going, maybe, declined or waitlisted"))
+		return
+	}
+
+	users, err := models.FindAttendees(h.dbDriver, event.UID, status)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	attendees := []models.PublicUserAttributes{}
+	for _, user := range users {
+		attendees = append(attendees, user.PublicAttributes())
+	}
+	c.JSON(http.StatusOK, attendees)
+}
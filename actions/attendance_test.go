@@ -0,0 +1,156 @@
+package actions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Attendance(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	createEvent := func(t *testing.T, capacity int) *models.Event {
+		creator := testhelpers.CreateSomeUser(dbDriver)
+		event := models.NewEvent()
+		event.Name = "training"
+		event.Capacity = capacity
+		_, err := db.CreateBy(dbDriver, event, creator.UID)
+		require.NoError(t, err)
+		return event
+	}
+
+	attend := func(t *testing.T, event *models.Event, user *models.User, body string) *models.Attendance {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events/"+event.UID.String()+"/attendance", bytes.NewReader([]byte(body)))
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		attendance := &models.Attendance{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), attendance))
+		return attendance
+	}
+
+	attendees := func(t *testing.T, event *models.Event, status string) []models.PublicUserAttributes {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/events/"+event.UID.String()+"/attendees?status="+status, nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		users := []models.PublicUserAttributes{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+		return users
+	}
+
+	t.Run("unauthorized requests return 401", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := createEvent(t, 0)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events/"+event.UID.String()+"/attendance", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("users that don't exist anymore can't respond", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := createEvent(t, 0)
+		deleted := models.NewUser()
+		deleted.Email = "deleted@example.com"
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events/"+event.UID.String()+"/attendance", nil)
+		testhelpers.AddAuthorizationHeader(req, deleted)
+		s.Engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, attendees(t, event, models.AttendanceGoing))
+	})
+
+	t.Run("users can respond to events", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := createEvent(t, 0)
+		user := testhelpers.CreateSomeUser(dbDriver)
+
+		assert.Equal(t, models.AttendanceGoing, attend(t, event, user, "").Status)
+		require.Len(t, attendees(t, event, models.AttendanceGoing), 1)
+
+		assert.Equal(t, models.AttendanceMaybe, attend(t, event, user, `{"status":"maybe"}`).Status)
+		assert.Len(t, attendees(t, event, models.AttendanceGoing), 0)
+		assert.Len(t, attendees(t, event, models.AttendanceMaybe), 1)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/events/"+event.UID.String()+"/attendance", bytes.NewReader([]byte(`{"status":"waitlisted"}`)))
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("full events waitlist users and promote them when someone cancels", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := createEvent(t, 1)
+		first := testhelpers.CreateSomeUser(dbDriver)
+		second := testhelpers.CreateSomeUser(dbDriver)
+		third := testhelpers.CreateSomeUser(dbDriver)
+
+		assert.Equal(t, models.AttendanceGoing, attend(t, event, first, "").Status)
+		assert.Equal(t, models.AttendanceWaitlisted, attend(t, event, second, "").Status)
+		assert.Equal(t, models.AttendanceWaitlisted, attend(t, event, third, "").Status)
+
+		waitlist := attendees(t, event, models.AttendanceWaitlisted)
+		require.Len(t, waitlist, 2)
+		assert.Equal(t, second.UID, waitlist[0].UID)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/events/"+event.UID.String()+"/attendance", nil)
+		testhelpers.AddAuthorizationHeader(req, first)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		going := attendees(t, event, models.AttendanceGoing)
+		require.Len(t, going, 1)
+		assert.Equal(t, second.UID, going[0].UID)
+
+		assert.Equal(t, models.AttendanceDeclined, attend(t, event, second, `{"status":"declined"}`).Status)
+		going = attendees(t, event, models.AttendanceGoing)
+		require.Len(t, going, 1)
+		assert.Equal(t, third.UID, going[0].UID)
+	})
+
+	t.Run("concurrent sign ups don't exceed the capacity", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := createEvent(t, 3)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			user := testhelpers.CreateSomeUser(dbDriver)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := models.Attend(dbDriver, event.UID, user.UID, models.AttendanceGoing)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, attendees(t, event, models.AttendanceGoing), 3)
+		assert.Len(t, attendees(t, event, models.AttendanceWaitlisted), 7)
+	})
+}
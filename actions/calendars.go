@@ -0,0 +1,187 @@
+package actions
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const calendarFeedSuffix = ".ics"
+
+//RegisterCalendarRoutes for personal calendar feeds
+func (h *ActionHandler) RegisterCalendarRoutes(group *gin.RouterGroup) {
+	group.GET("", h.getCalendarFeedURL)
+	group.POST("", h.rotateCalendarFeedURL)
+	group.GET("/:token", h.getUserCalendar)
+}
+
+type calendarFeed struct {
+	URL string `json:"url"`
+}
+
+//getCalendarFeedURL returns the secret feed url of the current user, it can be used without further authentication
+func (h *ActionHandler) getCalendarFeedURL(c *gin.Context) {
+	user := h.currentUser(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, newCalendarFeed(user))
+}
+
+//rotateCalendarFeedURL gives the current user a new feed url, e.g. because the old one was shared by accident. The old url stops working
+func (h *ActionHandler) rotateCalendarFeedURL(c *gin.Context) {
+	user := h.currentUser(c)
+	if user == nil {
+		return
+	}
+
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	user.CalendarFeedKey = hex.EncodeToString(key)
+	_, err = db.Save(h.dbDriver, user)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, newCalendarFeed(user))
+}
+
+func newCalendarFeed(user *models.User) calendarFeed {
+	return calendarFeed{URL: fmt.Sprintf("/calendars/%v%v", calendarFeedToken(user), calendarFeedSuffix)}
+}
+
+func (h *ActionHandler) getUserCalendar(c *gin.Context) {
+	token := c.Param("token")
+	if !strings.HasSuffix(token, calendarFeedSuffix) {
+		c.AbortWithError(http.StatusNotFound, errors.New("unknown calendar feed"))
+		return
+	}
+	user, err := h.userOfCalendarFeedToken(strings.TrimSuffix(token, calendarFeedSuffix))
+	if err != nil {
+		c.AbortWithError(http.StatusNotFound, err)
+		return
+	}
+
+	events, err := models.FindEventsOfUser(h.dbDriver, user.UID.String())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	h.serveCalendar(c, "Events of "+user.Name, events)
+}
+
+func (h *ActionHandler) getClubCalendar(c *gin.Context) {
+	uid := c.Param("uid")
+	club, err := models.FindClub(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	events, err := models.FindEventsHostedWithin(h.dbDriver, club.UID.String())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	h.serveCalendar(c, club.Name, events)
+}
+
+func (h *ActionHandler) getGroupCalendar(c *gin.Context) {
+	uid := c.Param("uid")
+	group, err := models.FindGroup(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	events, err := models.FindEventsHostedWithin(h.dbDriver, group.UID.String())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	h.serveCalendar(c, group.Name, events)
+}
+
+//serveCalendar renders the events as iCalendar, answering conditional requests of polling clients with 304
+func (h *ActionHandler) serveCalendar(c *gin.Context, name string, events []*models.Event) {
+	calendarEvents := []calendar.Event{}
+	lastModified := time.Time{}
+	for _, event := range events {
+		if event.StartsAt.IsZero() {
+			continue
+		}
+		calendarEvents = append(calendarEvents, event.CalendarEvent())
+		if event.LastModified().After(lastModified) {
+			lastModified = event.LastModified()
+		}
+	}
+
+	body := &bytes.Buffer{}
+	err := calendar.WriteCalendar(body, name, calendarEvents)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	checksum := sha1.Sum(body.Bytes())
+	c.Header("ETag", `"`+hex.EncodeToString(checksum[:])+`"`)
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Request, "calendar.ics", lastModified, bytes.NewReader(body.Bytes()))
+}
+
+//calendarFeedToken is the url-safe user uid together with its signature
+func calendarFeedToken(user *models.User) string {
+	token := append(user.UID[:], calendarFeedSignature(user)...)
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+//userOfCalendarFeedToken finds the user of the token if its signature is valid, tokens signed before the feed key of the user changed aren't
+func (h *ActionHandler) userOfCalendarFeedToken(token string) (*models.User, error) {
+	errInvalidToken := errors.New("invalid calendar feed token")
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(decoded) <= len(uuid.UUID{}) {
+		return nil, errInvalidToken
+	}
+
+	userUID, err := uuid.FromBytes(decoded[:len(uuid.UUID{})])
+	if err != nil {
+		return nil, err
+	}
+	user, err := models.FindUser(h.dbDriver, userUID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(decoded[len(uuid.UUID{}):], calendarFeedSignature(user)) {
+		return nil, errInvalidToken
+	}
+	return user, nil
+}
+
+func calendarFeedSignature(user *models.User) []byte {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte("calendar:"))
+	mac.Write(user.UID[:])
+	mac.Write([]byte(user.CalendarFeedKey))
+	return mac.Sum(nil)[:16]
+}
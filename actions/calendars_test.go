@@ -0,0 +1,160 @@
+package actions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Calendars(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	t.Run("club calendars include events of groups within the club", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+
+		event := models.NewEvent()
+		event.Name = "training"
+		event.StartsAt = time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+		event.EndsAt = event.StartsAt.Add(2 * time.Hour)
+		_, err = db.CreateBy(dbDriver, event, user.UID)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, event.UID, group.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/clubs/"+club.UID.String()+"/calendar.ics", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/calendar")
+		assert.Contains(t, w.Body.String(), "UID:"+event.UID.String())
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/clubs/"+club.UID.String()+"/calendar.ics", nil)
+		req.Header.Set("If-None-Match", etag)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotModified, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/groups/"+group.UID.String()+"/calendar.ics", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "UID:"+event.UID.String())
+	})
+
+	t.Run("users can subscribe to their personal calendar feed", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		other := testhelpers.CreateSomeUser(dbDriver)
+		newEvent := func(name string, creator *models.User) *models.Event {
+			event := models.NewEvent()
+			event.Name = name
+			event.StartsAt = time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+			_, err := db.CreateBy(dbDriver, event, creator.UID)
+			require.NoError(t, err)
+			return event
+		}
+		created := newEvent("my event", user)
+		attended := newEvent("attended", other)
+		_, err := models.Attend(dbDriver, attended.UID, user.UID, models.AttendanceGoing)
+		require.NoError(t, err)
+		declined := newEvent("declined", other)
+		_, err = models.Attend(dbDriver, declined.UID, user.UID, models.AttendanceDeclined)
+		require.NoError(t, err)
+
+		club := models.NewClub()
+		_, err = db.Save(dbDriver, club)
+		require.NoError(t, err)
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		_, err = models.RequestMembership(dbDriver, user.UID, club)
+		require.NoError(t, err)
+		_, err = models.ApproveMembership(dbDriver, user.UID, club, models.MembershipRoleMember)
+		require.NoError(t, err)
+		hosted := newEvent("hosted", other)
+		_, err = db.CreateRelation(dbDriver, hosted.UID, group.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+		unrelated := newEvent("unrelated", other)
+
+		feedURL := func() string {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/calendars", nil)
+			testhelpers.AddAuthorizationHeader(req, user)
+			s.Engine.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			feed := map[string]string{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+			return feed["url"]
+		}
+		url := feedURL()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		for _, event := range []*models.Event{created, attended, hosted} {
+			assert.Contains(t, w.Body.String(), "UID:"+event.UID.String(), event.Name)
+		}
+		for _, event := range []*models.Event{declined, unrelated} {
+			assert.NotContains(t, w.Body.String(), "UID:"+event.UID.String(), event.Name)
+		}
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/calendars/not-a-valid-token.ics", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		t.Run("rotating the feed url revokes the old one", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/calendars", nil)
+			testhelpers.AddAuthorizationHeader(req, user)
+			s.Engine.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			feed := map[string]string{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+			assert.NotEqual(t, url, feed["url"])
+			assert.Equal(t, feed["url"], feedURL())
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", url, nil)
+			s.Engine.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", feed["url"], nil)
+			s.Engine.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	})
+}
@@ -2,6 +2,7 @@ package actions
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -15,6 +16,7 @@ import (
 
 //RegisterClubRoutes within the given router group
 func (h *ActionHandler) RegisterClubRoutes(group *gin.RouterGroup) {
+	group.Use(withRouteModel(&models.Club{}))
 	group.GET("/:uid", h.getClub)
 	group.GET("", h.getClubs)
 	group.PATCH("/:uid", h.updateClub)
@@ -23,49 +25,91 @@ func (h *ActionHandler) RegisterClubRoutes(group *gin.RouterGroup) {
 
 	group.POST("/:uid/groups", h.postGroup)
 	group.GET("/:uid/groups", h.getGroups)
+	group.GET("/:uid/tree", h.getClubTree)
 
 	group.GET("/:uid/admins", h.getAdmins)
 	group.POST("/:uid/admins", h.postAdmins)
+	group.DELETE("/:uid/admins/:user_uid", h.deleteAdmins)
+	group.GET("/:uid/admins/effective", h.getEffectiveAdmins)
+
+	h.registerMembershipRoutes(group)
+
+	group.GET("/:uid/events", h.getHostedEvents)
+	group.POST("/:uid/events", h.postClubEvents)
+
+	group.GET("/:uid/calendar.ics", h.getClubCalendar)
+
+	group.GET("/:uid/venue", h.getVenueOf)
+	group.PUT("/:uid/venue", h.putClubVenue)
+	group.DELETE("/:uid/venue", h.deleteClubVenue)
+
+	group.GET("/:uid/tags", h.getTagsOf)
+	group.PUT("/:uid/tags/:tag_uid", h.putClubTag)
+	group.DELETE("/:uid/tags/:tag_uid", h.deleteClubTag)
+
+	group.GET("/:uid/sports", h.getSportsOf)
+	group.PUT("/:uid/sports/:sport_uid", h.putClubSport)
+	group.DELETE("/:uid/sports/:sport_uid", h.deleteClubSport)
 }
 
 func (h *ActionHandler) getClub(c *gin.Context) {
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-
-	club, err := models.FindClub(h.dbDriver, uid)
-	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
-		return
-	}
-	c.JSON(200, club)
+	getFromRepository(c, h.repository(&models.Club{}))
 }
 
+//getClubs lists the clubs by name, ?limit, ?cursor, ?sort and ?filter page, order and filter them.
+//?near=lat,lng&radius=km lists the clubs at venues within radius, nearest first.
+//?tags=a,b lists the clubs tagged with all of the tags, or any of them with ?tags_match=any, ?sport=uid,uid the clubs offering any of the sports
 func (h *ActionHandler) getClubs(c *gin.Context) {
-	clubs := []*models.Club{}
+	h.listClubs(c, []string{}, map[string]interface{}{})
+}
 
-	dbSession, err := h.dbDriver.Session(neo4j.AccessModeRead)
+//listClubs meeting the conditions, narrowed down further by the list filters and ?near of the request.
+//Clubs are listed by name a page at a time unless they are near a point, then they are all listed nearest first
+func (h *ActionHandler) listClubs(c *gin.Context, conditions []string, params map[string]interface{}) {
+	near, radiusMeters, err := nearQueryParams(c)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
-	records, err := neo4j.Collect(dbSession.Run("match (n:Club) return properties(n)", nil))
+	page, err := pageQueryParams(c, &models.Club{}, db.SortKey{Field: "name"})
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	filterConditions, err := listFilterConditions(c, params)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	conditions = append(conditions, filterConditions...)
 
-	for _, record := range records {
-		propInterface, ok := record.Get("properties(n)")
-		if ok {
-			props, ok := propInterface.(map[string]interface{})
-			if ok {
-				clubs = append(clubs, models.ClubFromProps(props))
-			}
-		}
+	clubs := h.repository(&models.Club{})
+	if near == nil {
+		respondWithPage(c, clubs, db.ListQuery{Conditions: conditions, Params: params}, page)
+		return
+	}
+	if asksForPage(c) {
+		c.AbortWithError(http.StatusBadRequest, errPageWithCustomOrder)
+		return
 	}
-	c.JSON(http.StatusOK, clubs)
+
+	params["near"] = near.NeoPoint()
+	params["radius"] = radiusMeters
+	nearClubs, err := clubs.List(db.ListQuery{
+		Match: fmt.Sprintf(
+			"match (n:Club)-[:%v]->(venue:Venue) with n, min(distance(venue.location, $near)) as distance where distance <= $radius",
+			models.EventOrClubAtVenue,
+		),
+		Conditions: append(conditions, page.FilterConditions(params)...),
+		Params:     params,
+		OrderBy:    "distance",
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	setTotalCount(c, int64(len(nearClubs)))
+	c.JSON(http.StatusOK, nearClubs)
 }
 
 func (h *ActionHandler) postClubs(c *gin.Context) {
@@ -88,14 +132,12 @@ func (h *ActionHandler) postClubs(c *gin.Context) {
 		return
 	}
 	club.ClubAttributes = *clubAttributes
-	props, err := db.CreateBy(h.dbDriver, club, currentUserClaim.UID)
+	createdClub, err := h.repository(club).Create(club, currentUserClaim.UID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	createdclub := models.ClubFromProps(props)
-	c.JSON(http.StatusCreated, createdclub)
+	c.JSON(http.StatusCreated, createdClub)
 }
 
 type clubAttributesUpdate struct {
@@ -114,15 +156,13 @@ func (h *ActionHandler) updateClub(c *gin.Context) {
 		return
 	}
 
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-	club, err := models.FindClub(h.dbDriver, uid)
+	clubs := h.repository(&models.Club{})
+	found, err := clubs.Get(c.Param("uid"))
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
+	club := found.(*models.Club)
 
 	updateAttributes := &clubAttributesUpdate{}
 	err = c.ShouldBindJSON(updateAttributes)
@@ -133,13 +173,12 @@ func (h *ActionHandler) updateClub(c *gin.Context) {
 
 	models.UpdateFrom(&club.ClubAttributes, updateAttributes)
 
-	clubProps, err := db.Save(h.dbDriver, club)
+	updatedClub, err := clubs.Update(club)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	c.JSON(200, models.ClubFromProps(clubProps))
+	c.JSON(http.StatusOK, updatedClub)
 }
 
 func (h *ActionHandler) deleteClub(c *gin.Context) {
@@ -160,11 +199,11 @@ func (h *ActionHandler) deleteClub(c *gin.Context) {
 	}
 	club, err := models.FindClub(h.dbDriver, uid)
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
 
-	err = db.DeleteNode(h.dbDriver, club.UID.String())
+	err = h.deleteIndexedNode(club, club.UID.String())
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +26,8 @@ func Test_Clubs(t *testing.T) {
 	config := api.DefaultServerConfig()
 	dbDriver := db.Driver(config.Neo4jAddress)
 	s := api.NewServer(config)
-	s.Init()
+	require.NoError(t, s.Init())
+	defer s.Close()
 	t.Run("unauthorized requests return 401", func(t *testing.T) {
 		testhelpers.Clear(dbDriver)
 
@@ -71,6 +73,85 @@ func Test_Clubs(t *testing.T) {
 		require.Equal(t, (*clubs)[0].Name, "blubbi di blup")
 	})
 
+	t.Run("clubs are only found by the uids of clubs", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		group := models.NewGroup()
+		_, err := db.Save(dbDriver, group)
+		require.NoError(t, err)
+
+		for _, uid := range []string{group.UID.String(), models.NewClub().UID.String(), "not-a-uid"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/clubs/"+uid, nil)
+			s.Engine.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		}
+
+		_, err = models.FindClub(dbDriver, group.UID.String())
+		assert.True(t, db.IsNotFound(err))
+		exists, err := db.Exists(dbDriver, &models.Group{}, group.UID.String())
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = db.Exists(dbDriver, &models.Club{}, group.UID.String())
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		first, second := models.NewClub(), models.NewClub()
+		for _, club := range []*models.Club{first, second} {
+			_, err = db.Save(dbDriver, club)
+			require.NoError(t, err)
+		}
+		found, err := db.FindManyByUID(dbDriver, &models.Club{}, []string{second.UID.String(), group.UID.String(), first.UID.String()})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, second.UID, models.ClubFromProps(found[0]).UID)
+		assert.Equal(t, first.UID, models.ClubFromProps(found[1]).UID)
+	})
+
+	t.Run("clubs are listed a page at a time, sorted and filtered", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		for _, name := range []string{"Rowing Club", "Chess Club", "Fencing Club", "Choir"} {
+			club := models.NewClub()
+			club.Name = name
+			_, err := db.Save(dbDriver, club)
+			require.NoError(t, err)
+		}
+
+		list := func(path string) ([]string, *httptest.ResponseRecorder) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			s.Engine.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			clubs := []*models.Club{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+			names := []string{}
+			for _, club := range clubs {
+				names = append(names, club.Name)
+			}
+			return names, w
+		}
+
+		names, w := list("/clubs?filter[name][contains]=Club&sort=-name&limit=2")
+		assert.Equal(t, []string{"Rowing Club", "Fencing Club"}, names)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"), "the clubs on all pages are counted")
+		next := w.Header().Get("X-Next-Cursor")
+		require.NotEmpty(t, next)
+		link := w.Header().Get("Link")
+		require.Contains(t, link, `rel="next"`)
+		names, w = list(link[1:strings.Index(link, ">")])
+		assert.Equal(t, []string{"Chess Club"}, names)
+		assert.Empty(t, w.Header().Get("Link"))
+
+		names, _ = list("/clubs?filter[name]=Choir")
+		assert.Equal(t, []string{"Choir"}, names)
+
+		for _, path := range []string{"/clubs?sort=password", "/clubs?filter[name][like]=Club", "/clubs?cursor=" + next, "/clubs?near=52.5,13.4&limit=2", "/clubs?page=2&per_page=2"} {
+			w = httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			s.Engine.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
 	t.Run("POSTS to /clubs cannot set the uid", func(t *testing.T) {
 		testhelpers.Clear(dbDriver)
 		w := httptest.NewRecorder()
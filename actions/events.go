@@ -2,65 +2,192 @@ package actions
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/alexmorten/events-api/db"
 
 	"github.com/alexmorten/events-api/models"
 	"github.com/gin-gonic/gin"
-	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"github.com/google/uuid"
 )
 
 //RegisterEventRoutes within the given router group
 func (h *ActionHandler) RegisterEventRoutes(group *gin.RouterGroup) {
+	group.Use(withRouteModel(&models.Event{}))
 	group.GET("/:uid", h.getEvent)
 	group.GET("", h.getEvents)
 	group.PATCH("/:uid", h.updateEvent)
 	group.POST("", h.postEvents)
 	group.DELETE("/:uid", h.deleteEvent)
+
+	group.POST("/:uid/attendance", h.postAttendance)
+	group.DELETE("/:uid/attendance", h.deleteAttendance)
+	group.GET("/:uid/attendees", h.getAttendees)
+
+	group.GET("/:uid/venue", h.getVenueOf)
+	group.PUT("/:uid/venue", h.putEventVenue)
+	group.DELETE("/:uid/venue", h.deleteEventVenue)
+
+	group.GET("/:uid/tags", h.getTagsOf)
+	group.PUT("/:uid/tags/:tag_uid", h.putEventTag)
+	group.DELETE("/:uid/tags/:tag_uid", h.deleteEventTag)
+
+	group.GET("/:uid/sports", h.getSportsOf)
+	group.PUT("/:uid/sports/:sport_uid", h.putEventSport)
+	group.DELETE("/:uid/sports/:sport_uid", h.deleteEventSport)
 }
 
 func (h *ActionHandler) getEvent(c *gin.Context) {
+	getFromRepository(c, h.repository(&models.Event{}))
+}
+
+//getEvents lists the events by start a page at a time (see listEvents), ?near=lat,lng&radius=km lists the events at venues within radius, nearest first
+func (h *ActionHandler) getEvents(c *gin.Context) {
+	near, radiusMeters, err := nearQueryParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if near == nil {
+		h.listEvents(c, "match (n:Event)", map[string]interface{}{})
+		return
+	}
+
+	h.listEventsOrderedBy(
+		c,
+		fmt.Sprintf(
+			"match (n:Event)-[:%v]->(venue:Venue) with n, distance(venue.location, $near) as distance where distance <= $radius",
+			models.EventOrClubAtVenue,
+		),
+		map[string]interface{}{"near": near.NeoPoint(), "radius": radiusMeters},
+		"distance",
+	)
+}
+
+//getHostedEvents lists the events of the club or group the route is about, including the events of all groups below it
+func (h *ActionHandler) getHostedEvents(c *gin.Context) {
 	uid := c.Param("uid")
 	if uid == "" {
 		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-
-	event, err := models.FindEvent(h.dbDriver, uid)
-	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
 		return
 	}
-	c.JSON(200, event)
+
+	h.listEvents(
+		c,
+		fmt.Sprintf(
+			"match (n:Event)-[:%v]->()-[:%v*0..10]->(:%v {uid: $uid}) with distinct n",
+			models.EventHostedByGroupOrClub,
+			models.GroupBelongsToGroupOrClub,
+			routeLabel(c),
+		),
+		map[string]interface{}{"uid": uid},
+	)
 }
 
-func (h *ActionHandler) getEvents(c *gin.Context) {
-	events := []*models.Event{}
+//listEvents responds with the events matched as n by the match clause, narrowed down to the time window given by the from and to query params.
+//Events are listed by start a page at a time, ?limit, ?cursor, ?sort and ?filter page, order and filter them.
+//Given both from and to, recurring events are expanded into their occurrences within the window and all of them are listed
+func (h *ActionHandler) listEvents(c *gin.Context, match string, params map[string]interface{}) {
+	h.listEventsOrderedBy(c, match, params, startOrder)
+}
 
-	dbSession, err := h.dbDriver.Session(neo4j.AccessModeRead)
+//startOrder orders events by their start, occurrences of series included
+const startOrder = "n.starts_at"
+
+//listEventsOrderedBy is listEvents with an order expression, which can use all variables of the match clause
+func (h *ActionHandler) listEventsOrderedBy(c *gin.Context, match string, params map[string]interface{}, orderBy string) {
+	conditions := []string{}
+	from, err := timeQueryParam(c, "from")
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
-	defer dbSession.Close()
+	if !from.IsZero() {
+		conditions = append(conditions, "((n.rrule is null and coalesce(n.ends_at, n.starts_at) >= $from) or (n.rrule is not null and coalesce(n.recurrence_ends_at, $from) >= $from))")
+		params["from"] = db.NeoDateTime(from)
+	}
+	to, err := timeQueryParam(c, "to")
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "n.starts_at < $to")
+		params["to"] = db.NeoDateTime(to)
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		c.AbortWithError(http.StatusBadRequest, errors.New("to has to be after from"))
+		return
+	}
+	filterConditions, err := listFilterConditions(c, params)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	conditions = append(conditions, filterConditions...)
+	page, err := pageQueryParams(c, &models.Event{}, db.SortKey{Field: "starts_at"})
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	expand := !from.IsZero() && !to.IsZero()
+	repository := h.repository(&models.Event{})
+	query := db.ListQuery{Match: match, Conditions: conditions, Params: params}
+	if orderBy == startOrder && !expand {
+		respondWithPage(c, repository, query, page)
+		return
+	}
+	// occurrences and distances aren't fields of events, these lists are complete and can only be filtered
+	if asksForPage(c) {
+		c.AbortWithError(http.StatusBadRequest, errPageWithCustomOrder)
+		return
+	}
+	query.Conditions = append(conditions, page.FilterConditions(params)...)
+	query.OrderBy = orderBy
 
-	records, err := neo4j.Collect(dbSession.Run("match (n:Event) return properties(n)", nil))
+	found, err := repository.List(query)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-	for _, record := range records {
-		propInterface, ok := record.Get("properties(n)")
-		if ok {
-			props, ok := propInterface.(map[string]interface{})
-			if ok {
-				events = append(events, models.EventFromProps(props))
-			}
+	events := []*models.Event{}
+	for _, event := range found {
+		events = append(events, event.(*models.Event))
+	}
+
+	if expand {
+		events, err = expandOccurrences(events, from, to)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if orderBy == startOrder {
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].StartsAt.Before(events[j].StartsAt)
+			})
 		}
 	}
+	setTotalCount(c, int64(len(events)))
 	c.JSON(http.StatusOK, events)
 }
 
+//expandOccurrences replaces recurring events with their occurrences within [from, to), keeping the order of the events
+func expandOccurrences(events []*models.Event, from, to time.Time) ([]*models.Event, error) {
+	occurrences := []*models.Event{}
+	for _, event := range events {
+		eventOccurrences, err := event.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, eventOccurrences...)
+	}
+	return occurrences, nil
+}
+
 func (h *ActionHandler) postEvents(c *gin.Context) {
 	currentUserClaim := h.currentUserClaim(c)
 	if currentUserClaim == nil {
@@ -68,6 +195,55 @@ func (h *ActionHandler) postEvents(c *gin.Context) {
 		return
 	}
 
+	h.createEvent(c, nil)
+}
+
+//postClubEvents creates an event hosted by the club
+func (h *ActionHandler) postClubEvents(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	uid := c.Param("uid")
+	club, err := models.FindClub(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !club.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	h.createEvent(c, &club.UID)
+}
+
+//postGroupEvents creates an event hosted by the group
+func (h *ActionHandler) postGroupEvents(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	uid := c.Param("uid")
+	group, err := models.FindGroup(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !group.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	h.createEvent(c, &group.UID)
+}
+
+//createEvent from the request body, hosted by the club or group with the given uid unless hostUID is nil
+func (h *ActionHandler) createEvent(c *gin.Context, hostUID *uuid.UUID) {
 	event := models.NewEvent()
 	eventAttributes := &models.EventAttributes{}
 	err := c.ShouldBindJSON(eventAttributes)
@@ -75,21 +251,57 @@ func (h *ActionHandler) postEvents(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	err = eventAttributes.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	eventAttributes.Normalize()
 	event.EventAttributes = *eventAttributes
-	props, err := db.CreateBy(h.dbDriver, event, currentUserClaim.UID)
+	var createdEvent db.Model
+	err = db.Transact(h.dbDriver, func(tx *db.Tx) error {
+		props, err := tx.CreateBy(event, h.currentUserClaim(c).UID)
+		if err != nil {
+			return err
+		}
+		createdEvent = h.repository(event).FromProps(props)
+		if hostUID != nil {
+			_, err = tx.CreateRelation(event.UID, *hostUID, models.EventHostedByGroupOrClub)
+		}
+		return err
+	})
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	createdEvent := models.EventFromProps(props)
 	c.JSON(http.StatusCreated, createdEvent)
 }
 
 type eventAttributesUpdate struct {
-	Name *string `json:"name"`
+	Name     *string    `json:"name"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	TimeZone *string    `json:"time_zone"`
+	AllDay   *bool      `json:"all_day"`
+	Capacity *int       `json:"capacity"`
+
+	SkillLevel *string `json:"skill_level"`
+	PriceCents *int    `json:"price_cents"`
+
+	RecurrenceRule *string      `json:"rrule"`
+	ExDates        *[]time.Time `json:"exdates"`
+	RDates         *[]time.Time `json:"rdates"`
 }
 
+const (
+	//editAllOccurrences of a recurring event, the default
+	editAllOccurrences = "all"
+	//editThisOccurrence detaches a single occurrence from its series
+	editThisOccurrence = "this"
+	//editFollowingOccurrences splits the series into two at the given occurrence
+	editFollowingOccurrences = "following"
+)
+
 func (h *ActionHandler) updateEvent(c *gin.Context) {
 	currentUserClaim := h.currentUserClaim(c)
 	if currentUserClaim == nil {
@@ -97,15 +309,13 @@ func (h *ActionHandler) updateEvent(c *gin.Context) {
 		return
 	}
 
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-	event, err := models.FindEvent(h.dbDriver, uid)
+	events := h.repository(&models.Event{})
+	found, err := events.Get(c.Param("uid"))
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
+	event := found.(*models.Event)
 
 	canEdit := event.CanBeEditedBy(h.dbDriver, currentUserClaim.UID)
 	if !canEdit {
@@ -120,15 +330,93 @@ func (h *ActionHandler) updateEvent(c *gin.Context) {
 		return
 	}
 
+	scope := c.DefaultQuery("scope", editAllOccurrences)
+	if scope != editAllOccurrences {
+		h.updateEventOccurrences(c, event, scope, updateAttributes)
+		return
+	}
+
 	models.UpdateFrom(&event.EventAttributes, updateAttributes)
+	err = event.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	event.Normalize()
 
-	eventProps, err := db.Save(h.dbDriver, event)
+	updatedEvent, err := events.Update(event)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	if updateAttributes.Capacity != nil {
+		err = models.PromoteWaitlistedAttendees(h.dbDriver, event.UID)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
 
-	c.JSON(200, models.EventFromProps(eventProps))
+	c.JSON(http.StatusOK, updatedEvent)
+}
+
+//updateEventOccurrences edits a single occurrence or all following occurrences of a recurring event
+//by splitting them off into a new event
+func (h *ActionHandler) updateEventOccurrences(c *gin.Context, series *models.Event, scope string, updateAttributes *eventAttributesUpdate) {
+	recurrenceID, err := timeQueryParam(c, "recurrence_id")
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if recurrenceID.IsZero() {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("recurrence_id is required when editing %v occurrences", scope))
+		return
+	}
+
+	var edited *models.Event
+	switch scope {
+	case editThisOccurrence:
+		if updateAttributes.RecurrenceRule != nil || updateAttributes.ExDates != nil || updateAttributes.RDates != nil {
+			c.AbortWithError(http.StatusBadRequest, errors.New("a single occurrence can't be made recurring"))
+			return
+		}
+		edited, err = series.DetachOccurrence(recurrenceID)
+	case editFollowingOccurrences:
+		edited, err = series.SplitAt(recurrenceID)
+	default:
+		err = fmt.Errorf("unknown scope %q", scope)
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	models.UpdateFrom(&edited.EventAttributes, updateAttributes)
+	err = edited.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	edited.Normalize()
+
+	var props map[string]interface{}
+	err = db.Transact(h.dbDriver, func(tx *db.Tx) error {
+		_, err := tx.Save(series)
+		if err != nil {
+			return err
+		}
+		props, err = tx.CreateBy(edited, h.currentUserClaim(c).UID)
+		if err != nil {
+			return err
+		}
+		return models.CopyEventHost(tx, series.UID, edited.UID)
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.repository(edited).FromProps(props))
 }
 
 func (h *ActionHandler) deleteEvent(c *gin.Context) {
@@ -144,7 +432,7 @@ func (h *ActionHandler) deleteEvent(c *gin.Context) {
 	}
 	event, err := models.FindEvent(h.dbDriver, uid)
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
 
@@ -154,7 +442,7 @@ func (h *ActionHandler) deleteEvent(c *gin.Context) {
 		return
 	}
 
-	err = db.DeleteNode(h.dbDriver, uid)
+	err = db.DeleteNode(h.dbDriver, event, uid)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -162,3 +450,17 @@ func (h *ActionHandler) deleteEvent(c *gin.Context) {
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+//timeQueryParam parses the RFC 3339 query parameter with the given name, returning the zero time if it is absent
+func timeQueryParam(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v has to be a RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
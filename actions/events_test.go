@@ -24,7 +24,8 @@ func Test_Events(t *testing.T) {
 	config := api.DefaultServerConfig()
 	dbDriver := db.Driver(config.Neo4jAddress)
 	s := api.NewServer(config)
-	s.Init()
+	require.NoError(t, s.Init())
+	defer s.Close()
 	t.Run("unauthorized requests return 401", func(t *testing.T) {
 		testhelpers.Clear(dbDriver)
 
@@ -139,4 +140,212 @@ func Test_Events(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, foundEvent)
 	})
+	t.Run("can create an event with a schedule", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		w := httptest.NewRecorder()
+		body := `{"name":"training", "starts_at": "2019-04-02T18:00:00+02:00", "ends_at": "2019-04-02T20:00:00+02:00", "time_zone": "Europe/Berlin"}`
+		reader := bytes.NewReader([]byte(body))
+		req, _ := http.NewRequest("POST", "/events", reader)
+
+		testhelpers.AddSomeAuthorization(dbDriver, req)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		event := &models.Event{}
+		err := json.Unmarshal(w.Body.Bytes(), event)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", event.TimeZone)
+		assert.True(t, time.Date(2019, 4, 2, 16, 0, 0, 0, time.UTC).Equal(event.StartsAt))
+		assert.True(t, time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC).Equal(event.EndsAt))
+	})
+
+	t.Run("rejects invalid schedules", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		for _, body := range []string{
+			`{"name":"backwards", "starts_at": "2019-04-02T18:00:00Z", "ends_at": "2019-04-02T17:00:00Z"}`,
+			`{"name":"unknown zone", "starts_at": "2019-04-02T18:00:00Z", "time_zone": "Mars/Olympus_Mons"}`,
+		} {
+			w := httptest.NewRecorder()
+			reader := bytes.NewReader([]byte(body))
+			req, _ := http.NewRequest("POST", "/events", reader)
+
+			testhelpers.AddSomeAuthorization(dbDriver, req)
+			s.Engine.ServeHTTP(w, req)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("can get events within a time window", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		for i, name := range []string{"first", "second", "third"} {
+			event := models.NewEvent()
+			event.Name = name
+			event.StartsAt = time.Date(2019, 4, 1+i*7, 18, 0, 0, 0, time.UTC)
+			event.EndsAt = event.StartsAt.Add(2 * time.Hour)
+			_, err := db.CreateBy(dbDriver, event, user.UID)
+			require.NoError(t, err)
+		}
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/events?from=2019-04-01T19:00:00Z&to=2019-04-09T00:00:00Z", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		events := &[]models.Event{}
+		err := json.Unmarshal(w.Body.Bytes(), events)
+		require.NoError(t, err)
+		require.Len(t, *events, 2)
+		assert.Equal(t, "first", (*events)[0].Name)
+		assert.Equal(t, "second", (*events)[1].Name)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/events?from=yesterday", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+	t.Run("expands recurring events within a time window", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		event := models.NewEvent()
+		event.Name = "weekly training"
+		event.StartsAt = time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+		event.EndsAt = event.StartsAt.Add(2 * time.Hour)
+		event.RecurrenceRule = "FREQ=WEEKLY"
+		event.Normalize()
+		_, err := db.CreateBy(dbDriver, event, user.UID)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/events?from=2019-04-08T00:00:00Z&to=2019-04-30T00:00:00Z", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		events := &[]models.Event{}
+		err = json.Unmarshal(w.Body.Bytes(), events)
+		require.NoError(t, err)
+		require.Len(t, *events, 3)
+		assert.Equal(t, event.UID, (*events)[0].UID)
+		assert.True(t, time.Date(2019, 4, 9, 18, 0, 0, 0, time.UTC).Equal(*(*events)[0].RecurrenceID))
+	})
+
+	t.Run("can edit a single occurrence of a recurring event", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		event := models.NewEvent()
+		event.Name = "weekly training"
+		event.StartsAt = time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+		event.EndsAt = event.StartsAt.Add(2 * time.Hour)
+		event.RecurrenceRule = "FREQ=WEEKLY;COUNT=3"
+		event.Normalize()
+		_, err := db.CreateBy(dbDriver, event, user.UID)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		body := `{"name":"moved training", "starts_at": "2019-04-10T18:00:00Z", "ends_at": "2019-04-10T20:00:00Z"}`
+		reader := bytes.NewReader([]byte(body))
+		req, _ := http.NewRequest("PATCH", "/events/"+event.UID.String()+"?scope=this&recurrence_id=2019-04-09T18:00:00Z", reader)
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/events?from=2019-04-01T00:00:00Z&to=2019-04-30T00:00:00Z", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		events := &[]models.Event{}
+		err = json.Unmarshal(w.Body.Bytes(), events)
+		require.NoError(t, err)
+		require.Len(t, *events, 3)
+		assert.Equal(t, "weekly training", (*events)[0].Name)
+		assert.Equal(t, "moved training", (*events)[1].Name)
+		assert.Nil(t, (*events)[1].RecurrenceID)
+		assert.Equal(t, "weekly training", (*events)[2].Name)
+	})
+
+	t.Run("club and group admins can create events hosted by them", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		clubAdmin := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, clubAdmin.UID))
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/clubs/"+club.UID.String()+"/events", bytes.NewReader([]byte(`{"name":"general assembly"}`)))
+		testhelpers.AddSomeAuthorization(dbDriver, req)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/clubs/"+club.UID.String()+"/events", bytes.NewReader([]byte(`{"name":"general assembly"}`)))
+		testhelpers.AddAuthorizationHeader(req, clubAdmin)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/groups/"+group.UID.String()+"/events", bytes.NewReader([]byte(`{"name":"training"}`)))
+		testhelpers.AddAuthorizationHeader(req, clubAdmin)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/clubs/"+club.UID.String()+"/events", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		events := &[]models.Event{}
+		err = json.Unmarshal(w.Body.Bytes(), events)
+		require.NoError(t, err)
+		assert.Len(t, *events, 2)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/groups/"+group.UID.String()+"/events", nil)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		events = &[]models.Event{}
+		err = json.Unmarshal(w.Body.Bytes(), events)
+		require.NoError(t, err)
+		require.Len(t, *events, 1)
+		assert.Equal(t, "training", (*events)[0].Name)
+	})
+
+	t.Run("admins of the hosting group can edit events they didn't create", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		creator := testhelpers.CreateSomeUser(dbDriver)
+		groupAdmin := testhelpers.CreateSomeUser(dbDriver)
+		parentGroup := models.NewGroup()
+		_, err := db.Save(dbDriver, parentGroup)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToGroup(dbDriver, parentGroup.UID, groupAdmin.UID))
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, parentGroup.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+
+		event := models.NewEvent()
+		event.Name = "Before"
+		_, err = db.CreateBy(dbDriver, event, creator.UID)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, event.UID, group.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/events/"+event.UID.String(), bytes.NewReader([]byte(`{"name":"After"}`)))
+		testhelpers.AddSomeAuthorization(dbDriver, req)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PATCH", "/events/"+event.UID.String(), bytes.NewReader([]byte(`{"name":"After"}`)))
+		testhelpers.AddAuthorizationHeader(req, groupAdmin)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
 }
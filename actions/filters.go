@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tagsMatchAll = "all"
+	tagsMatchAny = "any"
+)
+
+//listFilterConditions reads the ?tags and ?sport filters of list endpoints and returns the conditions on n they ask for
+func listFilterConditions(c *gin.Context, params map[string]interface{}) ([]string, error) {
+	conditions := []string{}
+	condition, err := tagCondition(c, params)
+	if err != nil {
+		return nil, err
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+	if condition := sportCondition(c, params); condition != "" {
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+//tagCondition reads ?tags=a,b and ?tags_match=all|any and returns a condition on n that is met by nodes tagged with all (the default)
+//or any of the tags, it is empty if no tags are given
+func tagCondition(c *gin.Context, params map[string]interface{}) (string, error) {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range listQueryParam(c, "tags") {
+		name = models.NormalizeTagName(name)
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	tagsMatch := c.DefaultQuery("tags_match", tagsMatchAll)
+	if tagsMatch != tagsMatchAll && tagsMatch != tagsMatchAny {
+		return "", fmt.Errorf("tags_match has to be %v or %v", tagsMatchAll, tagsMatchAny)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	params["tags"] = names
+	matching := fmt.Sprintf("size([(n)-[:%v]->(tag:Tag) where tag.name in $tags | tag])", models.NodeTaggedWithTag)
+	if tagsMatch == tagsMatchAny {
+		return matching + " > 0", nil
+	}
+	return matching + " = size($tags)", nil
+}
+
+//sportCondition reads ?sport=uid,uid and returns a condition on n that is met by events about any of the sports
+//and clubs and groups offering any of them, it is empty if no sports are given
+func sportCondition(c *gin.Context, params map[string]interface{}) string {
+	sportUIDs := listQueryParam(c, "sport")
+	if len(sportUIDs) == 0 {
+		return ""
+	}
+
+	params["sport_uids"] = sportUIDs
+	return fmt.Sprintf(
+		"size([(n)-[:%v|%v]->(sport:Sport) where sport.uid in $sport_uids | sport]) > 0",
+		models.EventIsSport, models.ClubOrGroupOffersSport,
+	)
+}
+
+//listQueryParam splits a comma separated query parameter, it is nil if the parameter isn't given
+func listQueryParam(c *gin.Context, name string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
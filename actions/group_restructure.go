@@ -0,0 +1,142 @@
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type groupParentAttributes struct {
+	ParentUID uuid.UUID `json:"parent_uid"`
+}
+
+type groupMergeAttributes struct {
+	TargetUID uuid.UUID `json:"target_uid"`
+}
+
+//updateGroupParent moves the group and all groups below it below another group or club
+func (h *ActionHandler) updateGroupParent(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	attributes := &groupParentAttributes{}
+	err = c.ShouldBindJSON(attributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	parent, err := models.FindGroupOrClub(h.dbDriver, attributes.ParentUID.String())
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if parent == nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("parent has to be an existing group or club"))
+		return
+	}
+
+	if !h.canRestructure(c, currentUserClaim, group, parent) {
+		return
+	}
+
+	err = models.MoveGroup(h.dbDriver, group.UID, parent.GetUID())
+	if err != nil {
+		h.abortWithRestructureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+//mergeGroup moves everything belonging to the group into the target group and deletes it
+func (h *ActionHandler) mergeGroup(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	attributes := &groupMergeAttributes{}
+	err = c.ShouldBindJSON(attributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	groupOrClub, err := models.FindGroupOrClub(h.dbDriver, attributes.TargetUID.String())
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	target, isGroup := groupOrClub.(*models.Group)
+	if !isGroup {
+		c.AbortWithError(http.StatusBadRequest, errors.New("target has to be an existing group"))
+		return
+	}
+
+	if !h.canRestructure(c, currentUserClaim, group, target) {
+		return
+	}
+
+	err = models.MergeGroups(h.dbDriver, group.UID, target.UID)
+	if err != nil {
+		h.abortWithRestructureError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, target)
+}
+
+//canRestructure checks that the user administers both the group and its new place.
+//Only global admins can move groups from one club to another
+func (h *ActionHandler) canRestructure(c *gin.Context, currentUserClaim *models.UserClaim, group *models.Group, destination models.GroupOrClub) bool {
+	if !group.AdministeredByUser(h.dbDriver, currentUserClaim.UID) || !destination.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return false
+	}
+	if currentUserClaim.Admin {
+		return true
+	}
+
+	currentClub, err := models.FindClubOf(h.dbDriver, group.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+	destinationClub, err := models.FindClubOf(h.dbDriver, destination.GetUID())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return false
+	}
+	if currentClub == nil || destinationClub == nil || currentClub.UID != destinationClub.UID {
+		c.AbortWithError(http.StatusForbidden, errors.New("groups can only be moved to another club by admins"))
+		return false
+	}
+	return true
+}
+
+func (h *ActionHandler) abortWithRestructureError(c *gin.Context, err error) {
+	switch err {
+	case models.ErrGroupNotFound:
+		c.AbortWithError(http.StatusNotFound, err)
+	case models.ErrGroupCycle, models.ErrGroupTooDeep:
+		c.AbortWithError(http.StatusConflict, err)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,168 @@
+package actions_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_GroupRestructuring(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path, body string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	createGroup := func(t *testing.T, parentUID uuid.UUID) *models.Group {
+		group := models.NewGroup()
+		_, err := db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, parentUID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		return group
+	}
+
+	createClub := func(t *testing.T, admin *models.User) *models.Club {
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, admin.UID))
+		return club
+	}
+
+	t.Run("groups can be moved within their club but not below themselves", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		club := createClub(t, admin)
+		youth := createGroup(t, club.UID)
+		under12 := createGroup(t, youth.UID)
+		seniors := createGroup(t, club.UID)
+
+		w := request("PATCH", "/groups/"+youth.UID.String()+"/parent", `{"parent_uid":"`+seniors.UID.String()+`"}`, testhelpers.CreateSomeUser(dbDriver))
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request("PATCH", "/groups/"+youth.UID.String()+"/parent", `{"parent_uid":"`+seniors.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		ancestors, err := models.FindAncestors(dbDriver, under12.UID)
+		require.NoError(t, err)
+		require.Len(t, ancestors, 3)
+		assert.Equal(t, seniors.UID, ancestors[1].UID)
+
+		w = request("PATCH", "/groups/"+seniors.UID.String()+"/parent", `{"parent_uid":"`+under12.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusConflict, w.Code)
+		w = request("PATCH", "/groups/"+seniors.UID.String()+"/parent", `{"parent_uid":"`+seniors.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("only global admins can move groups to another club", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		group := createGroup(t, createClub(t, admin).UID)
+		otherClub := createClub(t, admin)
+
+		w := request("PATCH", "/groups/"+group.UID.String()+"/parent", `{"parent_uid":"`+otherClub.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request("PATCH", "/groups/"+group.UID.String()+"/parent", `{"parent_uid":"`+otherClub.UID.String()+`"}`, testhelpers.CreateAdminUser(dbDriver))
+		require.Equal(t, http.StatusOK, w.Code)
+		club, err := models.FindClubOf(dbDriver, group.UID)
+		require.NoError(t, err)
+		assert.Equal(t, otherClub.UID, club.UID)
+	})
+
+	t.Run("merging moves everything into the target group", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		club := createClub(t, admin)
+		source := createGroup(t, club.UID)
+		target := createGroup(t, club.UID)
+		child := createGroup(t, source.UID)
+
+		groupAdmin := testhelpers.CreateSomeUser(dbDriver)
+		require.NoError(t, models.AddAdminToGroup(dbDriver, source.UID, groupAdmin.UID))
+		member := testhelpers.CreateSomeUser(dbDriver)
+		_, err := models.RequestMembership(dbDriver, member.UID, source)
+		require.NoError(t, err)
+		_, err = models.ApproveMembership(dbDriver, member.UID, source, models.MembershipRoleCoach)
+		require.NoError(t, err)
+		_, err = models.RequestMembership(dbDriver, member.UID, target)
+		require.NoError(t, err)
+		event := models.NewEvent()
+		_, err = db.CreateBy(dbDriver, event, admin.UID)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, event.UID, source.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+		tag := models.NewTag()
+		tag.Name = "beginners"
+		_, err = db.Save(dbDriver, tag)
+		require.NoError(t, err)
+		require.NoError(t, models.AddTag(dbDriver, source.UID, "Group", tag.UID))
+		sport := models.NewSport()
+		sport.Name = "rowing"
+		_, err = db.Save(dbDriver, sport)
+		require.NoError(t, err)
+		require.NoError(t, models.AddSport(dbDriver, source.UID, "Group", sport.UID))
+		venue := models.NewVenue()
+		venue.Location = &db.Point{Latitude: 52.52, Longitude: 13.4}
+		_, err = db.Save(dbDriver, venue)
+		require.NoError(t, err)
+		require.NoError(t, models.SetVenue(dbDriver, source.UID, "Group", venue.UID))
+
+		w := request("POST", "/groups/"+source.UID.String()+"/merge", `{"target_uid":"`+child.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusConflict, w.Code)
+		w = request("POST", "/groups/"+source.UID.String()+"/merge", `{"target_uid":"`+club.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = request("POST", "/groups/"+source.UID.String()+"/merge", `{"target_uid":"`+target.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err = models.FindGroup(dbDriver, source.UID.String())
+		assert.Error(t, err)
+		descendants, err := models.FindDescendants(dbDriver, target, models.MaxGroupDepth)
+		require.NoError(t, err)
+		require.Len(t, descendants, 1)
+		assert.Equal(t, child.UID, descendants[0].UID)
+		assert.True(t, target.AdministeredByUser(dbDriver, groupAdmin.UID))
+		members, total, err := models.FindMembers(dbDriver, target, models.MembershipActive, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, models.MembershipRoleCoach, members[0].Role)
+		events, err := models.FindEventsHostedWithin(dbDriver, target.UID.String())
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, event.UID, events[0].UID)
+		tags, err := models.FindTagsOf(dbDriver, target.UID, "Group")
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		assert.Equal(t, tag.UID, tags[0].UID)
+		sports, err := models.FindSportsOf(dbDriver, target.UID, "Group")
+		require.NoError(t, err)
+		require.Len(t, sports, 1)
+		assert.Equal(t, sport.UID, sports[0].UID)
+		targetVenue, err := models.FindVenueOf(dbDriver, target.UID, "Group")
+		require.NoError(t, err)
+		require.NotNil(t, targetVenue)
+		assert.Equal(t, venue.UID, targetVenue.UID)
+	})
+}
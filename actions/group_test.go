@@ -25,7 +25,8 @@ func Test_Groups(t *testing.T) {
 	config := api.DefaultServerConfig()
 	dbDriver := db.Driver(config.Neo4jAddress)
 	s := api.NewServer(config)
-	s.Init()
+	require.NoError(t, s.Init())
+	defer s.Close()
 
 	t.Run("admins can create and get a group inside a club", func(t *testing.T) {
 		testhelpers.Clear(dbDriver)
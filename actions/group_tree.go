@@ -0,0 +1,83 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+//getClubTree returns the club with all groups below it nested, ?depth limits how many levels are included
+func (h *ActionHandler) getClubTree(c *gin.Context) {
+	depth, err := depthQueryParam(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	club, err := models.FindClub(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	tree, err := models.FindClubTree(h.dbDriver, club, depth)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+//getGroupAncestors returns the groups and the club above the group, starting at the top
+func (h *ActionHandler) getGroupAncestors(c *gin.Context) {
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	ancestors, err := models.FindAncestors(h.dbDriver, group.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, ancestors)
+}
+
+//getGroupDescendants returns all groups below the group as a flat list ordered by depth, ?depth limits how many levels are included
+func (h *ActionHandler) getGroupDescendants(c *gin.Context) {
+	depth, err := depthQueryParam(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	group, err := models.FindGroup(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	descendants, err := models.FindDescendants(h.dbDriver, group, depth)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, descendants)
+}
+
+func depthQueryParam(c *gin.Context) (int, error) {
+	value := c.Query("depth")
+	if value == "" {
+		return models.MaxGroupDepth, nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth < 1 || depth > models.MaxGroupDepth {
+		return 0, fmt.Errorf("depth has to be a number between 1 and %v", models.MaxGroupDepth)
+	}
+	return depth, nil
+}
@@ -0,0 +1,99 @@
+package actions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_GroupTree(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	get := func(t *testing.T, path string, result interface{}) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		s.Engine.ServeHTTP(w, req)
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+		}
+		return w.Code
+	}
+
+	createGroup := func(t *testing.T, name string, parentUID uuid.UUID) *models.Group {
+		group := models.NewGroup()
+		group.Name = name
+		_, err := db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, parentUID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		return group
+	}
+
+	testhelpers.Clear(dbDriver)
+	club := models.NewClub()
+	club.Name = "FC Example"
+	_, err := db.Save(dbDriver, club)
+	require.NoError(t, err)
+	youth := createGroup(t, "youth", club.UID)
+	seniors := createGroup(t, "seniors", club.UID)
+	under12 := createGroup(t, "under 12", youth.UID)
+	goalkeepers := createGroup(t, "goalkeepers", under12.UID)
+
+	t.Run("club trees contain all nested groups", func(t *testing.T) {
+		tree := &models.ClubTree{}
+		require.Equal(t, http.StatusOK, get(t, "/clubs/"+club.UID.String()+"/tree", tree))
+		assert.Equal(t, club.UID, tree.UID)
+		require.Len(t, tree.Groups, 2)
+		assert.Equal(t, seniors.UID, tree.Groups[0].UID)
+		assert.Equal(t, youth.UID, tree.Groups[1].UID)
+		require.Len(t, tree.Groups[1].Groups, 1)
+		assert.Equal(t, under12.UID, tree.Groups[1].Groups[0].UID)
+		require.Len(t, tree.Groups[1].Groups[0].Groups, 1)
+		assert.Equal(t, goalkeepers.UID, tree.Groups[1].Groups[0].Groups[0].UID)
+
+		tree = &models.ClubTree{}
+		require.Equal(t, http.StatusOK, get(t, "/clubs/"+club.UID.String()+"/tree?depth=1", tree))
+		require.Len(t, tree.Groups, 2)
+		assert.Len(t, tree.Groups[1].Groups, 0)
+
+		assert.Equal(t, http.StatusBadRequest, get(t, "/clubs/"+club.UID.String()+"/tree?depth=0", tree))
+	})
+
+	t.Run("ancestors start at the club", func(t *testing.T) {
+		ancestors := []models.GroupOrClubReference{}
+		require.Equal(t, http.StatusOK, get(t, "/groups/"+goalkeepers.UID.String()+"/ancestors", &ancestors))
+		require.Len(t, ancestors, 3)
+		assert.Equal(t, club.UID, ancestors[0].UID)
+		assert.Equal(t, "Club", ancestors[0].Type)
+		assert.Equal(t, youth.UID, ancestors[1].UID)
+		assert.Equal(t, under12.UID, ancestors[2].UID)
+	})
+
+	t.Run("descendants are ordered by depth", func(t *testing.T) {
+		descendants := []models.Descendant{}
+		require.Equal(t, http.StatusOK, get(t, "/groups/"+youth.UID.String()+"/descendants", &descendants))
+		require.Len(t, descendants, 2)
+		assert.Equal(t, under12.UID, descendants[0].UID)
+		assert.Equal(t, youth.UID, descendants[0].ParentUID)
+		assert.Equal(t, int64(1), descendants[0].Depth)
+		assert.Equal(t, goalkeepers.UID, descendants[1].UID)
+		assert.Equal(t, int64(2), descendants[1].Depth)
+	})
+}
@@ -16,74 +16,78 @@ import (
 
 //RegisterGroupRoutes within the given router group
 func (h *ActionHandler) RegisterGroupRoutes(group *gin.RouterGroup) {
+	group.Use(withRouteModel(&models.Group{}))
 	group.GET("/:uid", h.getGroup)
 	group.GET("/:uid/groups", h.getGroups)
+	group.GET("/:uid/ancestors", h.getGroupAncestors)
+	group.GET("/:uid/descendants", h.getGroupDescendants)
 	group.PATCH("/:uid", h.updateGroup)
 	group.POST("/:uid/groups", h.postGroup)
 	group.DELETE("/:uid", h.deleteGroup)
+	group.PATCH("/:uid/parent", h.updateGroupParent)
+	group.POST("/:uid/merge", h.mergeGroup)
 
 	group.GET("/:uid/admins", h.getGroupAdmins)
 	group.POST("/:uid/admins", h.postGroupAdmins)
+	group.DELETE("/:uid/admins/:user_uid", h.deleteGroupAdmins)
+	group.GET("/:uid/admins/effective", h.getEffectiveAdmins)
+
+	h.registerMembershipRoutes(group)
+
+	group.GET("/:uid/events", h.getHostedEvents)
+	group.POST("/:uid/events", h.postGroupEvents)
+	group.POST("/:uid/events/import", h.importGroupEvents)
+
+	group.GET("/:uid/calendar.ics", h.getGroupCalendar)
+
+	group.GET("/:uid/tags", h.getTagsOf)
+	group.PUT("/:uid/tags/:tag_uid", h.putGroupTag)
+	group.DELETE("/:uid/tags/:tag_uid", h.deleteGroupTag)
+
+	group.GET("/:uid/sports", h.getSportsOf)
+	group.PUT("/:uid/sports/:sport_uid", h.putGroupSport)
+	group.DELETE("/:uid/sports/:sport_uid", h.deleteGroupSport)
 }
 
 func (h *ActionHandler) getGroup(c *gin.Context) {
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-		return
-	}
-
-	group, err := models.FindGroup(h.dbDriver, uid)
-	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
-		return
-	}
-	c.JSON(200, group)
+	getFromRepository(c, h.repository(&models.Group{}))
 }
 
+//getGroups lists the groups directly below a club or group by name, ?limit, ?cursor, ?sort and ?filter page, order and filter them
 func (h *ActionHandler) getGroups(c *gin.Context) {
-	groups := []*models.Group{}
-
 	uid := c.Param("uid")
 	if uid == "" {
 		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
 		return
 	}
 
-	parentGroup, _ := models.FindGroup(h.dbDriver, uid)
-	parentClub, _ := models.FindClub(h.dbDriver, uid)
-
-	if parentClub == nil && parentGroup == nil {
-		c.AbortWithError(http.StatusBadRequest, errors.New("no parent group or club found"))
+	parent, err := models.FindGroupOrClubWithLabel(h.dbDriver, routeLabel(c), uid)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if parent == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("no parent group or club found"))
 		return
 	}
 
-	dbSession, err := h.dbDriver.Session(neo4j.AccessModeRead)
+	page, err := pageQueryParams(c, &models.Group{}, db.SortKey{Field: "name"})
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
-	defer dbSession.Close()
-
-	records, err := neo4j.Collect(dbSession.Run(
-		fmt.Sprintf("match (n:Group)-[:%v]->(parent {uid: $uid}) return properties(n)", models.GroupBelongsToGroupOrClub),
-		map[string]interface{}{"uid": uid}))
+	params := map[string]interface{}{"uid": uid}
+	conditions, err := listFilterConditions(c, params)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		fmt.Println(err)
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 
-	for _, record := range records {
-		propInterface, ok := record.Get("properties(n)")
-		if ok {
-			props, ok := propInterface.(map[string]interface{})
-			if ok {
-				groups = append(groups, models.GroupFromProps(props))
-			}
-		}
-	}
-	c.JSON(http.StatusOK, groups)
+	respondWithPage(c, h.repository(&models.Group{}), db.ListQuery{
+		Match:      fmt.Sprintf("match (n:Group)-[:%v]->(:%v {uid: $uid})", models.GroupBelongsToGroupOrClub, parent.NodeName()),
+		Conditions: conditions,
+		Params:     params,
+	}, page)
 }
 
 func (h *ActionHandler) postGroup(c *gin.Context) {
@@ -104,43 +108,42 @@ func (h *ActionHandler) postGroup(c *gin.Context) {
 		return
 	}
 
-	parentGroup, _ := models.FindGroup(h.dbDriver, uid)
-	parentClub, _ := models.FindClub(h.dbDriver, uid)
-
-	if parentClub == nil && parentGroup == nil {
-		c.AbortWithError(http.StatusBadRequest, errors.New("no parent group or club found"))
+	parent, err := models.FindGroupOrClubWithLabel(h.dbDriver, routeLabel(c), uid)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-	var parentUID uuid.UUID
-	if parentClub != nil {
-		parentUID = parentClub.UID
-	}
-	if parentGroup != nil {
-		parentUID = parentGroup.UID
+	if parent == nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New("no parent group or club found"))
+		return
 	}
+	parentUID := parent.GetUID()
 
 	group := models.NewGroup()
 	groupAttributes := &models.GroupAttributes{}
-	err := c.ShouldBindJSON(groupAttributes)
+	err = c.ShouldBindJSON(groupAttributes)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
 	group.GroupAttributes = *groupAttributes
-	props, err := db.CreateBy(h.dbDriver, group, currentUserClaim.UID)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-	createdgroup := models.GroupFromProps(props)
+	var createdGroup db.Model
+	err = db.Transact(h.dbDriver, func(tx *db.Tx) error {
+		props, err := tx.CreateBy(group, currentUserClaim.UID)
+		if err != nil {
+			return err
+		}
+		createdGroup = h.repository(group).FromProps(props)
 
-	_, err = db.CreateRelation(h.dbDriver, createdgroup.UID, parentUID, models.GroupBelongsToGroupOrClub)
+		_, err = tx.CreateRelation(group.UID, parentUID, models.GroupBelongsToGroupOrClub)
+		return err
+	})
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdgroup)
+	c.JSON(http.StatusCreated, createdGroup)
 }
 
 type groupAttributesUpdate struct {
@@ -159,15 +162,13 @@ func (h *ActionHandler) updateGroup(c *gin.Context) {
 		return
 	}
 
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-	group, err := models.FindGroup(h.dbDriver, uid)
+	groups := h.repository(&models.Group{})
+	found, err := groups.Get(c.Param("uid"))
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
+	group := found.(*models.Group)
 
 	updateAttributes := &groupAttributesUpdate{}
 	err = c.ShouldBindJSON(updateAttributes)
@@ -178,13 +179,12 @@ func (h *ActionHandler) updateGroup(c *gin.Context) {
 
 	models.UpdateFrom(&group.GroupAttributes, updateAttributes)
 
-	groupProps, err := db.Save(h.dbDriver, group)
+	updatedGroup, err := groups.Update(group)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	c.JSON(200, models.GroupFromProps(groupProps))
+	c.JSON(http.StatusOK, updatedGroup)
 }
 
 func (h *ActionHandler) deleteGroup(c *gin.Context) {
@@ -205,11 +205,11 @@ func (h *ActionHandler) deleteGroup(c *gin.Context) {
 	}
 	group, err := models.FindGroup(h.dbDriver, uid)
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
 
-	err = db.DeleteNode(h.dbDriver, group.UID.String())
+	err = h.deleteIndexedNode(group, group.UID.String())
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -276,7 +276,7 @@ func (h *ActionHandler) postGroupAdmins(c *gin.Context) {
 	}
 	group, err := models.FindGroup(h.dbDriver, uid.String())
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
 
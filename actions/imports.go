@@ -0,0 +1,311 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//maxImportSize of uploaded calendars and spreadsheets in bytes
+const maxImportSize = 10 << 20
+
+//importRecord is a single event read from an uploaded file
+type importRecord struct {
+	sourceUID  string
+	attributes models.EventAttributes
+	err        error
+}
+
+type importResult struct {
+	SourceUID string     `json:"source_uid"`
+	Name      string     `json:"name"`
+	UID       *uuid.UUID `json:"uid,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+type importReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Created []importResult `json:"created"`
+	Updated []importResult `json:"updated"`
+	Skipped []importResult `json:"skipped"`
+}
+
+//importGroupEvents creates or updates the events of an iCalendar (text/calendar) or csv (text/csv) upload.
+//Events are matched on the uid they have in the uploaded file, so uploading the same file twice doesn't duplicate them
+func (h *ActionHandler) importGroupEvents(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	uid := c.Param("uid")
+	group, err := models.FindGroup(h.dbDriver, uid)
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !group.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	// one byte more than allowed is read to tell uploads that are too large from ones that are exactly the maximum size
+	upload, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxImportSize+1))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if len(upload) > maxImportSize {
+		c.AbortWithError(http.StatusRequestEntityTooLarge, fmt.Errorf("imports can't be larger than %v bytes", maxImportSize))
+		return
+	}
+	body := bytes.NewReader(upload)
+	var records []importRecord
+	switch c.ContentType() {
+	case "text/calendar":
+		records, err = importRecordsFromCalendar(body)
+	case "text/csv":
+		records, err = importRecordsFromCSV(body)
+	default:
+		c.AbortWithError(http.StatusUnsupportedMediaType, errors.New("only text/calendar and text/csv can be imported"))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	sourceUIDs := []string{}
+	for _, record := range records {
+		sourceUIDs = append(sourceUIDs, record.sourceUID)
+	}
+	existingEvents, err := models.QueryEvents(
+		h.dbDriver,
+		fmt.Sprintf("match (n:Event)-[:%v]->(g:Group {uid: $group_uid}) where n.source_uid in $source_uids return properties(n)", models.EventHostedByGroupOrClub),
+		map[string]interface{}{"group_uid": group.UID.String(), "source_uids": sourceUIDs},
+	)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	existingBySourceUID := map[string]*models.Event{}
+	for _, event := range existingEvents {
+		existingBySourceUID[event.SourceUID] = event
+	}
+
+	report := &importReport{
+		DryRun:  c.Query("dry_run") == "true",
+		Created: []importResult{},
+		Updated: []importResult{},
+		Skipped: []importResult{},
+	}
+	seen := map[string]bool{}
+	updated := []*models.Event{}
+	created := []*models.Event{}
+	for _, record := range records {
+		result := importResult{SourceUID: record.sourceUID, Name: record.attributes.Name}
+		if record.err == nil && seen[record.sourceUID] {
+			record.err = errors.New("uid appears more than once in the import")
+		}
+		if record.err == nil {
+			record.err = record.attributes.Validate()
+		}
+		if record.err != nil {
+			result.Reason = record.err.Error()
+			report.Skipped = append(report.Skipped, result)
+			continue
+		}
+		seen[record.sourceUID] = true
+		record.attributes.Normalize()
+
+		event, exists := existingBySourceUID[record.sourceUID]
+		if exists {
+			result.UID = &event.UID
+			// imported files don't contain a capacity, skill level or price, keep the ones set here
+			record.attributes.Capacity = event.Capacity
+			record.attributes.SkillLevel = event.SkillLevel
+			record.attributes.PriceCents = event.PriceCents
+			if event.EventAttributes.Equal(&record.attributes) {
+				result.Reason = "unchanged"
+				report.Skipped = append(report.Skipped, result)
+				continue
+			}
+			event.EventAttributes = record.attributes
+			updated = append(updated, event)
+			report.Updated = append(report.Updated, result)
+			continue
+		}
+
+		event = models.NewEvent()
+		event.EventAttributes = record.attributes
+		event.SourceUID = record.sourceUID
+		created = append(created, event)
+		if !report.DryRun {
+			result.UID = &event.UID
+		}
+		report.Created = append(report.Created, result)
+	}
+
+	if !report.DryRun {
+		// all or nothing, an import that fails halfway can be uploaded again as it is
+		err = db.Transact(h.dbDriver, func(tx *db.Tx) error {
+			for _, event := range updated {
+				_, err := tx.Save(event)
+				if err != nil {
+					return err
+				}
+			}
+			for _, event := range created {
+				_, err := tx.CreateBy(event, currentUserClaim.UID)
+				if err != nil {
+					return err
+				}
+				_, err = tx.CreateRelation(event.UID, group.UID, models.EventHostedByGroupOrClub)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func importRecordsFromCalendar(r io.Reader) ([]importRecord, error) {
+	parsedEvents, err := calendar.ParseEvents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []importRecord{}
+	for _, parsedEvent := range parsedEvents {
+		records = append(records, importRecord{
+			sourceUID:  parsedEvent.UID,
+			attributes: models.EventAttributesFromCalendar(parsedEvent.Event),
+			err:        parsedEvent.Err,
+		})
+	}
+	return records, nil
+}
+
+//importRecordsFromCSV reads a spreadsheet with a header row.
+//uid, name and starts_at columns are required, ends_at, time_zone, all_day and rrule are optional.
+//The uid is what re-imports are matched on, nothing else in a row stays the same when an event is renamed or moved
+func importRecordsFromCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	for _, required := range []string{"uid", "name", "starts_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv needs a %v column", required)
+		}
+	}
+
+	records := []importRecord{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record := importRecord{sourceUID: value("uid")}
+		record.attributes, record.err = eventAttributesFromCSV(value)
+		if record.err == nil && record.sourceUID == "" {
+			record.err = errors.New("uid can't be empty")
+		}
+		if record.err != nil {
+			record.err = fmt.Errorf("line %d: %v", line, record.err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func eventAttributesFromCSV(value func(column string) string) (models.EventAttributes, error) {
+	attributes := models.EventAttributes{
+		Name:           value("name"),
+		TimeZone:       value("time_zone"),
+		RecurrenceRule: value("rrule"),
+	}
+	location, err := attributes.Location()
+	if err != nil {
+		return attributes, err
+	}
+
+	if allDay := value("all_day"); allDay != "" {
+		attributes.AllDay, err = strconv.ParseBool(allDay)
+		if err != nil {
+			return attributes, fmt.Errorf("all_day has to be true or false")
+		}
+	}
+	attributes.StartsAt, err = parseCSVTime(value("starts_at"), location)
+	if err != nil {
+		return attributes, fmt.Errorf("starts_at: %v", err)
+	}
+	if endsAt := value("ends_at"); endsAt != "" {
+		attributes.EndsAt, err = parseCSVTime(endsAt, location)
+		if err != nil {
+			return attributes, fmt.Errorf("ends_at: %v", err)
+		}
+	}
+	return attributes, nil
+}
+
+var csvTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+//parseCSVTime accepts RFC 3339 timestamps and times without offset, which are interpreted in location
+func parseCSVTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't read %q as a time", value)
+}
@@ -0,0 +1,179 @@
+package actions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+type importReport struct {
+	DryRun  bool `json:"dry_run"`
+	Created []struct {
+		SourceUID string `json:"source_uid"`
+	} `json:"created"`
+	Updated []struct {
+		SourceUID string `json:"source_uid"`
+	} `json:"updated"`
+	Skipped []struct {
+		SourceUID string `json:"source_uid"`
+		Reason    string `json:"reason"`
+	} `json:"skipped"`
+}
+
+func Test_Imports(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	importFile := func(t *testing.T, user *models.User, group *models.Group, contentType, body, query string) (int, *importReport) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/groups/"+group.UID.String()+"/events/import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		testhelpers.AddAuthorizationHeader(req, user)
+		s.Engine.ServeHTTP(w, req)
+
+		report := &importReport{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), report))
+		}
+		return w.Code, report
+	}
+
+	setup := func(t *testing.T) (*models.User, *models.Group) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		group := models.NewGroup()
+		_, err := db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, user.UID, group.UID, models.UserAdministersGroupOrClub)
+		require.NoError(t, err)
+		return user, group
+	}
+
+	ics := func(summary string) string {
+		return strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"BEGIN:VEVENT",
+			"UID:training@example.com",
+			"SUMMARY:" + summary,
+			"DTSTART;TZID=Europe/Berlin:20190326T180000",
+			"DTEND;TZID=Europe/Berlin:20190326T200000",
+			"RRULE:FREQ=WEEKLY;COUNT=3",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:broken@example.com",
+			"SUMMARY:broken",
+			"DTSTART:not a date",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\r\n")
+	}
+
+	t.Run("importing a calendar twice updates the events instead of duplicating them", func(t *testing.T) {
+		user, group := setup(t)
+
+		code, report := importFile(t, user, group, "text/calendar", ics("training"), "")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, report.Created, 1)
+		assert.Equal(t, "training@example.com", report.Created[0].SourceUID)
+		require.Len(t, report.Skipped, 1)
+		assert.Equal(t, "broken@example.com", report.Skipped[0].SourceUID)
+
+		code, report = importFile(t, user, group, "text/calendar", ics("training"), "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.Created, 0)
+		assert.Len(t, report.Updated, 0)
+		assert.Len(t, report.Skipped, 2)
+
+		code, report = importFile(t, user, group, "text/calendar", ics("advanced training"), "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.Created, 0)
+		assert.Len(t, report.Updated, 1)
+
+		events, err := models.FindEventsHostedWithin(dbDriver, group.UID.String())
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "advanced training", events[0].Name)
+		assert.Equal(t, "Europe/Berlin", events[0].TimeZone)
+		assert.Equal(t, "FREQ=WEEKLY;COUNT=3", events[0].RecurrenceRule)
+	})
+
+	t.Run("dry runs don't write anything", func(t *testing.T) {
+		user, group := setup(t)
+
+		code, report := importFile(t, user, group, "text/calendar", ics("training"), "?dry_run=true")
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, report.DryRun)
+		assert.Len(t, report.Created, 1)
+
+		events, err := models.FindEventsHostedWithin(dbDriver, group.UID.String())
+		require.NoError(t, err)
+		assert.Len(t, events, 0)
+	})
+
+	t.Run("csv files can be imported", func(t *testing.T) {
+		user, group := setup(t)
+		csv := "uid,name,starts_at,ends_at,time_zone\n" +
+			"1,training,2019-04-02 18:00,2019-04-02 20:00,Europe/Berlin\n" +
+			"2,game,2019-04-06T15:00:00Z,,\n" +
+			"3,broken,yesterday,,\n" +
+			",no uid,2019-04-07T15:00:00Z,,\n"
+
+		code, report := importFile(t, user, group, "text/csv", csv, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.Created, 2)
+		assert.Len(t, report.Skipped, 2)
+
+		code, report = importFile(t, user, group, "text/csv", csv, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.Created, 0)
+		assert.Len(t, report.Skipped, 4)
+
+		// renamed rows are matched on their uid
+		code, report = importFile(t, user, group, "text/csv", strings.Replace(csv, "game", "final", 1), "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Len(t, report.Created, 0)
+		require.Len(t, report.Updated, 1)
+		assert.Equal(t, "2", report.Updated[0].SourceUID)
+
+		code, _ = importFile(t, user, group, "text/csv", "name,starts_at\ngame,2019-04-06T15:00:00Z\n", "")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("uploads larger than 10 MB are rejected", func(t *testing.T) {
+		user, group := setup(t)
+		csv := "uid,name,starts_at\n" + strings.Repeat("1,training,2019-04-02 18:00\n", 400000)
+
+		code, _ := importFile(t, user, group, "text/csv", csv, "")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	})
+
+	t.Run("only group admins can import and only calendars and csv files are accepted", func(t *testing.T) {
+		_, group := setup(t)
+		otherUser := testhelpers.CreateSomeUser(dbDriver)
+
+		code, _ := importFile(t, otherUser, group, "text/calendar", ics("training"), "")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		_, err := db.CreateRelation(dbDriver, admin.UID, group.UID, models.UserAdministersGroupOrClub)
+		require.NoError(t, err)
+		code, _ = importFile(t, admin, group, "application/json", "{}", "")
+		assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	})
+}
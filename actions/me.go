@@ -0,0 +1,146 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+//RegisterMeRoutes for the user the request is authenticated as
+func (h *ActionHandler) RegisterMeRoutes(group *gin.RouterGroup) {
+	group.GET("", h.getMe)
+	group.PATCH("", h.updateMe)
+	group.GET("/events", h.getMyEvents)
+	group.GET("/clubs", h.getMyClubs)
+	group.GET("/groups", h.getMyGroups)
+}
+
+//currentUser loads the user of the jwt, aborting the request if there is none
+func (h *ActionHandler) currentUser(c *gin.Context) *models.User {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil
+	}
+
+	user, err := models.FindUser(h.dbDriver, currentUserClaim.UID.String())
+	if err != nil {
+		abortWithFindError(c, err)
+		return nil
+	}
+	return user
+}
+
+func (h *ActionHandler) getMe(c *gin.Context) {
+	user := h.currentUser(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+type userProfileUpdate struct {
+	Name        *string `json:"name"`
+	NickName    *string `json:"nick_name"`
+	Description *string `json:"description"`
+	Location    *string `json:"location"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+func (h *ActionHandler) updateMe(c *gin.Context) {
+	user := h.currentUser(c)
+	if user == nil {
+		return
+	}
+
+	updateAttributes := &userProfileUpdate{}
+	err := c.ShouldBindJSON(updateAttributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	models.UpdateFrom(user, updateAttributes)
+
+	userProps, err := db.Save(h.dbDriver, user)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserFromProps(userProps))
+}
+
+//getMyEvents lists the events the current user created, attends or administers through the club or group hosting them,
+//each path matched on its own and their union made distinct.
+//Like /events it can be narrowed down to a time window with from and to
+func (h *ActionHandler) getMyEvents(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	h.listEvents(
+		c,
+		fmt.Sprintf(
+			`
+			match (u:User {uid: $user_uid})
+			optional match (created:Event)-[:%[1]v]->(u)
+			with u, collect(created) as created
+			optional match (u)-[attendance:%[2]v]->(attended:Event)
+			where coalesce(attendance.status, '') <> $declined
+			with u, created + collect(attended) as events
+			optional match (administered:Event)-[:%[3]v]->()-[:%[4]v*0..%[6]d]->()<-[:%[5]v]-(u)
+			with events + collect(administered) as events
+			unwind events as n
+			with distinct n
+			`,
+			models.ModelCreatedByUser,
+			models.UserAttendsEvent,
+			models.EventHostedByGroupOrClub,
+			models.GroupBelongsToGroupOrClub,
+			models.UserAdministersGroupOrClub,
+			models.MaxGroupDepth,
+		),
+		map[string]interface{}{
+			"user_uid": currentUserClaim.UID.String(),
+			"declined": models.AttendanceDeclined,
+		},
+	)
+}
+
+//getMyClubs lists the clubs the current user created, administers or is a member of
+func (h *ActionHandler) getMyClubs(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	clubs, err := models.FindClubsOfUser(h.dbDriver, currentUserClaim.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, clubs)
+}
+
+//getMyGroups lists the groups the current user created, administers or is a member of
+func (h *ActionHandler) getMyGroups(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	groups, err := models.FindGroupsOfUser(h.dbDriver, currentUserClaim.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
@@ -0,0 +1,140 @@
+package actions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Me(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path, body string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if user != nil {
+			testhelpers.AddAuthorizationHeader(req, user)
+		}
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("unauthorized requests return 401", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		for _, path := range []string{"/me", "/me/events", "/me/clubs", "/me/groups"} {
+			w := request("GET", path, "", nil)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		}
+	})
+
+	t.Run("users can get and edit their profile", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+
+		w := request("GET", "/me", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		me := &models.User{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), me))
+		assert.Equal(t, user.UID, me.UID)
+		assert.Equal(t, user.Email, me.Email)
+
+		w = request("PATCH", "/me", `{"nick_name":"blub", "location":"Berlin", "admin": true, "email": "other@example.com"}`, user)
+		require.Equal(t, http.StatusOK, w.Code)
+		me = &models.User{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), me))
+		assert.Equal(t, "blub", me.NickName)
+		assert.Equal(t, "Berlin", me.Location)
+		assert.False(t, me.Admin)
+		assert.Equal(t, user.Email, me.Email)
+	})
+
+	t.Run("users get the events, clubs and groups they are involved in", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		otherUser := testhelpers.CreateSomeUser(dbDriver)
+
+		club := models.NewClub()
+		club.Name = "administered club"
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, user.UID))
+		group := models.NewGroup()
+		group.Name = "group within the club"
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		otherGroup := models.NewGroup()
+		otherGroup.Name = "group the user is a member of"
+		_, err = db.Save(dbDriver, otherGroup)
+		require.NoError(t, err)
+		_, err = models.RequestMembership(dbDriver, user.UID, otherGroup)
+		require.NoError(t, err)
+		_, err = models.ApproveMembership(dbDriver, user.UID, otherGroup, models.MembershipRoleMember)
+		require.NoError(t, err)
+
+		createdEvent := models.NewEvent()
+		_, err = db.CreateBy(dbDriver, createdEvent, user.UID)
+		require.NoError(t, err)
+		// matched by two paths, listed once
+		_, err = models.Attend(dbDriver, createdEvent.UID, user.UID, models.AttendanceGoing)
+		require.NoError(t, err)
+		attendedEvent := models.NewEvent()
+		_, err = db.CreateBy(dbDriver, attendedEvent, otherUser.UID)
+		require.NoError(t, err)
+		_, err = models.Attend(dbDriver, attendedEvent.UID, user.UID, models.AttendanceGoing)
+		require.NoError(t, err)
+		hostedEvent := models.NewEvent()
+		_, err = db.CreateBy(dbDriver, hostedEvent, otherUser.UID)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, hostedEvent.UID, group.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+		declinedEvent := models.NewEvent()
+		_, err = db.CreateBy(dbDriver, declinedEvent, otherUser.UID)
+		require.NoError(t, err)
+		_, err = models.Attend(dbDriver, declinedEvent.UID, user.UID, models.AttendanceDeclined)
+		require.NoError(t, err)
+
+		w := request("GET", "/me/events", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		events := []models.Event{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		eventUIDs := []string{}
+		for _, event := range events {
+			eventUIDs = append(eventUIDs, event.UID.String())
+		}
+		assert.ElementsMatch(t, []string{createdEvent.UID.String(), attendedEvent.UID.String(), hostedEvent.UID.String()}, eventUIDs)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+
+		w = request("GET", "/me/clubs", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		clubs := []models.Club{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		require.Len(t, clubs, 1)
+		assert.Equal(t, club.UID, clubs[0].UID)
+
+		w = request("GET", "/me/groups", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		groups := []models.Group{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+		require.Len(t, groups, 1)
+		assert.Equal(t, otherGroup.UID, groups[0].UID)
+	})
+}
@@ -0,0 +1,242 @@
+package actions
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//registerMembershipRoutes that clubs and groups share
+func (h *ActionHandler) registerMembershipRoutes(group *gin.RouterGroup) {
+	group.GET("/:uid/members", h.getMembers)
+	group.PATCH("/:uid/members/:user_uid", h.updateMember)
+	group.POST("/:uid/membership", h.requestMembership)
+	group.DELETE("/:uid/membership", h.leaveGroupOrClub)
+	group.GET("/:uid/membership_requests", h.getMembershipRequests)
+	group.POST("/:uid/membership_requests/:user_uid/approve", h.approveMembershipRequest)
+	group.POST("/:uid/membership_requests/:user_uid/reject", h.rejectMembershipRequest)
+}
+
+type membershipRoleAttributes struct {
+	Role string `json:"role"`
+}
+
+//findGroupOrClub from the uid param with the label of the route, aborting with 404 if there is none
+func (h *ActionHandler) findGroupOrClub(c *gin.Context) models.GroupOrClub {
+	groupOrClub, err := models.FindGroupOrClubWithLabel(h.dbDriver, routeLabel(c), c.Param("uid"))
+	if err != nil || groupOrClub == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("club or group not found"))
+		return nil
+	}
+	return groupOrClub
+}
+
+//findAdministeredGroupOrClub from the uid param, aborting unless the current user administers it
+func (h *ActionHandler) findAdministeredGroupOrClub(c *gin.Context) models.GroupOrClub {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil
+	}
+
+	groupOrClub := h.findGroupOrClub(c)
+	if groupOrClub == nil {
+		return nil
+	}
+	if !groupOrClub.AdministeredByUser(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return nil
+	}
+	return groupOrClub
+}
+
+//membershipRole from the request body, member if none is given
+func membershipRole(c *gin.Context) (string, error) {
+	attributes := &membershipRoleAttributes{Role: models.MembershipRoleMember}
+	if c.Request.ContentLength != 0 {
+		err := c.ShouldBindJSON(attributes)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !models.ValidMembershipRole(attributes.Role) {
+		return "", errors.New("role has to be member, coach, treasurer or admin")
+	}
+	return attributes.Role, nil
+}
+
+func (h *ActionHandler) getMembers(c *gin.Context) {
+	if h.currentUserClaim(c) == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	groupOrClub := h.findGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+	h.respondWithMembers(c, groupOrClub, models.MembershipActive)
+}
+
+func (h *ActionHandler) getMembershipRequests(c *gin.Context) {
+	groupOrClub := h.findAdministeredGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+	h.respondWithMembers(c, groupOrClub, models.MembershipRequested)
+}
+
+func (h *ActionHandler) respondWithMembers(c *gin.Context, groupOrClub models.GroupOrClub, status string) {
+	skip, limit, err := pageParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	members, total, err := models.FindMembers(h.dbDriver, groupOrClub, status, skip, limit)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	setTotalCount(c, total)
+	c.JSON(http.StatusOK, members)
+}
+
+//requestMembership of the current user, admins have to approve the request
+func (h *ActionHandler) requestMembership(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	groupOrClub := h.findGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	membership, err := models.RequestMembership(h.dbDriver, currentUserClaim.UID, groupOrClub)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if membership == nil {
+		// the user of the token or the club or group was deleted in the meantime
+		c.AbortWithError(http.StatusNotFound, errors.New("user or "+strings.ToLower(groupOrClub.NodeName())+" not found"))
+		return
+	}
+	c.JSON(http.StatusCreated, membership)
+}
+
+//leaveGroupOrClub ends the membership of the current user or withdraws their request
+func (h *ActionHandler) leaveGroupOrClub(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	groupOrClub := h.findGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	membership, err := models.LeaveGroupOrClub(h.dbDriver, currentUserClaim.UID, groupOrClub)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if membership == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("not a member"))
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+//approveMembershipRequest with the role given in the body, member by default
+func (h *ActionHandler) approveMembershipRequest(c *gin.Context) {
+	groupOrClub := h.findAdministeredGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	userUID, err := uuid.Parse(c.Param("user_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	role, err := membershipRole(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	membership, err := models.ApproveMembership(h.dbDriver, userUID, groupOrClub, role)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if membership == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("membership request not found"))
+		return
+	}
+	c.JSON(http.StatusOK, membership)
+}
+
+func (h *ActionHandler) rejectMembershipRequest(c *gin.Context) {
+	groupOrClub := h.findAdministeredGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	userUID, err := uuid.Parse(c.Param("user_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	membership, err := models.RejectMembership(h.dbDriver, userUID, groupOrClub)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if membership == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("membership request not found"))
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+//updateMember changes the role of a member
+func (h *ActionHandler) updateMember(c *gin.Context) {
+	groupOrClub := h.findAdministeredGroupOrClub(c)
+	if groupOrClub == nil {
+		return
+	}
+
+	userUID, err := uuid.Parse(c.Param("user_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	role, err := membershipRole(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	membership, err := models.ChangeMembershipRole(h.dbDriver, userUID, groupOrClub, role)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if membership == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("member not found"))
+		return
+	}
+	c.JSON(http.StatusOK, membership)
+}
@@ -0,0 +1,162 @@
+package actions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Memberships(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path, body string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if user != nil {
+			testhelpers.AddAuthorizationHeader(req, user)
+		}
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	setup := func(t *testing.T) (*models.Club, *models.User) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		club.Name = "FC Example"
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, admin.UID))
+		return club, admin
+	}
+
+	t.Run("users can request to join and admins approve them with a role", func(t *testing.T) {
+		club, admin := setup(t)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		clubPath := "/clubs/" + club.UID.String()
+
+		w := request("POST", clubPath+"/membership", "", user)
+		require.Equal(t, http.StatusCreated, w.Code)
+		membership := &models.Membership{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), membership))
+		assert.Equal(t, models.MembershipRequested, membership.Status)
+
+		w = request("GET", clubPath+"/membership_requests", "", user)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = request("GET", clubPath+"/membership_requests", "", admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		requests := []models.Member{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &requests))
+		require.Len(t, requests, 1)
+		assert.Equal(t, user.UID, requests[0].UID)
+
+		w = request("POST", clubPath+"/membership_requests/"+user.UID.String()+"/approve", `{"role":"coach"}`, user)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = request("POST", clubPath+"/membership_requests/"+user.UID.String()+"/approve", `{"role":"president"}`, admin)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = request("POST", clubPath+"/membership_requests/"+user.UID.String()+"/approve", `{"role":"coach"}`, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = request("GET", clubPath+"/members", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+		members := []models.Member{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &members))
+		require.Len(t, members, 1)
+		assert.Equal(t, models.MembershipRoleCoach, members[0].Role)
+		assert.Equal(t, models.MembershipActive, members[0].Status)
+
+		w = request("PATCH", clubPath+"/members/"+user.UID.String(), `{"role":"treasurer"}`, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = request("GET", "/users/me/memberships", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		memberships := []models.UserMembership{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &memberships))
+		require.Len(t, memberships, 1)
+		assert.Equal(t, club.UID, memberships[0].UID)
+		assert.Equal(t, "Club", memberships[0].Type)
+		assert.Equal(t, models.MembershipRoleTreasurer, memberships[0].Role)
+
+		w = request("DELETE", clubPath+"/membership", "", user)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = request("GET", clubPath+"/members", "", user)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	})
+
+	t.Run("admins can reject membership requests", func(t *testing.T) {
+		club, admin := setup(t)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		clubPath := "/clubs/" + club.UID.String()
+
+		w := request("POST", clubPath+"/membership", "", user)
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = request("POST", clubPath+"/membership_requests/"+user.UID.String()+"/reject", "", admin)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = request("POST", clubPath+"/membership_requests/"+user.UID.String()+"/approve", "", admin)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("users that don't exist anymore can't request a membership", func(t *testing.T) {
+		club, _ := setup(t)
+		deleted := models.NewUser()
+		deleted.Email = "deleted@example.com"
+
+		w := request("POST", "/clubs/"+club.UID.String()+"/membership", "", deleted)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("members are paginated", func(t *testing.T) {
+		club, admin := setup(t)
+		clubPath := "/clubs/" + club.UID.String()
+		for i := 0; i < 3; i++ {
+			user := testhelpers.CreateSomeUser(dbDriver)
+			_, err := models.RequestMembership(dbDriver, user.UID, club)
+			require.NoError(t, err)
+			_, err = models.ApproveMembership(dbDriver, user.UID, club, models.MembershipRoleMember)
+			require.NoError(t, err)
+		}
+
+		w := request("GET", clubPath+"/members?page=2&per_page=2", "", admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+		members := []models.Member{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &members))
+		assert.Len(t, members, 1)
+
+		w = request("GET", clubPath+"/members?per_page=1000", "", admin)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+	t.Run("club routes don't accept the uids of groups and the other way round", func(t *testing.T) {
+		club, admin := setup(t)
+		group := models.NewGroup()
+		group.Name = "Under 12"
+		_, err := db.CreateBy(dbDriver, group, admin.UID)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, request("GET", "/clubs/"+group.UID.String()+"/members", "", admin).Code)
+		assert.Equal(t, http.StatusNotFound, request("POST", "/groups/"+club.UID.String()+"/membership", "", admin).Code)
+		assert.Equal(t, http.StatusNotFound, request("GET", "/clubs/"+group.UID.String()+"/groups", "", admin).Code)
+		assert.Equal(t, http.StatusOK, request("GET", "/groups/"+group.UID.String()+"/members", "", admin).Code)
+	})
+}
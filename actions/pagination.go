@@ -0,0 +1,126 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPerPage = 25
+	maxPerPage     = 100
+)
+
+//pageParams reads the page (starting at 1) and per_page query params and returns how many items to skip and to return.
+//Only search results and members are paged by number, the other lists are paged with cursors, see pageQueryParams
+func pageParams(c *gin.Context) (skip, limit int, err error) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fmt.Errorf("page has to be a number greater than 0")
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return 0, 0, fmt.Errorf("per_page has to be a number between 1 and %v", maxPerPage)
+	}
+	return (page - 1) * perPage, perPage, nil
+}
+
+//setTotalCount tells clients how many items there are across all pages
+func setTotalCount(c *gin.Context, total int64) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+}
+
+var filterParamPattern = regexp.MustCompile(`^filter\[([a-z_]+)\](?:\[([a-z]+)\])?$`)
+
+//errPageNumbers is returned when a list that is paged with cursors is asked for a page by number
+var errPageNumbers = errors.New("page and per_page aren't supported by this list, use limit and cursor")
+
+//errPageWithCustomOrder is returned when a list that isn't ordered by fields (e.g. by distance) is asked for a certain page or order
+var errPageWithCustomOrder = errors.New("limit, cursor and sort can't be combined with near or with from and to")
+
+//pageQueryParams reads ?limit, ?cursor, ?sort=field,-field and ?filter[field][op]=value (op defaults to eq)
+//for a page of the models of the type of the prototype, sorted by defaultSort if ?sort isn't given
+func pageQueryParams(c *gin.Context, prototype db.Model, defaultSort ...db.SortKey) (*db.Page, error) {
+	if c.Query("page") != "" || c.Query("per_page") != "" {
+		return nil, errPageNumbers
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPerPage)))
+	if err != nil || limit < 1 || limit > maxPerPage {
+		return nil, fmt.Errorf("limit has to be a number between 1 and %v", maxPerPage)
+	}
+
+	sort := defaultSort
+	if c.Query("sort") != "" {
+		sort = []db.SortKey{}
+		for _, field := range listQueryParam(c, "sort") {
+			sort = append(sort, db.SortKey{Field: strings.TrimPrefix(field, "-"), Descending: strings.HasPrefix(field, "-")})
+		}
+	}
+
+	filters := []db.Filter{}
+	for name, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(name, "filter") {
+			continue
+		}
+		match := filterParamPattern.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("%v has to look like filter[field][op]", name)
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		for _, value := range values {
+			filters = append(filters, db.Filter{Field: match[1], Op: op, Value: value})
+		}
+	}
+
+	return db.NewPage(prototype, limit, sort, filters, c.Query("cursor"))
+}
+
+//asksForPage checks if the request asks for a certain page, order or limit
+func asksForPage(c *gin.Context) bool {
+	return c.Query("limit") != "" || c.Query("cursor") != "" || c.Query("sort") != ""
+}
+
+//setNextPage links to the next page in the Link and X-Next-Cursor headers, there is none if next is empty
+func setNextPage(c *gin.Context, next string) {
+	if next == "" {
+		return
+	}
+	query := c.Request.URL.Query()
+	query.Set("cursor", next)
+	c.Header("Link", fmt.Sprintf("<%v?%v>; rel=\"next\"", c.Request.URL.Path, query.Encode()))
+	c.Header("X-Next-Cursor", next)
+}
+
+//respondWithPage of the models of the repository that match the query,
+//the number of models on all pages is sent in the X-Total-Count header
+func respondWithPage(c *gin.Context, repository *db.Repository, query db.ListQuery, page *db.Page) {
+	countQuery := query
+	countQuery.Params = map[string]interface{}{}
+	for key, value := range query.Params {
+		countQuery.Params[key] = value
+	}
+	countQuery.Conditions = append(append([]string{}, query.Conditions...), page.FilterConditions(countQuery.Params)...)
+	total, err := repository.Count(countQuery)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	found, next, err := repository.ListPage(query, page)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	setTotalCount(c, total)
+	setNextPage(c, next)
+	c.JSON(http.StatusOK, found)
+}
@@ -0,0 +1,104 @@
+package actions_test
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/alexmorten/events-api/search"
+	"github.com/alexmorten/events-api/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//changingBackend runs during before the first write into a new version, like a server that changes nodes while a reindex runs
+type changingBackend struct {
+	*search.MemoryBackend
+	during func()
+}
+
+func (b *changingBackend) WriteVersion(ctx context.Context, version string, documents []search.Document) ([]string, error) {
+	if b.during != nil {
+		b.during()
+		b.during = nil
+	}
+	return b.MemoryBackend.WriteVersion(ctx, version, documents)
+}
+
+func Test_Reindex(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	ctx := context.Background()
+
+	saveClub := func(name string) *models.Club {
+		club := models.NewClub()
+		club.Name = name
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		return club
+	}
+
+	t.Run("nodes changed and deleted while reindexing are written again after the switch", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		kept := saveClub("kept")
+		deleted := saveClub("deleted")
+
+		backend := &changingBackend{MemoryBackend: search.NewMemoryBackend()}
+		indexer := search.NewIndexer(backend, search.NeoDocumentLoader(dbDriver), search.NewNeoQueue(dbDriver))
+		indexer.ChangeLog = search.NewNeoChangeLog(dbDriver)
+		require.NoError(t, indexer.Index(ctx, []string{kept.UID.String(), deleted.UID.String()}))
+
+		var created *models.Club
+		backend.during = func() {
+			require.NoError(t, db.DeleteNode(dbDriver, &models.Club{}, deleted.UID.String()))
+			created = saveClub("created")
+			// the server indexes into the version that is still used
+			require.NoError(t, indexer.Index(ctx, []string{deleted.UID.String(), created.UID.String()}))
+		}
+		reindexer := search.NewReindexer(backend, dbDriver)
+		result, err := reindexer.Reindex(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"nodes-1"}, result.RemovedIndices)
+
+		indexed, err := backend.IndexedDocuments(ctx, 10)
+		require.NoError(t, err)
+		assert.Contains(t, indexed, kept.UID.String())
+		assert.Contains(t, indexed, created.UID.String())
+		assert.NotContains(t, indexed, deleted.UID.String())
+
+		report, err := reindexer.Verify(ctx)
+		require.NoError(t, err)
+		assert.True(t, report.Consistent(), report)
+		assert.Equal(t, 2, report.Checked)
+	})
+
+	t.Run("reindexes get their own versions and verify finds stale documents", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		club := saveClub("before")
+
+		backend := search.NewMemoryBackend()
+		reindexer := search.NewReindexer(backend, dbDriver)
+		first, err := reindexer.Reindex(ctx, true)
+		require.NoError(t, err)
+		second, err := reindexer.Reindex(ctx, true)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.IndexName, second.IndexName)
+		assert.Empty(t, second.RemovedIndices)
+
+		club.Name = "after"
+		_, err = db.Save(dbDriver, club)
+		require.NoError(t, err)
+		report, err := reindexer.Verify(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{club.UID.String()}, report.Stale)
+
+		third, err := reindexer.Reindex(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{second.IndexName}, third.RemovedIndices)
+		report, err = reindexer.Verify(ctx)
+		require.NoError(t, err)
+		assert.True(t, report.Consistent(), report)
+	})
+}
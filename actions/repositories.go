@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/gin-gonic/gin"
+)
+
+//repository for the models of the type of the prototype, e.g. h.repository(&models.Sport{})
+func (h *ActionHandler) repository(prototype db.Model) *db.Repository {
+	return db.NewRepository(h.dbDriver, prototype)
+}
+
+//getFromRepository responds with the model of the uid param
+func getFromRepository(c *gin.Context, repository *db.Repository) {
+	model, err := repository.Get(c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model)
+}
+
+//listFromRepository responds with the page of the models matching the query that ?limit, ?cursor, ?sort and ?filter ask for,
+//ordered by defaultSort unless ?sort is given, see respondWithPage
+func listFromRepository(c *gin.Context, repository *db.Repository, query db.ListQuery, defaultSort ...db.SortKey) {
+	page, err := pageQueryParams(c, repository.Prototype(), defaultSort...)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	respondWithPage(c, repository, query, page)
+}
+
+//deleteFromRepository deletes the model of the uid param
+func deleteFromRepository(c *gin.Context, repository *db.Repository) {
+	err := repository.Delete(c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,309 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/alexmorten/events-api/search"
+	"github.com/gin-gonic/gin"
+)
+
+//RegisterSearchRoutes within the given router group
+func (h *ActionHandler) RegisterSearchRoutes(group *gin.RouterGroup) {
+	group.GET("", h.getSearch)
+	group.GET("/suggest", h.getSuggestions)
+	group.GET("/events", h.getEventSearch)
+}
+
+//deleteIndexedNode deletes the node with the label of the model and the uid, the documents of its dependents are written again without its name
+func (h *ActionHandler) deleteIndexedNode(model db.Model, uid string) error {
+	return db.Transact(h.dbDriver, func(tx *db.Tx) error {
+		// its dependents can't be found anymore once it is deleted
+		if err := search.NotifyDependents(tx, uid); err != nil {
+			return err
+		}
+		return tx.DeleteNode(model, uid)
+	})
+}
+
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 25
+)
+
+type suggestion struct {
+	UID   string  `json:"uid"`
+	Type  string  `json:"type"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+type searchResult struct {
+	Type       string   `json:"type"`
+	Score      float64  `json:"score"`
+	Highlights []string `json:"highlights"`
+	Item       db.Model `json:"item"`
+}
+
+type facet struct {
+	UID   string `json:"uid"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type eventFacets struct {
+	Sports []facet `json:"sports"`
+	Clubs  []facet `json:"clubs"`
+}
+
+type eventSearchResponse struct {
+	Results []searchResult `json:"results"`
+	Facets  eventFacets    `json:"facets"`
+}
+
+//getSearch finds clubs, groups, events and sports by name, ?type restricts the search to one of them.
+//Results are ordered by relevance
+func (h *ActionHandler) getSearch(c *gin.Context) {
+	term := strings.TrimSpace(c.Query("q"))
+	if term == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("q can't be empty"))
+		return
+	}
+
+	query := search.Query{Term: term}
+	if searchType := c.Query("type"); searchType != "" {
+		label, ok := models.SearchableLabels[searchType]
+		if !ok {
+			c.AbortWithError(http.StatusBadRequest, errInvalidSearchType)
+			return
+		}
+		query.Labels = []string{label}
+	}
+
+	var err error
+	query.From, query.Size, err = pageParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.searchBackend.SearchNodes(c.Request.Context(), query)
+	if err != nil {
+		abortWithSearchError(c, err)
+		return
+	}
+
+	results, err := h.searchResults(result.Hits)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	setTotalCount(c, result.Total)
+	c.JSON(http.StatusOK, results)
+}
+
+//getEventSearch finds events by name (?q, optional) and filters:
+//?sport and ?club take comma separated uids, ?from and ?to a time window, ?skill_level comma separated levels,
+//?min_price and ?max_price cents and ?near=lat,lng with ?radius in kilometers.
+//The response contains the number of matching events per sport and club to narrow down the search further
+func (h *ActionHandler) getEventSearch(c *gin.Context) {
+	query := search.EventQuery{Term: strings.TrimSpace(c.Query("q"))}
+	var err error
+	query.Filter, err = eventFilterParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	query.From, query.Size, err = pageParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := h.searchBackend.SearchEvents(c.Request.Context(), query)
+	if err != nil {
+		abortWithSearchError(c, err)
+		return
+	}
+
+	response := eventSearchResponse{}
+	response.Results, err = h.searchResults(result.Hits)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	response.Facets.Sports, err = h.namedFacets(result.Sports)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	response.Facets.Clubs, err = h.namedFacets(result.Clubs)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	setTotalCount(c, result.Total)
+	c.JSON(http.StatusOK, response)
+}
+
+func eventFilterParams(c *gin.Context) (search.EventFilter, error) {
+	filter := search.EventFilter{
+		SportUIDs: listQueryParam(c, "sport"),
+		ClubUIDs:  listQueryParam(c, "club"),
+	}
+
+	var err error
+	filter.EndsAfter, err = timeQueryParam(c, "from")
+	if err != nil {
+		return filter, err
+	}
+	filter.StartsBefore, err = timeQueryParam(c, "to")
+	if err != nil {
+		return filter, err
+	}
+
+	filter.SkillLevels = listQueryParam(c, "skill_level")
+	for _, skillLevel := range filter.SkillLevels {
+		if !models.ValidSkillLevel(skillLevel) {
+			return filter, fmt.Errorf("skill_level has to be one of %v, %v or %v", models.SkillLevelBeginner, models.SkillLevelIntermediate, models.SkillLevelAdvanced)
+		}
+	}
+
+	for name, price := range map[string]**int{"min_price": &filter.MinPriceCents, "max_price": &filter.MaxPriceCents} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		cents, err := strconv.Atoi(value)
+		if err != nil || cents < 0 {
+			return filter, fmt.Errorf("%v has to be a number of cents", name)
+		}
+		*price = &cents
+	}
+
+	near, radiusMeters, err := nearQueryParams(c)
+	if err != nil {
+		return filter, err
+	}
+	if near != nil {
+		filter.Near = &search.GeoPoint{Lat: near.Latitude, Lon: near.Longitude}
+		filter.RadiusMeters = radiusMeters
+	}
+	return filter, nil
+}
+
+//searchResults loads the nodes of the hits
+func (h *ActionHandler) searchResults(hits []search.Hit) ([]searchResult, error) {
+	uids := []string{}
+	for _, hit := range hits {
+		uids = append(uids, hit.UID)
+	}
+	searchables, err := models.FindSearchables(h.dbDriver, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []searchResult{}
+	for _, hit := range hits {
+		// the index can lag behind the database, nodes that are gone by now are left out
+		item, ok := searchables[hit.UID]
+		if !ok {
+			continue
+		}
+		highlights := hit.Highlights
+		if highlights == nil {
+			highlights = []string{}
+		}
+		results = append(results, searchResult{
+			Type:       strings.ToLower(item.NodeName()),
+			Score:      hit.Score,
+			Highlights: highlights,
+			Item:       item,
+		})
+	}
+	return results, nil
+}
+
+//namedFacets adds the names of the sports or clubs the facets count
+func (h *ActionHandler) namedFacets(searchFacets []search.Facet) ([]facet, error) {
+	uids := []string{}
+	for _, searchFacet := range searchFacets {
+		uids = append(uids, searchFacet.Value)
+	}
+	searchables, err := models.FindSearchables(h.dbDriver, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := []facet{}
+	for _, searchFacet := range searchFacets {
+		var name string
+		switch item := searchables[searchFacet.Value].(type) {
+		case *models.Sport:
+			name = item.Name
+		case *models.Club:
+			name = item.Name
+		default:
+			continue
+		}
+		facets = append(facets, facet{UID: searchFacet.Value, Name: name, Count: searchFacet.Count})
+	}
+	return facets, nil
+}
+
+//getSuggestions for what a user typed so far, ?type=club,sport restricts them to some types
+func (h *ActionHandler) getSuggestions(c *gin.Context) {
+	prefix := strings.TrimLeft(c.Query("prefix"), " ")
+	if prefix == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New("prefix can't be empty"))
+		return
+	}
+
+	types := []string{}
+	if typeParam := c.Query("type"); typeParam != "" {
+		for _, searchType := range strings.Split(typeParam, ",") {
+			if _, ok := models.SearchableLabels[searchType]; !ok {
+				c.AbortWithError(http.StatusBadRequest, errInvalidSearchType)
+				return
+			}
+			types = append(types, searchType)
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSuggestions)))
+	if err != nil || limit < 1 || limit > maxSuggestions {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("limit has to be a number between 1 and %v", maxSuggestions))
+		return
+	}
+
+	suggestedNodes, err := h.searchBackend.Suggest(c.Request.Context(), prefix, types, limit)
+	if err != nil {
+		abortWithSearchError(c, err)
+		return
+	}
+
+	suggestions := []suggestion{}
+	for _, node := range suggestedNodes {
+		suggestions = append(suggestions, suggestion(node))
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
+
+var errInvalidSearchType = errors.New("type has to be one of club, group, event or sport")
+
+//abortWithSearchError responds with 503 if elasticsearch can't be reached
+func abortWithSearchError(c *gin.Context, err error) {
+	if search.IsUnavailable(err) {
+		c.Error(err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "search is currently unavailable, try again later"})
+		return
+	}
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
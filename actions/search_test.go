@@ -0,0 +1,213 @@
+package actions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/alexmorten/events-api/search"
+	"github.com/alexmorten/events-api/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Search(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	config.SearchBackend = search.BackendElasticsearch
+	config.ElasticsearchAddress = "http://127.0.0.1:1"
+	withoutElasticsearch := api.NewServer(config)
+	require.NoError(t, withoutElasticsearch.Init(), "elasticsearch is connected to lazily")
+	defer withoutElasticsearch.Close()
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("clubs and sports can be found as soon as they are indexed", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		club := models.NewClub()
+		club.Name = "Rowing Club Wannsee"
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		sport := models.NewSport()
+		sport.Name = "Rowing"
+		_, err = db.Save(dbDriver, sport)
+		require.NoError(t, err)
+
+		results := []map[string]interface{}{}
+		waitFor(t, func() bool {
+			w := request("/search?q=rowign")
+			json.Unmarshal(w.Body.Bytes(), &results)
+			return w.Code == http.StatusOK && len(results) == 2
+		})
+		assert.Equal(t, "sport", results[0]["type"])
+		assert.Equal(t, sport.UID.String(), results[0]["item"].(map[string]interface{})["uid"])
+		assert.Equal(t, []interface{}{"<em>Rowing</em> Club Wannsee"}, results[1]["highlights"])
+
+		w := request("/search?q=rowing&type=club")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+		suggestions := []map[string]interface{}{}
+		w = request("/search/suggest?prefix=wann")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &suggestions))
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, club.UID.String(), suggestions[0]["uid"])
+
+		require.NoError(t, db.DeleteNode(dbDriver, &models.Club{}, club.UID.String()))
+		waitFor(t, func() bool {
+			w := request("/search?q=rowing&type=club")
+			return w.Header().Get("X-Total-Count") == "0"
+		})
+	})
+
+	t.Run("events can be filtered and faceted", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		club := models.NewClub()
+		club.Name = "Rowing Club Wannsee"
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		sport := models.NewSport()
+		sport.Name = "Rowing"
+		_, err = db.Save(dbDriver, sport)
+		require.NoError(t, err)
+
+		startsAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+		for _, skillLevel := range []string{models.SkillLevelBeginner, models.SkillLevelAdvanced} {
+			event := models.NewEvent()
+			event.Name = "Regatta for " + skillLevel
+			event.StartsAt = startsAt
+			event.SkillLevel = skillLevel
+			_, err = db.Save(dbDriver, event)
+			require.NoError(t, err)
+			_, err = db.CreateRelation(dbDriver, event.UID, club.UID, models.EventHostedByGroupOrClub)
+			require.NoError(t, err)
+			_, err = db.CreateRelation(dbDriver, event.UID, sport.UID, models.EventIsSport)
+			require.NoError(t, err)
+		}
+
+		response := struct {
+			Results []map[string]interface{}
+			Facets  map[string][]map[string]interface{}
+		}{}
+		waitFor(t, func() bool {
+			w := request("/search/events?skill_level=beginner&sport=" + sport.UID.String())
+			json.Unmarshal(w.Body.Bytes(), &response)
+			return w.Code == http.StatusOK && len(response.Results) == 1 && len(response.Facets["clubs"]) == 1
+		})
+		assert.Equal(t, "Regatta for beginner", response.Results[0]["item"].(map[string]interface{})["name"])
+		assert.Equal(t, []map[string]interface{}{{"uid": sport.UID.String(), "name": "Rowing", "count": float64(1)}}, response.Facets["sports"])
+		assert.Equal(t, club.UID.String(), response.Facets["clubs"][0]["uid"])
+
+		w := request("/search/events?to=" + startsAt.Add(-time.Hour).Format(time.RFC3339))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-Total-Count"))
+	})
+
+	t.Run("the dependents of clubs, groups and sports are indexed again when they change", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateAdminUser(dbDriver)
+		club, group, event, sport := models.NewClub(), models.NewGroup(), models.NewEvent(), models.NewSport()
+		for _, model := range []db.Model{club, group, event, sport} {
+			_, err := db.Save(dbDriver, model)
+			require.NoError(t, err)
+		}
+		for _, relation := range []struct {
+			from, to *models.Model
+			name     string
+		}{
+			{&group.Model, &club.Model, models.GroupBelongsToGroupOrClub},
+			{&event.Model, &group.Model, models.EventHostedByGroupOrClub},
+			{&event.Model, &sport.Model, models.EventIsSport},
+			{&club.Model, &sport.Model, models.ClubOrGroupOffersSport},
+		} {
+			_, err := db.CreateRelation(dbDriver, relation.from.UID, relation.to.UID, relation.name)
+			require.NoError(t, err)
+		}
+
+		dependents := func(uid string) []string {
+			uids := []string{}
+			err := db.ReadTransact(dbDriver, func(tx *db.Tx) error {
+				changes, err := search.FindDependents(tx, []string{uid})
+				for _, change := range changes {
+					uids = append(uids, change.UID)
+				}
+				return err
+			})
+			require.NoError(t, err)
+			return uids
+		}
+		assert.ElementsMatch(t, []string{group.UID.String(), event.UID.String()}, dependents(club.UID.String()))
+		assert.ElementsMatch(t, []string{event.UID.String(), club.UID.String()}, dependents(sport.UID.String()))
+		assert.Empty(t, dependents(event.UID.String()))
+
+		changed := make(chan string, 10)
+		stopListening := db.OnChange(func(change db.Change) { changed <- change.UID })
+		defer stopListening()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/sports/"+sport.UID.String(), nil)
+		testhelpers.AddAuthorizationHeader(req, admin)
+		s.Engine.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		stopListening()
+		close(changed)
+		uids := []string{}
+		for uid := range changed {
+			uids = append(uids, uid)
+		}
+		assert.ElementsMatch(t, []string{sport.UID.String(), event.UID.String(), club.UID.String()}, uids)
+	})
+
+	t.Run("invalid queries are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("/search").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search?q=rowing&type=user").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search?q=rowing&per_page=1000").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/suggest").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/suggest?prefix=ro&type=club,user").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/suggest?prefix=ro&limit=0").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/events?skill_level=expert").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/events?min_price=-1").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/events?near=52.52").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/events?near=52.52,13.4&radius=0").Code)
+		assert.Equal(t, http.StatusBadRequest, request("/search/events?from=tomorrow").Code)
+	})
+
+	t.Run("searching without elasticsearch responds with service unavailable", func(t *testing.T) {
+		request := func(path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			withoutElasticsearch.Engine.ServeHTTP(w, req)
+			return w
+		}
+		w := request("/search?q=rowing&type=club")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "search is currently unavailable")
+
+		w = request("/search/suggest?prefix=ro&type=club,sport")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+//waitFor the condition to become true, e.g. until changes are indexed
+func waitFor(t *testing.T, condition func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatal("condition wasn't met within 5 seconds")
+}
@@ -1,14 +1,14 @@
 package actions
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/alexmorten/events-api/db"
 
 	"github.com/alexmorten/events-api/models"
 	"github.com/gin-gonic/gin"
-	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"github.com/google/uuid"
 )
 
 //RegisterSportRoutes within the given router group
@@ -18,48 +18,18 @@ func (h *ActionHandler) RegisterSportRoutes(group *gin.RouterGroup) {
 	group.PATCH("/:uid", h.updateSport)
 	group.POST("", h.postSports)
 	group.DELETE("/:uid", h.deleteSport)
+
+	group.GET("/:uid/clubs", h.getSportClubs)
+	group.GET("/:uid/events", h.getSportEvents)
 }
 
 func (h *ActionHandler) getSport(c *gin.Context) {
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-
-	sport, err := models.FindSport(h.dbDriver, uid)
-	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
-		return
-	}
-	c.JSON(200, sport)
+	getFromRepository(c, h.repository(&models.Sport{}))
 }
 
+//getSports lists the sports by name, ?limit, ?cursor, ?sort and ?filter page, order and filter them
 func (h *ActionHandler) getSports(c *gin.Context) {
-	sports := []*models.Sport{}
-
-	dbSession, err := h.dbDriver.Session(neo4j.AccessModeRead)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-	defer dbSession.Close()
-
-	records, err := neo4j.Collect(dbSession.Run("match (n:Sport) return properties(n)", nil))
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-
-	for _, record := range records {
-		propInterface, ok := record.Get("properties(n)")
-		if ok {
-			props, ok := propInterface.(map[string]interface{})
-			if ok {
-				sports = append(sports, models.SportFromProps(props))
-			}
-		}
-	}
-	c.JSON(http.StatusOK, sports)
+	listFromRepository(c, h.repository(&models.Sport{}), db.ListQuery{}, db.SortKey{Field: "name"})
 }
 
 func (h *ActionHandler) postSports(c *gin.Context) {
@@ -82,13 +52,11 @@ func (h *ActionHandler) postSports(c *gin.Context) {
 		return
 	}
 	sport.SportAttributes = *sportAttributes
-	props, err := db.CreateBy(h.dbDriver, sport, currentUserClaim.UID)
+	createdSport, err := h.repository(sport).Create(sport, currentUserClaim.UID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	createdSport := models.SportFromProps(props)
 	c.JSON(http.StatusCreated, createdSport)
 }
 
@@ -108,15 +76,13 @@ func (h *ActionHandler) updateSport(c *gin.Context) {
 		return
 	}
 
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
-	}
-	sport, err := models.FindSport(h.dbDriver, uid)
+	sports := h.repository(&models.Sport{})
+	found, err := sports.Get(c.Param("uid"))
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
+	sport := found.(*models.Sport)
 
 	updateAttributes := &sportAttributesUpdate{}
 	err = c.ShouldBindJSON(updateAttributes)
@@ -127,13 +93,12 @@ func (h *ActionHandler) updateSport(c *gin.Context) {
 
 	models.UpdateFrom(&sport.SportAttributes, updateAttributes)
 
-	sportProps, err := db.Save(h.dbDriver, sport)
+	updatedSport, err := sports.Update(sport)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
-
-	c.JSON(200, models.SportFromProps(sportProps))
+	c.JSON(http.StatusOK, updatedSport)
 }
 
 func (h *ActionHandler) deleteSport(c *gin.Context) {
@@ -148,21 +113,144 @@ func (h *ActionHandler) deleteSport(c *gin.Context) {
 		return
 	}
 
-	uid := c.Param("uid")
-	if uid == "" {
-		c.AbortWithError(http.StatusBadRequest, errors.New("uid can't be empty"))
+	sport, err := models.FindSport(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	err = h.deleteIndexedNode(sport, sport.UID.String())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+//getSportClubs lists the clubs offering the sport, ?tags and ?near narrow them down like for GET /clubs
+func (h *ActionHandler) getSportClubs(c *gin.Context) {
+	sport, ok := h.sportOfParam(c)
+	if !ok {
+		return
+	}
+
+	h.listClubs(
+		c,
+		[]string{fmt.Sprintf("(n)-[:%v]->(:Sport {uid: $sport_uid})", models.ClubOrGroupOffersSport)},
+		map[string]interface{}{"sport_uid": sport.UID.String()},
+	)
+}
+
+//getSportEvents lists the events about the sport, ?from, ?to and ?tags narrow them down like for GET /events
+func (h *ActionHandler) getSportEvents(c *gin.Context) {
+	sport, ok := h.sportOfParam(c)
+	if !ok {
+		return
+	}
+
+	h.listEvents(
+		c,
+		fmt.Sprintf("match (n:Event)-[:%v]->(:Sport {uid: $sport_uid})", models.EventIsSport),
+		map[string]interface{}{"sport_uid": sport.UID.String()},
+	)
+}
+
+func (h *ActionHandler) sportOfParam(c *gin.Context) (*models.Sport, bool) {
+	sport, err := models.FindSport(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return nil, false
+	}
+	return sport, true
+}
+
+//getSportsOf the event, club or group the route is about, 404 if there is none with the uid
+func (h *ActionHandler) getSportsOf(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.routeNodeExists(c, uid.String()) {
+		return
+	}
+
+	sports, err := models.FindSportsOf(h.dbDriver, uid, routeLabel(c))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, sports)
+}
+
+func (h *ActionHandler) putEventSport(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.putSportRelation(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteEventSport(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.deleteSportRelation(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) putClubSport(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.putSportRelation(c, club.UID, club.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteClubSport(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.deleteSportRelation(c, club.UID, club.NodeName())
 	}
-	sport, err := models.FindSport(h.dbDriver, uid)
+}
+
+func (h *ActionHandler) putGroupSport(c *gin.Context) {
+	group, ok := h.administeredGroup(c)
+	if ok {
+		h.putSportRelation(c, group.UID, group.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteGroupSport(c *gin.Context) {
+	group, ok := h.administeredGroup(c)
+	if ok {
+		h.deleteSportRelation(c, group.UID, group.NodeName())
+	}
+}
+
+func (h *ActionHandler) putSportRelation(c *gin.Context, uid uuid.UUID, label string) {
+	sport, err := models.FindSport(h.dbDriver, c.Param("sport_uid"))
 	if err != nil {
-		c.AbortWithError(http.StatusNotFound, err)
+		abortWithFindError(c, err)
 		return
 	}
 
-	err = db.DeleteNode(h.dbDriver, sport.UID.String())
+	err = models.AddSport(h.dbDriver, uid, label, sport.UID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
+	c.JSON(http.StatusOK, sport)
+}
 
+func (h *ActionHandler) deleteSportRelation(c *gin.Context, uid uuid.UUID, label string) {
+	sportUID, err := uuid.Parse(c.Param("sport_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = models.RemoveSport(h.dbDriver, uid, label, sportUID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -24,7 +24,8 @@ func Test_Sports(t *testing.T) {
 	config := api.DefaultServerConfig()
 	dbDriver := db.Driver(config.Neo4jAddress)
 	s := api.NewServer(config)
-	s.Init()
+	require.NoError(t, s.Init())
+	defer s.Close()
 	t.Run("unauthorized requests return 401", func(t *testing.T) {
 		testhelpers.Clear(dbDriver)
 
@@ -150,4 +151,137 @@ func Test_Sports(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, foundClub)
 	})
+	t.Run("clubs, groups and events can be linked to sports and filtered by them", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		request := func(method, path string, user *models.User) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, path, nil)
+			if user != nil {
+				testhelpers.AddAuthorizationHeader(req, user)
+			}
+			s.Engine.ServeHTTP(w, req)
+			return w
+		}
+
+		rowing := models.NewSport()
+		rowing.Name = "Rowing"
+		_, err := db.Save(dbDriver, rowing)
+		require.NoError(t, err)
+		chess := models.NewSport()
+		chess.Name = "Chess"
+		_, err = db.Save(dbDriver, chess)
+		require.NoError(t, err)
+
+		club := models.NewClub()
+		_, err = db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, admin.UID))
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		event := models.NewEvent()
+		event.StartsAt = time.Now()
+		_, err = db.Save(dbDriver, event)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, event.UID, club.UID, models.EventHostedByGroupOrClub)
+		require.NoError(t, err)
+
+		clubSportPath := "/clubs/" + club.UID.String() + "/sports/" + rowing.UID.String()
+		require.Equal(t, http.StatusUnauthorized, request("PUT", clubSportPath, nil).Code)
+		require.Equal(t, http.StatusForbidden, request("PUT", clubSportPath, testhelpers.CreateSomeUser(dbDriver)).Code)
+		require.Equal(t, http.StatusNotFound, request("PUT", "/clubs/"+club.UID.String()+"/sports/"+club.UID.String(), admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", clubSportPath, admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", clubSportPath, admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", "/clubs/"+club.UID.String()+"/sports/"+chess.UID.String(), admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", "/groups/"+group.UID.String()+"/sports/"+chess.UID.String(), admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", "/events/"+event.UID.String()+"/sports/"+rowing.UID.String(), admin).Code)
+
+		sports := []*models.Sport{}
+		w := request("GET", "/clubs/"+club.UID.String()+"/sports", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sports))
+		require.Len(t, sports, 2)
+		assert.Equal(t, "Chess", sports[0].Name)
+		assert.Equal(t, "Rowing", sports[1].Name)
+		require.Equal(t, http.StatusNotFound, request("GET", "/groups/"+club.UID.String()+"/sports", nil).Code)
+
+		clubs := []*models.Club{}
+		w = request("GET", "/sports/"+rowing.UID.String()+"/clubs", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		require.Len(t, clubs, 1)
+		assert.Equal(t, club.UID, clubs[0].UID)
+
+		events := []*models.Event{}
+		w = request("GET", "/sports/"+rowing.UID.String()+"/events", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, event.UID, events[0].UID)
+		w = request("GET", "/events?sport="+chess.UID.String(), nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		assert.Empty(t, events)
+
+		groups := []*models.Group{}
+		w = request("GET", "/clubs/"+club.UID.String()+"/groups?sport="+rowing.UID.String()+","+chess.UID.String(), nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+		require.Len(t, groups, 1)
+
+		require.Equal(t, http.StatusNoContent, request("DELETE", clubSportPath, admin).Code)
+		w = request("GET", "/clubs?sport="+rowing.UID.String(), nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		assert.Empty(t, clubs)
+		require.Equal(t, http.StatusNotFound, request("GET", "/sports/"+club.UID.String()+"/events", nil).Code)
+	})
+	t.Run("sports are listed by name a page at a time", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateAdminUser(dbDriver)
+		for _, name := range []string{"Rowing", "Chess", "Fencing"} {
+			sport := models.NewSport()
+			sport.Name = name
+			_, err := db.CreateBy(dbDriver, sport, admin.UID)
+			require.NoError(t, err)
+		}
+
+		names := func(path string) ([]string, string) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			s.Engine.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+			sports := []*models.Sport{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sports))
+			names := []string{}
+			for _, sport := range sports {
+				names = append(names, sport.Name)
+			}
+			return names, w.Header().Get("X-Next-Cursor")
+		}
+		all, next := names("/sports")
+		assert.Equal(t, []string{"Chess", "Fencing", "Rowing"}, all)
+		assert.Empty(t, next)
+		first, next := names("/sports?limit=2")
+		assert.Equal(t, []string{"Chess", "Fencing"}, first)
+		require.NotEmpty(t, next)
+		second, last := names("/sports?limit=2&cursor=" + next)
+		assert.Equal(t, []string{"Rowing"}, second)
+		assert.Empty(t, last)
+		descending, _ := names("/sports?sort=-name")
+		assert.Equal(t, []string{"Rowing", "Fencing", "Chess"}, descending)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/sports?sort=-name&cursor="+next, nil)
+		s.Engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "cursors only work with the sort they were made for")
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("DELETE", "/sports/"+admin.UID.String(), nil)
+		testhelpers.AddAuthorizationHeader(req, admin)
+		s.Engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		_, err := models.FindUser(dbDriver, admin.UID.String())
+		assert.NoError(t, err)
+	})
 }
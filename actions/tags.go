@@ -0,0 +1,222 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+//RegisterTagRoutes within the given router group
+func (h *ActionHandler) RegisterTagRoutes(group *gin.RouterGroup) {
+	group.GET("/:uid", h.getTag)
+	group.GET("", h.getTags)
+	group.PATCH("/:uid", h.updateTag)
+	group.POST("", h.postTags)
+	group.DELETE("/:uid", h.deleteTag)
+}
+
+func (h *ActionHandler) getTag(c *gin.Context) {
+	getFromRepository(c, h.repository(&models.Tag{}))
+}
+
+//getTags lists the tags by name, ?limit, ?cursor, ?sort and ?filter page, order and filter them
+func (h *ActionHandler) getTags(c *gin.Context) {
+	listFromRepository(c, h.repository(&models.Tag{}), db.ListQuery{}, db.SortKey{Field: "name"})
+}
+
+//postTags creates a tag, any user can create tags to label their events, clubs and groups
+func (h *ActionHandler) postTags(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	tag := models.NewTag()
+	tagAttributes := &models.TagAttributes{}
+	err := c.ShouldBindJSON(tagAttributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	tagAttributes.Normalize()
+	err = tagAttributes.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tag.TagAttributes = *tagAttributes
+	props, err := db.CreateBy(h.dbDriver, tag, currentUserClaim.UID)
+	if err != nil {
+		abortWithTagWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, models.TagFromProps(props))
+}
+
+type tagAttributesUpdate struct {
+	Name *string `json:"name"`
+}
+
+//updateTag renames a tag, only admins can rename tags as they are shared by everyone
+func (h *ActionHandler) updateTag(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserClaim.Admin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	tag, err := models.FindTag(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	updateAttributes := &tagAttributesUpdate{}
+	err = c.ShouldBindJSON(updateAttributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	models.UpdateFrom(&tag.TagAttributes, updateAttributes)
+	tag.Normalize()
+	err = tag.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tagProps, err := db.Save(h.dbDriver, tag)
+	if err != nil {
+		abortWithTagWriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.TagFromProps(tagProps))
+}
+
+func (h *ActionHandler) deleteTag(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserClaim.Admin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	deleteFromRepository(c, h.repository(&models.Tag{}))
+}
+
+//abortWithTagWriteError responds with 409 if another tag already has the name and with 500 otherwise.
+//The uniqueness constraint of the name is checked instead of looking for the name first, which tags written at the same time would get past
+func abortWithTagWriteError(c *gin.Context, err error) {
+	if db.IsConstraintViolation(err) {
+		c.AbortWithError(http.StatusConflict, models.ErrTagNameTaken)
+		return
+	}
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
+
+//getTagsOf the event, club or group the route is about, 404 if there is none with the uid
+func (h *ActionHandler) getTagsOf(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.routeNodeExists(c, uid.String()) {
+		return
+	}
+
+	tags, err := models.FindTagsOf(h.dbDriver, uid, routeLabel(c))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *ActionHandler) putEventTag(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.putTagging(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteEventTag(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.deleteTagging(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) putClubTag(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.putTagging(c, club.UID, club.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteClubTag(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.deleteTagging(c, club.UID, club.NodeName())
+	}
+}
+
+func (h *ActionHandler) putGroupTag(c *gin.Context) {
+	group, ok := h.administeredGroup(c)
+	if ok {
+		h.putTagging(c, group.UID, group.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteGroupTag(c *gin.Context) {
+	group, ok := h.administeredGroup(c)
+	if ok {
+		h.deleteTagging(c, group.UID, group.NodeName())
+	}
+}
+
+func (h *ActionHandler) putTagging(c *gin.Context, uid uuid.UUID, label string) {
+	tag, err := models.FindTag(h.dbDriver, c.Param("tag_uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	err = models.AddTag(h.dbDriver, uid, label, tag.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+func (h *ActionHandler) deleteTagging(c *gin.Context, uid uuid.UUID, label string) {
+	tagUID, err := uuid.Parse(c.Param("tag_uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = models.RemoveTag(h.dbDriver, uid, label, tagUID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
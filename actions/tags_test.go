@@ -0,0 +1,152 @@
+package actions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Tags(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path, body string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if user != nil {
+			testhelpers.AddAuthorizationHeader(req, user)
+		}
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	createTag := func(t *testing.T, name string) *models.Tag {
+		tag := models.NewTag()
+		tag.Name = name
+		_, err := db.Save(dbDriver, tag)
+		require.NoError(t, err)
+		return tag
+	}
+
+	t.Run("tags have unique normalized names and only admins can rename or delete them", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		admin := testhelpers.CreateAdminUser(dbDriver)
+
+		require.Equal(t, http.StatusUnauthorized, request("POST", "/tags", `{"name":"Beginners"}`, nil).Code)
+		w := request("POST", "/tags", `{"name":"  Beach  Volleyball "}`, user)
+		require.Equal(t, http.StatusCreated, w.Code)
+		tag := &models.Tag{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), tag))
+		assert.Equal(t, "beach volleyball", tag.Name)
+
+		require.Equal(t, http.StatusConflict, request("POST", "/tags", `{"name":"BEACH VOLLEYBALL"}`, user).Code)
+		require.Equal(t, http.StatusBadRequest, request("POST", "/tags", `{"name":" "}`, user).Code)
+
+		other := createTag(t, "beginners")
+		require.Equal(t, http.StatusForbidden, request("PATCH", "/tags/"+tag.UID.String(), `{"name":"Volleyball"}`, user).Code)
+		require.Equal(t, http.StatusConflict, request("PATCH", "/tags/"+tag.UID.String(), `{"name":"Beginners"}`, admin).Code)
+		require.Equal(t, http.StatusOK, request("PATCH", "/tags/"+tag.UID.String(), `{"name":"Beach Volleyball"}`, admin).Code)
+		require.Equal(t, http.StatusOK, request("PATCH", "/tags/"+tag.UID.String(), `{"name":"Volleyball"}`, admin).Code)
+
+		tags := []*models.Tag{}
+		w = request("GET", "/tags", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+		require.Len(t, tags, 2)
+		assert.Equal(t, "beginners", tags[0].Name)
+		assert.Equal(t, "volleyball", tags[1].Name)
+
+		require.Equal(t, http.StatusForbidden, request("DELETE", "/tags/"+other.UID.String(), "", user).Code)
+		require.Equal(t, http.StatusNoContent, request("DELETE", "/tags/"+other.UID.String(), "", admin).Code)
+		require.Equal(t, http.StatusNotFound, request("GET", "/tags/"+other.UID.String(), "", nil).Code)
+	})
+
+	t.Run("events, clubs and groups can be tagged and filtered by tags", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		beginners := createTag(t, "beginners")
+		outdoor := createTag(t, "outdoor")
+
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, admin.UID))
+		group := models.NewGroup()
+		_, err = db.Save(dbDriver, group)
+		require.NoError(t, err)
+		_, err = db.CreateRelation(dbDriver, group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+
+		events := []*models.Event{}
+		for _, tags := range [][]*models.Tag{{beginners}, {beginners, outdoor}, {outdoor}, {}} {
+			event := models.NewEvent()
+			_, err = db.Save(dbDriver, event)
+			require.NoError(t, err)
+			_, err = db.CreateRelation(dbDriver, event.UID, club.UID, models.EventHostedByGroupOrClub)
+			require.NoError(t, err)
+			for _, tag := range tags {
+				w := request("PUT", "/events/"+event.UID.String()+"/tags/"+tag.UID.String(), "", admin)
+				require.Equal(t, http.StatusOK, w.Code)
+			}
+			events = append(events, event)
+		}
+		// tagging twice doesn't tag twice
+		require.Equal(t, http.StatusOK, request("PUT", "/events/"+events[0].UID.String()+"/tags/"+beginners.UID.String(), "", admin).Code)
+
+		eventUIDs := func(path string) []uuid.UUID {
+			w := request("GET", path, "", nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			found := []*models.Event{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+			uids := []uuid.UUID{}
+			for _, event := range found {
+				uids = append(uids, event.UID)
+			}
+			return uids
+		}
+		assert.ElementsMatch(t, []uuid.UUID{events[1].UID}, eventUIDs("/events?tags=beginners,Outdoor"))
+		assert.ElementsMatch(t, []uuid.UUID{events[0].UID, events[1].UID, events[2].UID}, eventUIDs("/events?tags=beginners,outdoor&tags_match=any"))
+		assert.ElementsMatch(t, []uuid.UUID{events[0].UID, events[1].UID}, eventUIDs("/clubs/"+club.UID.String()+"/events?tags=beginners"))
+		assert.Len(t, eventUIDs("/events"), 4)
+
+		require.Equal(t, http.StatusForbidden, request("PUT", "/clubs/"+club.UID.String()+"/tags/"+outdoor.UID.String(), "", testhelpers.CreateSomeUser(dbDriver)).Code)
+		require.Equal(t, http.StatusOK, request("PUT", "/clubs/"+club.UID.String()+"/tags/"+outdoor.UID.String(), "", admin).Code)
+		require.Equal(t, http.StatusOK, request("PUT", "/groups/"+group.UID.String()+"/tags/"+beginners.UID.String(), "", admin).Code)
+
+		clubs := []*models.Club{}
+		w := request("GET", "/clubs?tags=outdoor", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		require.Len(t, clubs, 1)
+		groups := []*models.Group{}
+		w = request("GET", "/clubs/"+club.UID.String()+"/groups?tags=outdoor", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &groups))
+		assert.Empty(t, groups)
+
+		require.Equal(t, http.StatusNoContent, request("DELETE", "/events/"+events[1].UID.String()+"/tags/"+outdoor.UID.String(), "", admin).Code)
+		tags := []*models.Tag{}
+		w = request("GET", "/events/"+events[1].UID.String()+"/tags", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+		require.Len(t, tags, 1)
+		assert.Equal(t, beginners.UID, tags[0].UID)
+		require.Equal(t, http.StatusNotFound, request("GET", "/clubs/"+events[1].UID.String()+"/tags", "", nil).Code)
+
+		require.Equal(t, http.StatusBadRequest, request("GET", "/events?tags=outdoor&tags_match=some", "", nil).Code)
+	})
+}
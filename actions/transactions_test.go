@@ -0,0 +1,91 @@
+package actions_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/alexmorten/events-api/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Transact(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+
+	var mutex sync.Mutex
+	changed := map[string]bool{}
+	stopListening := db.OnChange(func(change db.Change) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		changed[change.UID] = true
+	})
+	defer stopListening()
+	wasChanged := func(uid string) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return changed[uid]
+	}
+
+	t.Run("work that fails is rolled back and listeners aren't told about it", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		group := models.NewGroup()
+
+		err := db.Transact(dbDriver, func(tx *db.Tx) error {
+			_, err := tx.CreateBy(group, user.UID)
+			require.NoError(t, err)
+			// the parent doesn't exist
+			_, err = tx.CreateRelation(group.UID, models.NewClub().UID, models.GroupBelongsToGroupOrClub)
+			return err
+		})
+		require.Error(t, err)
+
+		_, err = db.FindByUID(dbDriver, group, group.UID.String())
+		assert.True(t, db.IsNotFound(err))
+		assert.False(t, wasChanged(group.UID.String()))
+	})
+
+	t.Run("work that succeeds is committed together", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		user := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		group := models.NewGroup()
+
+		err := db.Transact(dbDriver, func(tx *db.Tx) error {
+			_, err := tx.Save(club)
+			if err != nil {
+				return err
+			}
+			_, err = tx.CreateBy(group, user.UID)
+			if err != nil {
+				return err
+			}
+			assert.False(t, wasChanged(group.UID.String()))
+			_, err = tx.CreateRelation(group.UID, club.UID, models.GroupBelongsToGroupOrClub)
+			return err
+		})
+		require.NoError(t, err)
+
+		relation, err := db.FindRelation(dbDriver, group.UID.String(), club.UID.String(), models.GroupBelongsToGroupOrClub)
+		require.NoError(t, err)
+		assert.NotNil(t, relation)
+		assert.True(t, wasChanged(group.UID.String()))
+		assert.True(t, wasChanged(club.UID.String()))
+	})
+
+	t.Run("errors of the work are returned as they are", func(t *testing.T) {
+		errSomething := errors.New("something")
+		calls := 0
+		err := db.Transact(dbDriver, func(tx *db.Tx) error {
+			calls++
+			return errSomething
+		})
+		assert.Equal(t, errSomething, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,29 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+)
+
+//RegisterUserRoutes within the given router group
+func (h *ActionHandler) RegisterUserRoutes(group *gin.RouterGroup) {
+	group.GET("/me/memberships", h.getMyMemberships)
+}
+
+//getMyMemberships lists the clubs and groups the current user is a member of or asked to join
+func (h *ActionHandler) getMyMemberships(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	memberships, err := models.FindMembershipsOfUser(h.dbDriver, currentUserClaim.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, memberships)
+}
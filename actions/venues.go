@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+const (
+	defaultRadiusKilometers = 10
+	maxRadiusKilometers     = 500
+)
+
+var errInvalidNear = errors.New("near has to be latitude,longitude")
+
+//RegisterVenueRoutes within the given router group
+func (h *ActionHandler) RegisterVenueRoutes(group *gin.RouterGroup) {
+	group.GET("/:uid", h.getVenue)
+	group.GET("", h.getVenues)
+	group.PATCH("/:uid", h.updateVenue)
+	group.POST("", h.postVenues)
+	group.DELETE("/:uid", h.deleteVenue)
+}
+
+func (h *ActionHandler) getVenue(c *gin.Context) {
+	getFromRepository(c, h.repository(&models.Venue{}))
+}
+
+//getVenues lists all venues, ?near=lat,lng&radius=km lists the venues within radius, nearest first
+func (h *ActionHandler) getVenues(c *gin.Context) {
+	near, radiusMeters, err := nearQueryParams(c)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	query := "match (n:Venue) return properties(n) order by n.name"
+	params := map[string]interface{}{}
+	if near != nil {
+		query = "match (n:Venue) with n, distance(n.location, $near) as distance where distance <= $radius return properties(n) order by distance"
+		params = map[string]interface{}{"near": near.NeoPoint(), "radius": radiusMeters}
+	}
+
+	dbSession, err := h.dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	venues := []*models.Venue{}
+	for _, record := range records {
+		props, ok := record.GetByIndex(0).(map[string]interface{})
+		if ok {
+			venues = append(venues, models.VenueFromProps(props))
+		}
+	}
+	setTotalCount(c, int64(len(venues)))
+	c.JSON(http.StatusOK, venues)
+}
+
+func (h *ActionHandler) postVenues(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	venue := models.NewVenue()
+	venueAttributes := &models.VenueAttributes{}
+	err := c.ShouldBindJSON(venueAttributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	err = venueAttributes.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	venue.VenueAttributes = *venueAttributes
+	props, err := db.CreateBy(h.dbDriver, venue, currentUserClaim.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.VenueFromProps(props))
+}
+
+type venueAttributesUpdate struct {
+	Name     *string   `json:"name"`
+	Address  *string   `json:"address"`
+	Location *db.Point `json:"location"`
+}
+
+func (h *ActionHandler) updateVenue(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	venue, err := models.FindVenue(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !venue.CanBeEditedBy(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	updateAttributes := &venueAttributesUpdate{}
+	err = c.ShouldBindJSON(updateAttributes)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	models.UpdateFrom(&venue.VenueAttributes, updateAttributes)
+	err = venue.Validate()
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	venueProps, err := db.Save(h.dbDriver, venue)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if updateAttributes.Location != nil {
+		err = models.NotifyChangesAtVenue(h.dbDriver, venue.UID)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.VenueFromProps(venueProps))
+}
+
+func (h *ActionHandler) deleteVenue(c *gin.Context) {
+	currentUserClaim := h.currentUserClaim(c)
+	if currentUserClaim == nil {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	venue, err := models.FindVenue(h.dbDriver, c.Param("uid"))
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+	if !venue.CanBeEditedBy(h.dbDriver, currentUserClaim.UID) {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	err = models.DeleteVenue(h.dbDriver, venue.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+type venueReference struct {
+	VenueUID uuid.UUID `json:"venue_uid" binding:"required"`
+}
+
+//getVenueOf the event or club the route is about, 404 if there is none with the uid or it has no venue
+func (h *ActionHandler) getVenueOf(c *gin.Context) {
+	uid, err := uuid.Parse(c.Param("uid"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.routeNodeExists(c, uid.String()) {
+		return
+	}
+
+	venue, err := models.FindVenueOf(h.dbDriver, uid, routeLabel(c))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if venue == nil {
+		c.AbortWithError(http.StatusNotFound, errors.New("no venue set"))
+		return
+	}
+	c.JSON(http.StatusOK, venue)
+}
+
+func (h *ActionHandler) putEventVenue(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.setVenue(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteEventVenue(c *gin.Context) {
+	event, ok := h.editableEvent(c)
+	if ok {
+		h.removeVenue(c, event.UID, event.NodeName())
+	}
+}
+
+func (h *ActionHandler) putClubVenue(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.setVenue(c, club.UID, club.NodeName())
+	}
+}
+
+func (h *ActionHandler) deleteClubVenue(c *gin.Context) {
+	club, ok := h.administeredClub(c)
+	if ok {
+		h.removeVenue(c, club.UID, club.NodeName())
+	}
+}
+
+func (h *ActionHandler) setVenue(c *gin.Context, uid uuid.UUID, label string) {
+	reference := &venueReference{}
+	err := c.ShouldBindJSON(reference)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	venue, err := models.FindVenue(h.dbDriver, reference.VenueUID.String())
+	if err != nil {
+		abortWithFindError(c, err)
+		return
+	}
+
+	err = models.SetVenue(h.dbDriver, uid, label, venue.UID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, venue)
+}
+
+func (h *ActionHandler) removeVenue(c *gin.Context, uid uuid.UUID, label string) {
+	err := models.RemoveVenue(h.dbDriver, uid, label)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+//nearQueryParams reads ?near=lat,lng and ?radius in kilometers, near is nil if it isn't given
+func nearQueryParams(c *gin.Context) (near *db.Point, radiusMeters float64, err error) {
+	value := c.Query("near")
+	if value == "" {
+		return nil, 0, nil
+	}
+
+	coordinates := strings.Split(value, ",")
+	if len(coordinates) != 2 {
+		return nil, 0, errInvalidNear
+	}
+	latitude, latitudeErr := strconv.ParseFloat(strings.TrimSpace(coordinates[0]), 64)
+	longitude, longitudeErr := strconv.ParseFloat(strings.TrimSpace(coordinates[1]), 64)
+	point := &db.Point{Latitude: latitude, Longitude: longitude}
+	if latitudeErr != nil || longitudeErr != nil || point.Validate() != nil {
+		return nil, 0, errInvalidNear
+	}
+
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius", strconv.Itoa(defaultRadiusKilometers)), 64)
+	if err != nil || radius <= 0 || radius > maxRadiusKilometers {
+		return nil, 0, fmt.Errorf("radius has to be a number of kilometers between 0 and %v", maxRadiusKilometers)
+	}
+	return point, radius * 1000, nil
+}
@@ -0,0 +1,145 @@
+package actions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexmorten/events-api/models"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexmorten/events-api/testhelpers"
+
+	"github.com/alexmorten/events-api/db"
+)
+
+func Test_Venues(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+	s := api.NewServer(config)
+	require.NoError(t, s.Init())
+	defer s.Close()
+
+	request := func(method, path, body string, user *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		if user != nil {
+			testhelpers.AddAuthorizationHeader(req, user)
+		}
+		s.Engine.ServeHTTP(w, req)
+		return w
+	}
+
+	createVenue := func(t *testing.T, name string, latitude, longitude float64) *models.Venue {
+		venue := models.NewVenue()
+		venue.Name = name
+		venue.Location = &db.Point{Latitude: latitude, Longitude: longitude}
+		_, err := db.Save(dbDriver, venue)
+		require.NoError(t, err)
+		return venue
+	}
+
+	t.Run("users can create venues, only their creator can change them", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		creator := testhelpers.CreateSomeUser(dbDriver)
+
+		w := request("POST", "/venues", `{"name":"Boathouse","address":"Am Großen Wannsee 1","location":{"latitude":52.43,"longitude":13.17}}`, nil)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		w = request("POST", "/venues", `{"name":"Boathouse","location":{"latitude":152.43,"longitude":13.17}}`, creator)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = request("POST", "/venues", `{"name":"Boathouse"}`, creator)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = request("POST", "/venues", `{"name":"Boathouse","address":"Am Großen Wannsee 1","location":{"latitude":52.43,"longitude":13.17}}`, creator)
+		require.Equal(t, http.StatusCreated, w.Code)
+		venue := &models.Venue{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), venue))
+
+		found, err := models.FindVenue(dbDriver, venue.UID.String())
+		require.NoError(t, err)
+		assert.Equal(t, &db.Point{Latitude: 52.43, Longitude: 13.17}, found.Location)
+		assert.Equal(t, "Am Großen Wannsee 1", found.Address)
+
+		w = request("PATCH", "/venues/"+venue.UID.String(), `{"name":"Old Boathouse"}`, testhelpers.CreateSomeUser(dbDriver))
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = request("PATCH", "/venues/"+venue.UID.String(), `{"name":"Old Boathouse"}`, creator)
+		require.Equal(t, http.StatusOK, w.Code)
+		found, err = models.FindVenue(dbDriver, venue.UID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "Old Boathouse", found.Name)
+		assert.Equal(t, 52.43, found.Location.Latitude)
+
+		w = request("DELETE", "/venues/"+venue.UID.String(), "", creator)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, http.StatusNotFound, request("GET", "/venues/"+venue.UID.String(), "", nil).Code)
+	})
+
+	t.Run("events and clubs can be found near a point, nearest first", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		admin := testhelpers.CreateSomeUser(dbDriver)
+		club := models.NewClub()
+		_, err := db.Save(dbDriver, club)
+		require.NoError(t, err)
+		require.NoError(t, models.AddAdminToClub(dbDriver, club.UID, admin.UID))
+
+		mitte := createVenue(t, "Mitte", 52.52, 13.405)
+		potsdam := createVenue(t, "Potsdam", 52.3906, 13.0645)
+		munich := createVenue(t, "Munich", 48.137, 11.575)
+
+		events := []*models.Event{}
+		for _, venue := range []*models.Venue{potsdam, munich, mitte} {
+			event := models.NewEvent()
+			event.Name = "Training in " + venue.Name
+			event.StartsAt = time.Now()
+			_, err = db.Save(dbDriver, event)
+			require.NoError(t, err)
+			_, err = db.CreateRelation(dbDriver, event.UID, club.UID, models.EventHostedByGroupOrClub)
+			require.NoError(t, err)
+
+			w := request("PUT", "/events/"+event.UID.String()+"/venue", `{"venue_uid":"`+venue.UID.String()+`"}`, admin)
+			require.Equal(t, http.StatusOK, w.Code)
+			events = append(events, event)
+		}
+
+		w := request("GET", "/events?near=52.52,13.405&radius=50", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		found := []*models.Event{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+		require.Len(t, found, 2)
+		assert.Equal(t, events[2].UID, found[0].UID)
+		assert.Equal(t, events[0].UID, found[1].UID)
+
+		w = request("PUT", "/clubs/"+club.UID.String()+"/venue", `{"venue_uid":"`+munich.UID.String()+`"}`, testhelpers.CreateSomeUser(dbDriver))
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = request("PUT", "/clubs/"+club.UID.String()+"/venue", `{"venue_uid":"`+munich.UID.String()+`"}`, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		clubs := []*models.Club{}
+		w = request("GET", "/clubs?near=52.52,13.405", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		assert.Empty(t, clubs)
+		w = request("GET", "/clubs?near=48.14,11.58&radius=5", "", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clubs))
+		require.Len(t, clubs, 1)
+		require.Equal(t, http.StatusOK, request("GET", "/clubs/"+club.UID.String()+"/venue", "", nil).Code)
+		require.Equal(t, http.StatusNotFound, request("GET", "/events/"+club.UID.String()+"/venue", "", nil).Code)
+
+		w = request("DELETE", "/events/"+events[2].UID.String()+"/venue", "", admin)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, http.StatusNotFound, request("GET", "/events/"+events[2].UID.String()+"/venue", "", nil).Code)
+		venue, err := models.FindVenueOf(dbDriver, events[0].UID, events[0].NodeName())
+		require.NoError(t, err)
+		assert.Equal(t, potsdam.UID, venue.UID)
+	})
+
+	t.Run("invalid near queries are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/events?near=52.52", "", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/clubs?near=95,13.4", "", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, request("GET", "/venues?near=52.52,13.4&radius=-1", "", nil).Code)
+	})
+}
@@ -0,0 +1,281 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const productID = "-//alexmorten//events-api//EN"
+
+//maxLineLength in octets before a content line gets folded
+const maxLineLength = 75
+
+//Event is everything we need to know about an event to render it as a VEVENT
+type Event struct {
+	UID          string
+	Summary      string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	TimeZone     string
+	RRule        string
+	ExDates      []time.Time
+	RDates       []time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+//WriteCalendar renders the events as a VCALENDAR with the given name, including VTIMEZONE blocks for all time zones used
+func WriteCalendar(w io.Writer, name string, events []Event) error {
+	writer := &contentWriter{w: bufio.NewWriter(w)}
+
+	writer.line("BEGIN:VCALENDAR")
+	writer.line("VERSION:2.0")
+	writer.line("PRODID:" + productID)
+	writer.line("CALSCALE:GREGORIAN")
+	writer.line("METHOD:PUBLISH")
+	if name != "" {
+		writer.line("X-WR-CALNAME:" + EscapeText(name))
+	}
+
+	for _, timeZone := range usedTimeZones(events) {
+		writeTimeZone(writer, timeZone, events)
+	}
+	for _, event := range events {
+		writeEvent(writer, event)
+	}
+
+	writer.line("END:VCALENDAR")
+	if writer.err != nil {
+		return writer.err
+	}
+	return writer.w.Flush()
+}
+
+func writeEvent(writer *contentWriter, event Event) {
+	writer.line("BEGIN:VEVENT")
+	writer.line("UID:" + EscapeText(event.UID))
+	stamp := event.LastModified
+	if stamp.IsZero() {
+		stamp = event.Created
+	}
+	writer.line("DTSTAMP:" + FormatUTC(stamp))
+	if !event.Created.IsZero() {
+		writer.line("CREATED:" + FormatUTC(event.Created))
+	}
+	if !event.LastModified.IsZero() {
+		writer.line("LAST-MODIFIED:" + FormatUTC(event.LastModified))
+	}
+	writer.line("SUMMARY:" + EscapeText(event.Summary))
+	writer.line("DTSTART" + event.dateTimeValue(event.Start))
+	if !event.End.IsZero() {
+		writer.line("DTEND" + event.dateTimeValue(event.End))
+	}
+	if event.RRule != "" {
+		writer.line("RRULE:" + strings.TrimPrefix(event.RRule, "RRULE:"))
+	}
+	for _, exdate := range event.ExDates {
+		writer.line("EXDATE" + event.dateTimeValue(exdate))
+	}
+	for _, rdate := range event.RDates {
+		writer.line("RDATE" + event.dateTimeValue(rdate))
+	}
+	writer.line("END:VEVENT")
+}
+
+//dateTimeValue returns the parameters and value of a date time property of the event, starting with ";" or ":"
+func (e *Event) dateTimeValue(t time.Time) string {
+	if e.AllDay {
+		return ";VALUE=DATE:" + FormatDate(e.in(t))
+	}
+	if e.TimeZone == "" {
+		return ":" + FormatUTC(t)
+	}
+	return fmt.Sprintf(";TZID=%v:%v", e.TimeZone, FormatLocal(e.in(t)))
+}
+
+func (e *Event) in(t time.Time) time.Time {
+	if e.TimeZone == "" {
+		return t.UTC()
+	}
+	location, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return t.UTC()
+	}
+	return t.In(location)
+}
+
+func usedTimeZones(events []Event) []string {
+	used := map[string]bool{}
+	for _, event := range events {
+		if event.TimeZone != "" && !event.AllDay {
+			used[event.TimeZone] = true
+		}
+	}
+	timeZones := []string{}
+	for timeZone := range used {
+		timeZones = append(timeZones, timeZone)
+	}
+	sort.Strings(timeZones)
+	return timeZones
+}
+
+//writeTimeZone renders a VTIMEZONE with all offset transitions in the years the events of that zone span
+func writeTimeZone(writer *contentWriter, timeZone string, events []Event) {
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return
+	}
+
+	firstYear, lastYear := 0, 0
+	for _, event := range events {
+		if event.TimeZone != timeZone || event.AllDay {
+			continue
+		}
+		start, end := event.Start.In(location).Year(), event.Start.In(location).Year()
+		if event.RRule != "" {
+			end = time.Now().In(location).Year() + 5
+		}
+		if firstYear == 0 || start < firstYear {
+			firstYear = start
+		}
+		if end > lastYear {
+			lastYear = end
+		}
+	}
+
+	writer.line("BEGIN:VTIMEZONE")
+	writer.line("TZID:" + timeZone)
+
+	transitions := zoneTransitions(location, firstYear, lastYear)
+	if len(transitions) == 0 {
+		// zones without transitions get a single observance valid since the epoch
+		name, offset := time.Date(firstYear, time.January, 1, 0, 0, 0, 0, location).Zone()
+		transitions = append(transitions, zoneTransition{
+			at:         time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC),
+			offsetFrom: offset,
+			offsetTo:   offset,
+			name:       name,
+		})
+	}
+	for _, transition := range transitions {
+		component := "STANDARD"
+		if transition.daylight {
+			component = "DAYLIGHT"
+		}
+		writer.line("BEGIN:" + component)
+		localStart := transition.at.In(time.FixedZone("", transition.offsetFrom))
+		writer.line("DTSTART:" + FormatLocal(localStart))
+		writer.line("TZOFFSETFROM:" + formatOffset(transition.offsetFrom))
+		writer.line("TZOFFSETTO:" + formatOffset(transition.offsetTo))
+		writer.line("TZNAME:" + EscapeText(transition.name))
+		writer.line("END:" + component)
+	}
+
+	writer.line("END:VTIMEZONE")
+}
+
+type zoneTransition struct {
+	at         time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	daylight   bool
+}
+
+//zoneTransitions finds the changes of the utc offset of location between the start of firstYear and the end of lastYear
+func zoneTransitions(location *time.Location, firstYear, lastYear int) []zoneTransition {
+	transitions := []zoneTransition{}
+	for year := firstYear; year <= lastYear; year++ {
+		_, januaryOffset := time.Date(year, time.January, 1, 0, 0, 0, 0, location).Zone()
+		_, julyOffset := time.Date(year, time.July, 1, 0, 0, 0, 0, location).Zone()
+		standardOffset := januaryOffset
+		if julyOffset < standardOffset {
+			standardOffset = julyOffset
+		}
+
+		day := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		for ; day.Before(end); day = day.Add(24 * time.Hour) {
+			next := day.Add(24 * time.Hour)
+			_, offsetBefore := day.In(location).Zone()
+			_, offsetAfter := next.In(location).Zone()
+			if offsetBefore == offsetAfter {
+				continue
+			}
+
+			// narrow down the exact second of the change
+			low, high := day.Unix(), next.Unix()
+			for high-low > 1 {
+				middle := low + (high-low)/2
+				if _, offset := time.Unix(middle, 0).In(location).Zone(); offset == offsetBefore {
+					low = middle
+				} else {
+					high = middle
+				}
+			}
+			at := time.Unix(high, 0).UTC()
+			name, _ := at.In(location).Zone()
+			transitions = append(transitions, zoneTransition{
+				at:         at,
+				offsetFrom: offsetBefore,
+				offsetTo:   offsetAfter,
+				name:       name,
+				daylight:   offsetAfter > standardOffset,
+			})
+		}
+	}
+	return transitions
+}
+
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%v%02d%02d", sign, offset/3600, (offset%3600)/60)
+}
+
+//EscapeText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func EscapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+//contentWriter writes folded content lines terminated by CRLF and remembers the first error
+type contentWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *contentWriter) line(line string) {
+	if cw.err != nil {
+		return
+	}
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// don't split multi byte utf-8 sequences
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		_, cw.err = cw.w.WriteString(line[:cut] + "\r\n ")
+		if cw.err != nil {
+			return
+		}
+		line = line[cut:]
+		// continuation lines start with a space
+		limit = maxLineLength - 1
+	}
+	_, cw.err = cw.w.WriteString(line + "\r\n")
+}
@@ -0,0 +1,75 @@
+package calendar_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteCalendar(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	created := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	events := []calendar.Event{
+		{
+			UID:          "a5d6f9a4-4a4e-4b8c-8d5b-6a2f0e0b1c2d",
+			Summary:      "Training, beginners; bring shoes",
+			Start:        time.Date(2019, 3, 26, 18, 0, 0, 0, berlin),
+			End:          time.Date(2019, 3, 26, 20, 0, 0, 0, berlin),
+			TimeZone:     "Europe/Berlin",
+			RRule:        "FREQ=WEEKLY;COUNT=3",
+			ExDates:      []time.Time{time.Date(2019, 4, 2, 18, 0, 0, 0, berlin)},
+			Created:      created,
+			LastModified: created,
+		},
+		{
+			UID:     "b5d6f9a4-4a4e-4b8c-8d5b-6a2f0e0b1c2d",
+			Summary: "Club trip",
+			Start:   time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+			End:     time.Date(2019, 5, 3, 0, 0, 0, 0, time.UTC),
+			AllDay:  true,
+			Created: created,
+		},
+	}
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, calendar.WriteCalendar(buffer, "FC Example", events))
+	ics := buffer.String()
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "X-WR-CALNAME:FC Example\r\n")
+	assert.Contains(t, ics, "TZID:Europe/Berlin\r\n")
+	assert.Contains(t, ics, "BEGIN:DAYLIGHT\r\nDTSTART:20190331T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT\r\n")
+	assert.Contains(t, ics, "BEGIN:STANDARD\r\nDTSTART:20191027T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD\r\n")
+	assert.Contains(t, ics, "UID:a5d6f9a4-4a4e-4b8c-8d5b-6a2f0e0b1c2d\r\n")
+	assert.Contains(t, ics, "SUMMARY:Training\\, beginners\\; bring shoes\r\n")
+	assert.Contains(t, ics, "DTSTART;TZID=Europe/Berlin:20190326T180000\r\n")
+	assert.Contains(t, ics, "RRULE:FREQ=WEEKLY;COUNT=3\r\n")
+	assert.Contains(t, ics, "EXDATE;TZID=Europe/Berlin:20190402T180000\r\n")
+	assert.Contains(t, ics, "DTSTART;VALUE=DATE:20190501\r\nDTEND;VALUE=DATE:20190503\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20190301T120000Z\r\n")
+}
+
+func Test_WriteCalendarFoldsLongLines(t *testing.T) {
+	events := []calendar.Event{{
+		UID:     "c5d6f9a4-4a4e-4b8c-8d5b-6a2f0e0b1c2d",
+		Summary: strings.Repeat("Ä", 100),
+		Start:   time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC),
+	}}
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, calendar.WriteCalendar(buffer, "", events))
+
+	for _, line := range strings.Split(buffer.String(), "\r\n") {
+		assert.True(t, len(line) <= 75, line)
+	}
+	unfolded := strings.Replace(buffer.String(), "\r\n ", "", -1)
+	assert.Contains(t, unfolded, "SUMMARY:"+strings.Repeat("Ä", 100)+"\r\n")
+}
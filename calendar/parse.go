@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ParsedEvent is a VEVENT read from a calendar, Err is set if the event couldn't be understood
+type ParsedEvent struct {
+	Event
+	Err error
+}
+
+//contentLine is a single unfolded line like DTSTART;TZID=Europe/Berlin:20190402T180000
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+//ParseEvents reads all VEVENTs of an iCalendar stream.
+//Errors within single events are reported on the event, an error is only returned if the stream isn't a calendar at all
+func ParseEvents(r io.Reader) ([]ParsedEvent, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, errors.New("not an iCalendar file, expected BEGIN:VCALENDAR")
+	}
+
+	events := []ParsedEvent{}
+	var current []contentLine
+	depth := 0
+	for _, raw := range lines {
+		line, err := parseContentLine(raw)
+		if err != nil {
+			if current != nil {
+				current = append(current, contentLine{name: "X-INVALID", value: raw})
+			}
+			continue
+		}
+
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT"):
+			current = []contentLine{}
+			depth = 0
+		case line.name == "BEGIN" && current != nil:
+			// nested components like VALARM are ignored
+			depth++
+		case line.name == "END" && current != nil && depth > 0:
+			depth--
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT") && current != nil:
+			events = append(events, eventFromLines(current))
+			current = nil
+		case current != nil && depth == 0:
+			current = append(current, line)
+		}
+	}
+	return events, nil
+}
+
+func eventFromLines(lines []contentLine) ParsedEvent {
+	parsed := ParsedEvent{}
+	event := &parsed.Event
+	var duration time.Duration
+	hasDuration := false
+
+	fail := func(err error) ParsedEvent {
+		parsed.Err = err
+		return parsed
+	}
+
+	for _, line := range lines {
+		var err error
+		switch line.name {
+		case "UID":
+			event.UID = UnescapeText(line.value)
+		case "SUMMARY":
+			event.Summary = UnescapeText(line.value)
+		case "DTSTART":
+			event.Start, err = event.parseDateTimeLine(line)
+		case "DTEND":
+			event.End, err = event.parseDateTimeLine(line)
+		case "DURATION":
+			duration, err = ParseDuration(line.value)
+			hasDuration = true
+		case "RRULE":
+			_, err = ParseRRule(line.value)
+			event.RRule = line.value
+		case "EXDATE", "RDATE":
+			var dates []time.Time
+			dates, err = event.parseDateTimeList(line)
+			if line.name == "EXDATE" {
+				event.ExDates = append(event.ExDates, dates...)
+			} else {
+				event.RDates = append(event.RDates, dates...)
+			}
+		case "RECURRENCE-ID":
+			err = errors.New("changed occurrences of recurring events (RECURRENCE-ID) are not supported")
+		case "CREATED":
+			event.Created, _ = ParseDateTime(line.value, nil)
+		case "LAST-MODIFIED":
+			event.LastModified, _ = ParseDateTime(line.value, nil)
+		case "X-INVALID":
+			err = fmt.Errorf("invalid content line %q", line.value)
+		}
+		if err != nil {
+			return fail(fmt.Errorf("%v: %v", line.name, err))
+		}
+	}
+
+	if event.UID == "" {
+		return fail(errors.New("UID is missing"))
+	}
+	if event.Start.IsZero() {
+		return fail(errors.New("DTSTART is missing"))
+	}
+	if hasDuration && event.End.IsZero() {
+		if event.AllDay {
+			event.End = event.Start.AddDate(0, 0, int(duration.Hours()/24))
+		} else {
+			event.End = event.Start.Add(duration)
+		}
+	}
+	return parsed
+}
+
+//parseDateTimeLine parses DTSTART/DTEND and remembers zone and all day information on the event
+func (e *Event) parseDateTimeLine(line contentLine) (time.Time, error) {
+	dates, err := e.parseDateTimeList(line)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(dates) != 1 {
+		return time.Time{}, errors.New("expected a single value")
+	}
+	if line.name == "DTSTART" {
+		e.AllDay = strings.EqualFold(line.params["VALUE"], "DATE")
+	}
+	return dates[0], nil
+}
+
+func (e *Event) parseDateTimeList(line contentLine) ([]time.Time, error) {
+	location := time.UTC
+	if timeZone := line.params["TZID"]; timeZone != "" {
+		loaded, err := time.LoadLocation(strings.Trim(timeZone, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", timeZone)
+		}
+		location = loaded
+		if e.TimeZone == "" {
+			e.TimeZone = loaded.String()
+		}
+	}
+
+	dates := []time.Time{}
+	for _, value := range strings.Split(line.value, ",") {
+		if strings.EqualFold(line.params["VALUE"], "PERIOD") || strings.Contains(value, "/") {
+			return nil, errors.New("periods are not supported")
+		}
+		t, err := ParseDateTime(value, location)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, t)
+	}
+	return dates, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-]?)P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+//ParseDuration parses a RFC 5545 DURATION like PT1H30M or P2D
+func ParseDuration(value string) (time.Duration, error) {
+	matches := durationPattern.FindStringSubmatch(strings.ToUpper(value))
+	if matches == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	duration := time.Duration(0)
+	for i, unit := range units {
+		if matches[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+2])
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(n) * unit
+	}
+	if matches[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+//UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(s)
+}
+
+//unfoldLines joins folded content lines and drops empty ones
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, strings.TrimPrefix(line, "\ufeff"))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func parseContentLine(raw string) (contentLine, error) {
+	inQuotes := false
+	separator := -1
+	for i, r := range raw {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			separator = i
+			break
+		}
+	}
+	if separator < 1 {
+		return contentLine{}, fmt.Errorf("invalid content line %q", raw)
+	}
+
+	parts := strings.Split(raw[:separator], ";")
+	line := contentLine{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  raw[separator+1:],
+	}
+	for _, param := range parts[1:] {
+		keyValue := strings.SplitN(param, "=", 2)
+		if len(keyValue) == 2 {
+			line.params[strings.ToUpper(keyValue[0])] = strings.Trim(keyValue[1], `"`)
+		}
+	}
+	return line, nil
+}
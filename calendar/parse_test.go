@@ -0,0 +1,102 @@
+package calendar_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseEvents(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:training@example.com",
+		"SUMMARY:Training\\, beginners",
+		"DTSTART;TZID=Europe/Berlin:20190326T180000",
+		"DURATION:PT1H30M",
+		"RRULE:FREQ=WEEKLY;COUNT=3",
+		"EXDATE;TZID=Europe/Berlin:20190402T180000",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:trip@example.com",
+		"SUMMARY:Club trip with a summary that is long enough to be folded by the",
+		"  exporting application",
+		"DTSTART;VALUE=DATE:20190501",
+		"DTEND;VALUE=DATE:20190503",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:no uid",
+		"DTSTART:20190501T100000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:moved@example.com",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20190409T180000",
+		"DTSTART;TZID=Europe/Berlin:20190409T190000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := calendar.ParseEvents(strings.NewReader(ics))
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	training := events[0]
+	require.NoError(t, training.Err)
+	assert.Equal(t, "training@example.com", training.UID)
+	assert.Equal(t, "Training, beginners", training.Summary)
+	assert.Equal(t, "Europe/Berlin", training.TimeZone)
+	assert.True(t, time.Date(2019, 3, 26, 18, 0, 0, 0, berlin).Equal(training.Start))
+	assert.True(t, time.Date(2019, 3, 26, 19, 30, 0, 0, berlin).Equal(training.End))
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=3", training.RRule)
+	require.Len(t, training.ExDates, 1)
+	assert.True(t, time.Date(2019, 4, 2, 18, 0, 0, 0, berlin).Equal(training.ExDates[0]))
+
+	trip := events[1]
+	require.NoError(t, trip.Err)
+	assert.Equal(t, "Club trip with a summary that is long enough to be folded by the exporting application", trip.Summary)
+	assert.True(t, trip.AllDay)
+	assert.True(t, time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC).Equal(trip.Start))
+	assert.True(t, time.Date(2019, 5, 3, 0, 0, 0, 0, time.UTC).Equal(trip.End))
+
+	assert.Error(t, events[2].Err)
+	assert.Error(t, events[3].Err)
+}
+
+func Test_ParseEventsRejectsOtherFiles(t *testing.T) {
+	_, err := calendar.ParseEvents(strings.NewReader("name,starts_at\ntraining,2019-04-02"))
+	assert.Error(t, err)
+}
+
+func Test_ParseDuration(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+	} {
+		duration, err := calendar.ParseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, duration, value)
+	}
+
+	for _, value := range []string{"", "P", "PT", "1H", "PT1X"} {
+		_, err := calendar.ParseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func Test_UnescapeText(t *testing.T) {
+	text := "Training, beginners; bring shoes\nand water \\ juice"
+	assert.Equal(t, text, calendar.UnescapeText(calendar.EscapeText(text)))
+}
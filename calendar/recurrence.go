@@ -0,0 +1,146 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	utcDateTimeLayout = "20060102T150405Z"
+	dateTimeLayout    = "20060102T150405"
+	dateLayout        = "20060102"
+)
+
+//Recurrence combines a recurrence rule with explicitly excluded (EXDATE) and added (RDATE) occurrences
+type Recurrence struct {
+	Rule    *RRule
+	ExDates []time.Time
+	RDates  []time.Time
+}
+
+//Between returns the sorted occurrences of the series starting at dtstart that start within [from, to).
+//At most MaxOccurrences are returned
+func (r *Recurrence) Between(dtstart, from, to time.Time) []time.Time {
+	occurrences := []time.Time{}
+	add := func(occurrence time.Time) {
+		if occurrence.Before(from) || !occurrence.Before(to) || r.excluded(occurrence) {
+			return
+		}
+		for _, existing := range occurrences {
+			if existing.Equal(occurrence) {
+				return
+			}
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	if r.Rule != nil {
+		r.Rule.Iterate(dtstart, func(occurrence time.Time) bool {
+			if !occurrence.Before(to) {
+				return false
+			}
+			add(occurrence)
+			return len(occurrences) < MaxOccurrences
+		})
+	} else {
+		add(dtstart)
+	}
+	for _, rdate := range r.RDates {
+		add(rdate.In(dtstart.Location()))
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	if len(occurrences) > MaxOccurrences {
+		occurrences = occurrences[:MaxOccurrences]
+	}
+	return occurrences
+}
+
+//Includes reports whether the series starting at dtstart has an occurrence starting at t
+func (r *Recurrence) Includes(dtstart, t time.Time) bool {
+	occurrences := r.Between(dtstart, t, t.Add(time.Nanosecond))
+	return len(occurrences) == 1
+}
+
+//Last returns the start of the last occurrence, ok is false for series without an end
+func (r *Recurrence) Last(dtstart time.Time) (last time.Time, ok bool) {
+	last = dtstart
+	if r.Rule != nil {
+		if r.Rule.Count == 0 && r.Rule.Until.IsZero() {
+			return time.Time{}, false
+		}
+		r.Rule.Iterate(dtstart, func(occurrence time.Time) bool {
+			last = occurrence
+			return true
+		})
+	}
+	for _, rdate := range r.RDates {
+		if rdate.After(last) {
+			last = rdate
+		}
+	}
+	return last, true
+}
+
+//CountBefore returns how many occurrences generated by the rule start before t
+func (r *Recurrence) CountBefore(dtstart, t time.Time) int {
+	if r.Rule == nil {
+		if dtstart.Before(t) {
+			return 1
+		}
+		return 0
+	}
+
+	count := 0
+	r.Rule.Iterate(dtstart, func(occurrence time.Time) bool {
+		if !occurrence.Before(t) {
+			return false
+		}
+		count++
+		return true
+	})
+	return count
+}
+
+func (r *Recurrence) excluded(occurrence time.Time) bool {
+	for _, exdate := range r.ExDates {
+		if exdate.Equal(occurrence) {
+			return true
+		}
+	}
+	return false
+}
+
+//ParseDateTime parses RFC 5545 DATE-TIME ("20190402T180000Z", "20190402T180000") and DATE ("20190402") values.
+//Floating times and dates are interpreted in location, UTC if location is nil
+func ParseDateTime(value string, location *time.Location) (time.Time, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	switch len(value) {
+	case len(utcDateTimeLayout):
+		return time.Parse(utcDateTimeLayout, value)
+	case len(dateTimeLayout):
+		return time.ParseInLocation(dateTimeLayout, value, location)
+	case len(dateLayout):
+		return time.ParseInLocation(dateLayout, value, location)
+	}
+	return time.Time{}, fmt.Errorf("invalid date time %q", value)
+}
+
+//FormatUTC formats t as a RFC 5545 DATE-TIME in UTC
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format(utcDateTimeLayout)
+}
+
+//FormatLocal formats t as a RFC 5545 DATE-TIME without zone information, to be used together with a TZID
+func FormatLocal(t time.Time) string {
+	return t.Format(dateTimeLayout)
+}
+
+//FormatDate formats t as a RFC 5545 DATE
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
@@ -0,0 +1,416 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Frequency of a recurrence rule
+type Frequency string
+
+const (
+	//Daily recurrence
+	Daily Frequency = "DAILY"
+	//Weekly recurrence
+	Weekly Frequency = "WEEKLY"
+	//Monthly recurrence
+	Monthly Frequency = "MONTHLY"
+	//Yearly recurrence
+	Yearly Frequency = "YEARLY"
+)
+
+//maxPeriods bounds the expansion of rules that never produce an occurrence (e.g. every 30th of february)
+const maxPeriods = 100000
+
+//MaxOccurrences that are returned for a single expansion
+const MaxOccurrences = 1000
+
+//WeekdayNum is a BYDAY entry like MO, 2TU or -1FR
+type WeekdayNum struct {
+	Weekday time.Weekday
+	//N is the position of the weekday within the month (or year), 0 means every such weekday
+	N int
+}
+
+//RRule is the subset of RFC 5545 recurrence rules we support
+type RRule struct {
+	Frequency  Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+//ParseRRule parses a rule like "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10", the "RRULE:" prefix is optional
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return nil, errors.New("rrule can't be empty")
+	}
+
+	rule := &RRule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		key, value := strings.ToUpper(keyValue[0]), strings.ToUpper(keyValue[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Frequency = Frequency(value)
+			default:
+				return nil, fmt.Errorf("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positiveInt(value)
+		case "COUNT":
+			rule.Count, err = positiveInt(value)
+		case "UNTIL":
+			rule.Until, err = ParseDateTime(value, nil)
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekdayNum, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, weekdayNum)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(value, ",") {
+				m, err := strconv.Atoi(month)
+				if err != nil || m < 1 || m > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", month)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			weekday, ok := weekdayNames[value]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", value)
+			}
+			rule.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v: %v", key, err)
+		}
+	}
+
+	if rule.Frequency == "" {
+		return nil, errors.New("rrule needs a FREQ")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("rrule can't have both COUNT and UNTIL")
+	}
+	return rule, nil
+}
+
+//String returns the rule in its RFC 5545 representation without the "RRULE:" prefix
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+FormatUTC(r.Until))
+	}
+	if len(r.ByMonth) > 0 {
+		months := []string{}
+		for _, month := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(month)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := []string{}
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := []string{}
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayName(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+//String returns the RFC 5545 representation of the weekday
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayName(w.Weekday)
+	}
+	return strconv.Itoa(w.N) + weekdayName(w.Weekday)
+}
+
+//Iterate calls f with each occurrence of the rule starting at dtstart (which is always the first occurrence)
+//until f returns false or the rule ends.
+//Occurrences keep the wall clock time of dtstart in its location, also across daylight saving changes
+func (r *RRule) Iterate(dtstart time.Time, f func(occurrence time.Time) bool) {
+	emitted := 0
+	if !f(dtstart) {
+		return
+	}
+	emitted++
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, candidate := range r.candidates(dtstart, period) {
+			if !candidate.After(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+			if !f(candidate) {
+				return
+			}
+			emitted++
+		}
+	}
+}
+
+//candidates returns the sorted occurrences within the nth period after the one containing dtstart
+func (r *RRule) candidates(dtstart time.Time, n int) []time.Time {
+	hour, minute, second := dtstart.Clock()
+	location := dtstart.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, dtstart.Nanosecond(), location)
+	}
+
+	days := []time.Time{}
+	switch r.Frequency {
+	case Daily:
+		day := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+n*r.Interval)
+		if r.matchesMonth(day.Month()) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(dtstart.Year(), dtstart.Month(), dtstart.Day()-offset+n*r.Interval*7)
+		for i := 0; i < 7; i++ {
+			day := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			if !r.matchesMonth(day.Month()) {
+				continue
+			}
+			if len(r.ByDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if len(r.ByDay) > 0 && !r.matchesWeekday(day) {
+				continue
+			}
+			days = append(days, day)
+		}
+	case Monthly:
+		month := at(dtstart.Year(), dtstart.Month()+time.Month(n*r.Interval), 1)
+		if r.matchesMonth(month.Month()) {
+			days = r.daysInMonth(month, dtstart, at)
+		}
+	case Yearly:
+		year := dtstart.Year() + n*r.Interval
+		if len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 {
+			days = r.weekdaysInRange(at(year, time.January, 1), at(year+1, time.January, 1), at)
+			break
+		}
+		// BYMONTHDAY expands over every month like BYDAY does, only without any day rule the month of dtstart is used
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByMonthDay) > 0 {
+			months = allMonths
+		}
+		if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, month := range months {
+			days = append(days, r.daysInMonth(at(year, month, 1), dtstart, at)...)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+var allMonths = []time.Month{
+	time.January, time.February, time.March, time.April, time.May, time.June,
+	time.July, time.August, time.September, time.October, time.November, time.December,
+}
+
+//daysInMonth returns the candidates within the month starting at firstOfMonth
+func (r *RRule) daysInMonth(firstOfMonth, dtstart time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	year, month := firstOfMonth.Year(), firstOfMonth.Month()
+	length := daysIn(year, month)
+
+	if len(r.ByDay) > 0 {
+		days := r.weekdaysInRange(firstOfMonth, at(year, month+1, 1), at)
+		if len(r.ByMonthDay) == 0 {
+			return days
+		}
+		filtered := []time.Time{}
+		for _, day := range days {
+			if r.matchesMonthDay(day) {
+				filtered = append(filtered, day)
+			}
+		}
+		return filtered
+	}
+
+	monthDays := r.ByMonthDay
+	if len(monthDays) == 0 {
+		monthDays = []int{dtstart.Day()}
+	}
+	days := []time.Time{}
+	for _, monthDay := range monthDays {
+		if monthDay < 0 {
+			monthDay = length + monthDay + 1
+		}
+		if monthDay < 1 || monthDay > length {
+			continue
+		}
+		days = append(days, at(year, month, monthDay))
+	}
+	return days
+}
+
+//weekdaysInRange returns the days in [start, end) matching BYDAY, ordinals are relative to the range
+func (r *RRule) weekdaysInRange(start, end time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	byWeekday := map[time.Weekday][]time.Time{}
+	for day := start; day.Before(end); day = at(day.Year(), day.Month(), day.Day()+1) {
+		byWeekday[day.Weekday()] = append(byWeekday[day.Weekday()], day)
+	}
+
+	days := []time.Time{}
+	for _, weekdayNum := range r.ByDay {
+		matching := byWeekday[weekdayNum.Weekday]
+		switch {
+		case weekdayNum.N == 0:
+			days = append(days, matching...)
+		case weekdayNum.N > 0 && weekdayNum.N <= len(matching):
+			days = append(days, matching[weekdayNum.N-1])
+		case weekdayNum.N < 0 && -weekdayNum.N <= len(matching):
+			days = append(days, matching[len(matching)+weekdayNum.N])
+		}
+	}
+	return days
+}
+
+func (r *RRule) matchesMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := daysIn(day.Year(), day.Month())
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || (monthDay < 0 && length+monthDay+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, weekdayNum := range r.ByDay {
+		if weekdayNum.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func positiveInt(s string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if i < 1 {
+		return 0, errors.New("has to be positive")
+	}
+	return i, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	weekday, ok := weekdayNames[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+	weekdayNum := WeekdayNum{Weekday: weekday}
+	if len(s) > 2 {
+		n, err := strconv.Atoi(s[:len(s)-2])
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+		weekdayNum.N = n
+	}
+	return weekdayNum, nil
+}
+
+func weekdayName(weekday time.Weekday) string {
+	for name, w := range weekdayNames {
+		if w == weekday {
+			return name
+		}
+	}
+	return ""
+}
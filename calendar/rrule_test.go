@@ -0,0 +1,121 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRRule(t *testing.T) {
+	rule, err := calendar.ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,-1FR;UNTIL=20190430T000000Z")
+	require.NoError(t, err)
+	assert.Equal(t, calendar.Weekly, rule.Frequency)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, []calendar.WeekdayNum{{Weekday: time.Tuesday}, {Weekday: time.Friday, N: -1}}, rule.ByDay)
+	assert.Equal(t, time.Date(2019, 4, 30, 0, 0, 0, 0, time.UTC), rule.Until)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;UNTIL=20190430T000000Z;BYDAY=TU,-1FR", rule.String())
+
+	for _, invalid := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20190430T000000Z",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYSETPOS=1",
+	} {
+		_, err := calendar.ParseRRule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func occurrences(t *testing.T, rule string, dtstart time.Time, n int) []time.Time {
+	r, err := calendar.ParseRRule(rule)
+	require.NoError(t, err)
+	result := []time.Time{}
+	r.Iterate(dtstart, func(occurrence time.Time) bool {
+		result = append(result, occurrence)
+		return len(result) < n
+	})
+	return result
+}
+
+func Test_RRuleIterate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	dtstart := time.Date(2019, 3, 26, 18, 0, 0, 0, berlin) // a tuesday
+
+	weekly := occurrences(t, "FREQ=WEEKLY;BYDAY=TU,TH", dtstart, 4)
+	assert.Equal(t, []time.Time{
+		dtstart,
+		time.Date(2019, 3, 28, 18, 0, 0, 0, berlin),
+		time.Date(2019, 4, 2, 18, 0, 0, 0, berlin),
+		time.Date(2019, 4, 4, 18, 0, 0, 0, berlin),
+	}, weekly)
+	// wall clock time is kept across the daylight saving change on march 31st
+	assert.Equal(t, 18, weekly[2].Hour())
+
+	assert.Equal(t, []time.Time{
+		dtstart,
+		time.Date(2019, 3, 29, 18, 0, 0, 0, berlin),
+		time.Date(2019, 4, 1, 18, 0, 0, 0, berlin),
+	}, occurrences(t, "FREQ=DAILY;INTERVAL=3", dtstart, 3))
+
+	assert.Equal(t, []time.Time{
+		dtstart,
+		time.Date(2019, 3, 29, 18, 0, 0, 0, berlin),
+		time.Date(2019, 4, 26, 18, 0, 0, 0, berlin),
+	}, occurrences(t, "FREQ=MONTHLY;BYDAY=-1FR", dtstart, 3))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 1, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 3, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 5, 31, 10, 0, 0, 0, time.UTC),
+	}, occurrences(t, "FREQ=MONTHLY", time.Date(2019, 1, 31, 10, 0, 0, 0, time.UTC), 3))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 3, 26, 10, 0, 0, 0, time.UTC),
+		time.Date(2020, 3, 26, 10, 0, 0, 0, time.UTC),
+	}, occurrences(t, "FREQ=YEARLY;COUNT=2", time.Date(2019, 3, 26, 10, 0, 0, 0, time.UTC), 10))
+
+	// without BYMONTH the month days are expanded over every month of the year
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 3, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 3, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 4, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2019, 5, 15, 10, 0, 0, 0, time.UTC),
+	}, occurrences(t, "FREQ=YEARLY;BYMONTHDAY=15", time.Date(2019, 3, 10, 10, 0, 0, 0, time.UTC), 4))
+	assert.Len(t, occurrences(t, "FREQ=YEARLY;BYMONTHDAY=15;UNTIL=20200310T000000Z", time.Date(2019, 3, 10, 10, 0, 0, 0, time.UTC), 20), 13)
+
+	assert.Len(t, occurrences(t, "FREQ=DAILY;UNTIL=20190330T170000Z", dtstart, 10), 5)
+}
+
+func Test_RecurrenceBetween(t *testing.T) {
+	dtstart := time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+	rule, err := calendar.ParseRRule("FREQ=WEEKLY")
+	require.NoError(t, err)
+	recurrence := &calendar.Recurrence{
+		Rule:    rule,
+		ExDates: []time.Time{time.Date(2019, 4, 9, 18, 0, 0, 0, time.UTC)},
+		RDates:  []time.Time{time.Date(2019, 4, 10, 18, 0, 0, 0, time.UTC)},
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Date(2019, 4, 10, 18, 0, 0, 0, time.UTC),
+		time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC),
+	}, recurrence.Between(dtstart, time.Date(2019, 4, 3, 0, 0, 0, 0, time.UTC), time.Date(2019, 4, 17, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, recurrence.Includes(dtstart, time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC)))
+	assert.False(t, recurrence.Includes(dtstart, time.Date(2019, 4, 9, 18, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2, recurrence.CountBefore(dtstart, time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC)))
+
+	_, ok := recurrence.Last(dtstart)
+	assert.False(t, ok)
+	rule.Count = 3
+	last, ok := recurrence.Last(dtstart)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC), last)
+}
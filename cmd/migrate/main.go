@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/migrations"
+
+	//import .env file if present
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const usage = `usage: migrate [flags] status|up|down
+
+status  lists the migrations and when they were applied
+up      applies the pending migrations
+down    rolls back the last applied migrations, -steps of them
+
+flags:
+`
+
+func main() {
+	var neo4jAddress string
+	var steps int
+	var lockWait time.Duration
+
+	flag.StringVar(&neo4jAddress, "neo4j_address", "bolt://0.0.0.0:7687", "address to neo4j")
+	flag.IntVar(&steps, "steps", 1, "how many migrations down rolls back")
+	flag.DurationVar(&lockWait, "lock_wait", 2*time.Minute, "how long to wait for migrations another process is applying")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	migrator, err := db.NewMigrator(db.Driver(neo4jAddress), migrations.All)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator.LockWait = lockWait
+
+	switch flag.Arg(0) {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-25v  %v\n", status.Version, appliedAt, status.Description)
+		}
+	case "up":
+		applied, err := migrator.Up()
+		printMigrations("applied", applied)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		rolledBack, err := migrator.Down(steps)
+		printMigrations("rolled back", rolledBack)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printMigrations(description string, migrations []db.Migration) {
+	fmt.Printf("%v %d migrations\n", description, len(migrations))
+	for _, migration := range migrations {
+		fmt.Printf("  %d %v\n", migration.Version, migration.Description)
+	}
+}
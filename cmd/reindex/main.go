@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/search"
+
+	//import .env file if present
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	var neo4jAddress, elasticAddress string
+	var verify, keepOld bool
+	var batchSize int
+
+	flag.StringVar(&neo4jAddress, "neo4j_address", "bolt://0.0.0.0:7687", "address to neo4j")
+	flag.StringVar(&elasticAddress, "elastic_address", "http://0.0.0.0:9200", "address to elasticsearch")
+	flag.BoolVar(&verify, "verify", false, "only compare the index with neo4j and report the differences instead of reindexing")
+	flag.BoolVar(&keepOld, "keep_old", false, "keep the previous versions of the index instead of deleting them")
+	flag.IntVar(&batchSize, "batch_size", 1000, "how many nodes are read and written at once")
+	flag.Parse()
+
+	client, err := search.NewClient(elasticAddress, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reindexer := search.NewReindexer(client, db.Driver(neo4jAddress))
+	reindexer.BatchSize = batchSize
+
+	if verify {
+		report, err := reindexer.Verify(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("checked %d nodes\n", report.Checked)
+		printUIDs("missing in the index", report.MissingInIndex)
+		printUIDs("missing in neo4j", report.MissingInDatabase)
+		printUIDs("stale in the index", report.Stale)
+		if !report.Consistent() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := reindexer.Reindex(context.Background(), keepOld)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("indexed %d documents into %v\n", result.Documents, result.IndexName)
+	if len(result.RemovedIndices) > 0 {
+		fmt.Printf("removed %v\n", strings.Join(result.RemovedIndices, ", "))
+	}
+}
+
+func printUIDs(description string, uids []string) {
+	fmt.Printf("%d %v\n", len(uids), description)
+	for _, uid := range uids {
+		fmt.Printf("  %v\n", uid)
+	}
+}
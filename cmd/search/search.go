@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/alexmorten/events-api/models"
 	"github.com/alexmorten/events-api/search"
 )
 
@@ -13,8 +13,11 @@ func main() {
 		panic(err)
 	}
 
-	client.FuzzyNameSearch("Club", "something", func(props map[string]interface{}) {
-		club := models.ClubFromProps(props)
-		fmt.Println(club)
-	})
+	result, err := client.SearchNodes(context.Background(), search.Query{Term: "something", Labels: []string{"Club"}, Size: 10})
+	if err != nil {
+		panic(err)
+	}
+	for _, hit := range result.Hits {
+		fmt.Println(hit.UID, hit.Score, hit.Highlights)
+	}
 }
@@ -2,8 +2,10 @@ package main
 
 import (
 	"flag"
+	"log"
 
 	"github.com/alexmorten/events-api"
+	"github.com/alexmorten/events-api/search"
 
 	//import .env file if present
 	_ "github.com/joho/godotenv/autoload"
@@ -16,9 +18,13 @@ func main() {
 	flag.StringVar(&config.ElasticsearchAddress, "elastic_address", "http://0.0.0.0:9200", "address to elasticsearch")
 	flag.IntVar(&config.Port, "port", 3000, "port the server should listen on for http requests")
 	flag.BoolVar(&config.LazyInitializeElastic, "lazily_initialize_elastic", false, "if set to true, creating the connection to elastic_search will be defered until we make a call to it")
+	flag.StringVar(&config.SearchBackend, "search_backend", search.BackendElasticsearch, "where documents are searched, elasticsearch or memory (which needs no elasticsearch but is lost on restart)")
+	flag.BoolVar(&config.SkipMigrations, "skip_migrations", false, "don't apply pending migrations on start, e.g. because they are applied with cmd/migrate")
 	flag.Parse()
 
 	s := api.NewServer(config)
-	s.Init()
+	if err := s.Init(); err != nil {
+		log.Fatal(err)
+	}
 	s.Run()
 }
@@ -0,0 +1,43 @@
+package db
+
+import "sync"
+
+//Change of a node, Label is empty if the label isn't known (e.g. when a node is deleted by uid)
+type Change struct {
+	UID     string
+	Label   string
+	Deleted bool
+}
+
+//ChangeListener gets called after a node was written, it shouldn't block
+type ChangeListener func(change Change)
+
+var changeListeners = struct {
+	sync.RWMutex
+	nextID    int
+	listeners map[int]ChangeListener
+}{listeners: map[int]ChangeListener{}}
+
+//OnChange registers a listener that is called whenever Save, CreateBy, DeleteNode or CreateRelation changed a node.
+//Calling the returned func unregisters it again
+func OnChange(listener ChangeListener) (unregister func()) {
+	changeListeners.Lock()
+	defer changeListeners.Unlock()
+	id := changeListeners.nextID
+	changeListeners.nextID++
+	changeListeners.listeners[id] = listener
+	return func() {
+		changeListeners.Lock()
+		defer changeListeners.Unlock()
+		delete(changeListeners.listeners, id)
+	}
+}
+
+//NotifyChange tells all listeners about the change, queries that write nodes without the functions of this package should call it themselves
+func NotifyChange(change Change) {
+	changeListeners.RLock()
+	defer changeListeners.RUnlock()
+	for _, listener := range changeListeners.listeners {
+		listener(change)
+	}
+}
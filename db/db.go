@@ -24,18 +24,31 @@ type Model interface {
 	NodeName() string
 }
 
-//Save the model to the database
+//touchable models keep track of when they were last updated
+type touchable interface {
+	Touch()
+}
+
+//Save the model to the database, models that can be touched get their update time refreshed when they are updated
 func Save(dbDriver neo4j.Driver, model Model) (props map[string]interface{}, err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
-	if err != nil {
-		return nil, err
+	err = Transact(dbDriver, func(tx *Tx) error {
+		props, err = tx.Save(model)
+		return err
+	})
+	return props, err
+}
+
+//Save the model in the transaction, see Save
+func (tx *Tx) Save(model Model) (props map[string]interface{}, err error) {
+	if t, ok := model.(touchable); ok && !model.Created() {
+		t.Touch()
 	}
 
 	var record neo4j.Record
 	if model.Created() {
-		record, err = neo4j.Single(dbSession.Run(fmt.Sprintf("create (n:%v {%v}) return properties(n)", model.NodeName(), NeoPropString(model)), MarshalNeoFields(model)))
+		record, err = neo4j.Single(tx.Run(fmt.Sprintf("create (n:%v {%v}) return properties(n)", model.NodeName(), NeoPropString(model)), MarshalNeoFields(model)))
 	} else {
-		record, err = neo4j.Single(dbSession.Run(fmt.Sprintf("match (n:%v {uid: $uid}) set n += {%v} return properties(n)", model.NodeName(), NeoPropString(model)), MarshalNeoFields(model)))
+		record, err = neo4j.Single(tx.Run(fmt.Sprintf("match (n:%v {uid: $uid}) set n += {%v} return properties(n)", model.NodeName(), NeoPropString(model)), MarshalNeoFields(model)))
 	}
 	if err != nil {
 		return nil, err
@@ -45,6 +58,7 @@ func Save(dbDriver neo4j.Driver, model Model) (props map[string]interface{}, err
 	if ok {
 		props, ok := propInterface.(map[string]interface{})
 		if ok {
+			tx.NotifyChange(Change{UID: fmt.Sprint(props["uid"]), Label: model.NodeName()})
 			return props, nil
 		}
 	}
@@ -53,13 +67,18 @@ func Save(dbDriver neo4j.Driver, model Model) (props map[string]interface{}, err
 
 //CreateBy creates the model node together with a relationship to a user with the given id
 func CreateBy(dbDriver neo4j.Driver, model Model, userUID uuid.UUID) (props map[string]interface{}, err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
-	if err != nil {
-		return nil, err
-	}
+	err = Transact(dbDriver, func(tx *Tx) error {
+		props, err = tx.CreateBy(model, userUID)
+		return err
+	})
+	return props, err
+}
+
+//CreateBy creates the model node in the transaction, see CreateBy
+func (tx *Tx) CreateBy(model Model, userUID uuid.UUID) (props map[string]interface{}, err error) {
 	neoFields := MarshalNeoFields(model)
 	neoFields["user_uid"] = userUID.String()
-	record, err := neo4j.Single(dbSession.Run(fmt.Sprintf("match (u:User {uid: $user_uid}) create (n:%v {%v})-[r:CREATED_BY]->(u) return properties(n)", model.NodeName(), NeoPropString(model)), neoFields))
+	record, err := neo4j.Single(tx.Run(fmt.Sprintf("match (u:User {uid: $user_uid}) create (n:%v {%v})-[r:CREATED_BY]->(u) return properties(n)", model.NodeName(), NeoPropString(model)), neoFields))
 	if err != nil {
 		return nil, err
 	}
@@ -68,40 +87,25 @@ func CreateBy(dbDriver neo4j.Driver, model Model, userUID uuid.UUID) (props map[
 	if ok {
 		props, ok := propInterface.(map[string]interface{})
 		if ok {
+			tx.NotifyChange(Change{UID: fmt.Sprint(props["uid"]), Label: model.NodeName()})
 			return props, nil
 		}
 	}
 	return nil, errors.New("creating node went wrong")
 }
 
-//FindNode props for uid
-func FindNode(dbDriver neo4j.Driver, uid string) (props map[string]interface{}, err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
-	if err != nil {
-		return nil, err
-	}
-	record, err := neo4j.Single(dbSession.Run("match (n {uid: $uid}) return properties(n)", map[string]interface{}{"uid": uid}))
-	if err != nil {
-		return nil, err
-	}
-	propInterface, ok := record.Get("properties(n)")
-	if ok {
-		props, ok := propInterface.(map[string]interface{})
-		if ok {
-			return props, nil
-		}
-	}
-	return nil, nil
-}
-
 //FindRelation between two nodes
 func FindRelation(dbDriver neo4j.Driver, fromNodeUID, toNodeUID, relationName string) (props map[string]interface{}, err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
-	if err != nil {
-		return nil, err
-	}
+	err = ReadTransact(dbDriver, func(tx *Tx) error {
+		props, err = tx.FindRelation(fromNodeUID, toNodeUID, relationName)
+		return err
+	})
+	return props, err
+}
 
-	record, err := neo4j.Single(dbSession.Run(
+//FindRelation between two nodes in the transaction
+func (tx *Tx) FindRelation(fromNodeUID, toNodeUID, relationName string) (props map[string]interface{}, err error) {
+	record, err := neo4j.Single(tx.Run(
 		fmt.Sprintf("match (fromNode {uid: $from_uid})-[r:%v]->(toNode {uid: $to_uid}) return properties(r)", relationName),
 		map[string]interface{}{"from_uid": fromNodeUID, "to_uid": toNodeUID},
 	))
@@ -119,25 +123,35 @@ func FindRelation(dbDriver neo4j.Driver, fromNodeUID, toNodeUID, relationName st
 	return nil, nil
 }
 
-//DeleteNode with given uid, detaching all relationships attached to it
-func DeleteNode(dbDriver neo4j.Driver, uid string) (err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
-	if err != nil {
-		return err
-	}
+//DeleteNode with the label of the model and the uid, detaching all relationships attached to it.
+//The model is only used for its label, e.g. DeleteNode(dbDriver, &Club{}, uid)
+func DeleteNode(dbDriver neo4j.Driver, model Model, uid string) (err error) {
+	return Transact(dbDriver, func(tx *Tx) error {
+		return tx.DeleteNode(model, uid)
+	})
+}
 
-	_, err = dbSession.Run("match (n {uid: $uid}) detach delete n", map[string]interface{}{"uid": uid})
+//DeleteNode in the transaction, see DeleteNode
+func (tx *Tx) DeleteNode(model Model, uid string) (err error) {
+	_, err = neo4j.Collect(tx.Run(fmt.Sprintf("match (n:%v {uid: $uid}) detach delete n", model.NodeName()), map[string]interface{}{"uid": uid}))
+	if err == nil {
+		tx.NotifyChange(Change{UID: uid, Label: model.NodeName(), Deleted: true})
+	}
 	return err
 }
 
 //CreateRelation creates the model node together with a relationship to a user with the given id
 func CreateRelation(dbDriver neo4j.Driver, fromUID, toUID uuid.UUID, relationName string) (props map[string]interface{}, err error) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
-	if err != nil {
-		return nil, err
-	}
+	err = Transact(dbDriver, func(tx *Tx) error {
+		props, err = tx.CreateRelation(fromUID, toUID, relationName)
+		return err
+	})
+	return props, err
+}
 
-	record, err := neo4j.Single(dbSession.Run(
+//CreateRelation in the transaction, see CreateRelation
+func (tx *Tx) CreateRelation(fromUID, toUID uuid.UUID, relationName string) (props map[string]interface{}, err error) {
+	record, err := neo4j.Single(tx.Run(
 		fmt.Sprintf(
 			`
 			match (from_n {uid: $from_uid}), (to_n {uid: $to_uid})
@@ -159,6 +173,7 @@ func CreateRelation(dbDriver neo4j.Driver, fromUID, toUID uuid.UUID, relationNam
 	if ok {
 		props, ok := propInterface.(map[string]interface{})
 		if ok {
+			tx.NotifyRelationChange(Change{UID: fromUID.String()}, Change{UID: toUID.String()})
 			return props, nil
 		}
 	}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//NotFoundError is returned when there is no node with the label and uid
+type NotFoundError struct {
+	Label string
+	UID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v %v not found", strings.ToLower(e.Label), e.UID)
+}
+
+//RelationNotFoundError is returned when two nodes don't have the relation
+type RelationNotFoundError struct {
+	Relation string
+	FromUID  string
+	ToUID    string
+}
+
+func (e *RelationNotFoundError) Error() string {
+	return fmt.Sprintf("%v relation from %v to %v not found", e.Relation, e.FromUID, e.ToUID)
+}
+
+//IsNotFound checks if the error is a NotFoundError or RelationNotFoundError
+func IsNotFound(err error) bool {
+	switch err.(type) {
+	case *NotFoundError, *RelationNotFoundError:
+		return true
+	}
+	return false
+}
+
+//IsConstraintViolation checks if the error is the error neo4j returns when a write breaks a uniqueness constraint,
+//e.g. because another node with the label already has the unique property
+func IsConstraintViolation(err error) bool {
+	databaseError, ok := err.(interface{ Code() string })
+	return ok && databaseError.Code() == "Neo.ClientError.Schema.ConstraintValidationFailed"
+}
+
+//FindByUID returns the props of the node with the label of the model and the uid,
+//or a NotFoundError if there is none. The model is only used for its label, e.g. FindByUID(dbDriver, &Club{}, uid).
+//Matching by label lets neo4j use the uniqueness constraint of the uid as index
+func FindByUID(dbDriver neo4j.Driver, model Model, uid string) (props map[string]interface{}, err error) {
+	err = ReadTransact(dbDriver, func(tx *Tx) error {
+		props, err = tx.FindByUID(model, uid)
+		return err
+	})
+	return props, err
+}
+
+//FindByUID in the transaction, see FindByUID
+func (tx *Tx) FindByUID(model Model, uid string) (map[string]interface{}, error) {
+	records, err := neo4j.Collect(tx.Run(
+		fmt.Sprintf("match (n:%v {uid: $uid}) return properties(n)", model.NodeName()),
+		map[string]interface{}{"uid": uid},
+	))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, &NotFoundError{Label: model.NodeName(), UID: uid}
+	}
+	props, ok := records[0].GetByIndex(0).(map[string]interface{})
+	if !ok {
+		return nil, &NotFoundError{Label: model.NodeName(), UID: uid}
+	}
+	return props, nil
+}
+
+//Exists checks if there is a node with the label of the model and the uid
+func Exists(dbDriver neo4j.Driver, model Model, uid string) (exists bool, err error) {
+	err = ReadTransact(dbDriver, func(tx *Tx) error {
+		exists, err = tx.Exists(model, uid)
+		return err
+	})
+	return exists, err
+}
+
+//Exists in the transaction, see Exists
+func (tx *Tx) Exists(model Model, uid string) (bool, error) {
+	record, err := neo4j.Single(tx.Run(
+		fmt.Sprintf("optional match (n:%v {uid: $uid}) return n is not null", model.NodeName()),
+		map[string]interface{}{"uid": uid},
+	))
+	if err != nil {
+		return false, err
+	}
+	exists, _ := record.GetByIndex(0).(bool)
+	return exists, nil
+}
+
+//FindManyByUID returns the props of the nodes with the label of the model and one of the uids in the order of the uids,
+//uids without a node are left out
+func FindManyByUID(dbDriver neo4j.Driver, model Model, uids []string) ([]map[string]interface{}, error) {
+	found := []map[string]interface{}{}
+	err := ReadTransact(dbDriver, func(tx *Tx) error {
+		records, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf("unwind range(0, size($uids) - 1) as i match (n:%v {uid: $uids[i]}) return properties(n) order by i", model.NodeName()),
+			map[string]interface{}{"uids": uids},
+		))
+		if err != nil {
+			return err
+		}
+		found = found[:0]
+		for _, record := range records {
+			if props, ok := record.GetByIndex(0).(map[string]interface{}); ok {
+				found = append(found, props)
+			}
+		}
+		return nil
+	})
+	return found, err
+}
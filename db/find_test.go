@@ -0,0 +1,21 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsNotFound(t *testing.T) {
+	notFound := &db.NotFoundError{Label: "Club", UID: "some-uid"}
+	assert.Equal(t, "club some-uid not found", notFound.Error())
+	assert.True(t, db.IsNotFound(notFound))
+	assert.False(t, db.IsNotFound(errors.New("club some-uid not found")))
+	assert.False(t, db.IsNotFound(nil))
+
+	relationNotFound := &db.RelationNotFoundError{Relation: "OFFERS", FromUID: "club-uid", ToUID: "sport-uid"}
+	assert.Equal(t, "OFFERS relation from club-uid to sport-uid not found", relationNotFound.Error())
+	assert.True(t, db.IsNotFound(relationNotFound))
+}
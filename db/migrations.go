@@ -1,29 +1,351 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
 )
 
-//MustCreateConstraints on neo4j database or panic
-func MustCreateConstraints(dbDriver neo4j.Driver) {
-	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+const (
+	//defaultMigrationLabel is the label migrations are recorded with
+	defaultMigrationLabel = "Migration"
+	//defaultMigrationLockName is the name of the (:MigrationLock) node migrators lock
+	defaultMigrationLockName = "migrations"
+)
+
+//lockPollInterval is how often a migrator checks if a lock held by another process was released
+const lockPollInterval = 500 * time.Millisecond
+
+//ErrMigrationLocked is returned when another process holds the migration lock for longer than a migrator waits for it
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
+//Migration changes the schema or the data of the database. Migrations are applied once each in the order of their versions
+//and recorded as (:Migration {version}) nodes (see Migrator.Label), a migration is applied together with its record unless it is a schema migration
+type Migration struct {
+	Version     int
+	Description string
+	//Schema migrations create or drop constraints and indexes, which neo4j can't do in the transaction that records them.
+	//They are recorded right after they are applied and have to be safe to apply again in case that fails
+	Schema bool
+	Up     func(tx *Tx) error
+	//Down undoes Up, migrations without it can't be rolled back
+	Down func(tx *Tx) error
+}
+
+//Statements runs the cypher statements one after another, e.g. as Up or Down of a schema migration
+func Statements(statements ...string) func(tx *Tx) error {
+	return func(tx *Tx) error {
+		for _, statement := range statements {
+			result, err := tx.Run(statement, nil)
+			if err != nil {
+				return err
+			}
+			if _, err = result.Summary(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+//MigrationStatus of a migration, AppliedAt is zero for pending migrations.
+//Migrations that are applied but unknown to the migrator only have a version and description
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+//Applied is true if the migration was applied to the database
+func (s *MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+//Migrator applies and rolls back migrations. It holds a lock in the database while it does,
+//so that processes starting at the same time don't apply the same migrations twice
+type Migrator struct {
+	//LockTimeout after which a lock can be taken over, e.g. because its process crashed. It is renewed after every migration
+	LockTimeout time.Duration
+	//LockWait is how long Up and Down wait for a lock held by another process
+	LockWait time.Duration
+	//Label migrations are recorded with and LockName of the lock the migrator holds.
+	//Migrators with other ones keep their migrations apart from the ones of the application, e.g. in tests
+	Label    string
+	LockName string
+
+	dbDriver   neo4j.Driver
+	migrations []Migration
+	owner      string
+}
+
+//NewMigrator for the migrations, their versions have to be greater than 0 and unique
+func NewMigrator(dbDriver neo4j.Driver, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version < 1 {
+			return nil, fmt.Errorf("migration %q: version has to be greater than 0", migration.Description)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("there are several migrations with version %v", migration.Version)
+		}
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %v has no Up", migration.Version)
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		LockTimeout: 10 * time.Minute,
+		LockWait:    2 * time.Minute,
+		Label:       defaultMigrationLabel,
+		LockName:    defaultMigrationLockName,
+		dbDriver:    dbDriver,
+		migrations:  sorted,
+		owner:       fmt.Sprintf("%v-%v-%v", hostname, os.Getpid(), uuid.New()),
+	}, nil
+}
+
+//Migrate applies the pending migrations, the error is ErrMigrationLocked if another process applies them for longer than LockWait
+func Migrate(dbDriver neo4j.Driver, migrations []Migration) error {
+	migrator, err := NewMigrator(dbDriver, migrations)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+//Status of all migrations ordered by version, including the ones that are applied but unknown to the migrator
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	applied, err := m.applied()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer dbSession.Close()
 
-	panicOnErrSummary(dbSession.Run("CREATE CONSTRAINT ON (u:User) ASSERT u.uid IS UNIQUE", nil))
-	panicOnErrSummary(dbSession.Run("CREATE CONSTRAINT ON (u:User) ASSERT u.email IS UNIQUE", nil))
-	panicOnErrSummary(dbSession.Run("CREATE CONSTRAINT ON (e:Event) ASSERT e.uid IS UNIQUE", nil))
-	panicOnErrSummary(dbSession.Run("CREATE CONSTRAINT ON (c:Club) ASSERT c.uid IS UNIQUE", nil))
-	panicOnErrSummary(dbSession.Run("CREATE CONSTRAINT ON (s:Sport) ASSERT s.uid IS UNIQUE", nil))
+	statuses := []*MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, record)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+//Up applies the pending migrations in order and returns them, it stops at the first migration that fails
+func (m *Migrator) Up() (applied []Migration, err error) {
+	err = m.whileLocked(func() error {
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied() {
+				continue
+			}
+			if err := m.apply(status.Migration); err != nil {
+				return fmt.Errorf("migration %v (%v): %v", status.Version, status.Description, err)
+			}
+			applied = append(applied, status.Migration)
+			if err := m.renewLock(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return applied, err
 }
 
-func panicOnErrSummary(result neo4j.Result, err error) {
+//Down rolls back the last steps applied migrations, the last one first, and returns them
+func (m *Migrator) Down(steps int) (rolledBack []Migration, err error) {
+	err = m.whileLocked(func() error {
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			status := statuses[i]
+			if !status.Applied() {
+				continue
+			}
+			if status.Up == nil {
+				return fmt.Errorf("migration %v (%v) is unknown and can't be rolled back", status.Version, status.Description)
+			}
+			if status.Down == nil {
+				return fmt.Errorf("migration %v (%v) can't be rolled back", status.Version, status.Description)
+			}
+			if err := m.rollBack(status.Migration); err != nil {
+				return fmt.Errorf("rolling back migration %v (%v): %v", status.Version, status.Description, err)
+			}
+			rolledBack = append(rolledBack, status.Migration)
+			if err := m.renewLock(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	record := func(tx *Tx) error {
+		_, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf("create (:%v {version: $version, description: $description, applied_at: $applied_at})", m.Label),
+			map[string]interface{}{"version": migration.Version, "description": migration.Description, "applied_at": NeoDateTime(time.Now())},
+		))
+		return err
+	}
+	if migration.Schema {
+		if err := Transact(m.dbDriver, migration.Up); err != nil {
+			return err
+		}
+		return Transact(m.dbDriver, record)
+	}
+	return Transact(m.dbDriver, func(tx *Tx) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+func (m *Migrator) rollBack(migration Migration) error {
+	unrecord := func(tx *Tx) error {
+		_, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf("match (n:%v {version: $version}) delete n", m.Label),
+			map[string]interface{}{"version": migration.Version},
+		))
+		return err
+	}
+	if migration.Schema {
+		if err := Transact(m.dbDriver, migration.Down); err != nil {
+			return err
+		}
+		return Transact(m.dbDriver, unrecord)
+	}
+	return Transact(m.dbDriver, func(tx *Tx) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return unrecord(tx)
+	})
+}
+
+//applied migrations keyed by version
+func (m *Migrator) applied() (map[int]*MigrationStatus, error) {
+	applied := map[int]*MigrationStatus{}
+	err := ReadTransact(m.dbDriver, func(tx *Tx) error {
+		records, err := neo4j.Collect(tx.Run(fmt.Sprintf("match (n:%v) return n.version, n.description, n.applied_at", m.Label), nil))
+		if err != nil {
+			return err
+		}
+		applied = map[int]*MigrationStatus{}
+		for _, record := range records {
+			version, _ := record.GetByIndex(0).(int64)
+			description, _ := record.GetByIndex(1).(string)
+			appliedAt, _ := record.GetByIndex(2).(time.Time)
+			applied[int(version)] = &MigrationStatus{Migration: Migration{Version: int(version), Description: description}, AppliedAt: appliedAt}
+		}
+		return nil
+	})
+	return applied, err
+}
+
+//whileLocked runs work while the migrator holds the migration lock, waiting up to LockWait for it.
+//The error of releasing the lock is returned if work succeeded, the lock expires after LockTimeout anyway
+func (m *Migrator) whileLocked(work func() error) (err error) {
+	// the constraints make sure there is only one lock node per name and migrations are recorded once
+	err = Transact(m.dbDriver, Statements(
+		"CREATE CONSTRAINT ON (l:MigrationLock) ASSERT l.name IS UNIQUE",
+		fmt.Sprintf("CREATE CONSTRAINT ON (m:%v) ASSERT m.version IS UNIQUE", m.Label),
+	))
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if _, err = result.Summary(); err != nil {
-		panic(err)
+
+	deadline := time.Now().Add(m.LockWait)
+	for {
+		locked, err := m.tryLock()
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationLocked
+		}
+		time.Sleep(lockPollInterval)
+	}
+	defer func() {
+		unlockErr := m.unlock()
+		if unlockErr == nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("releasing the migration lock: %v", unlockErr)
+			return
+		}
+		log.Printf("releasing the migration lock: %v", unlockErr)
+	}()
+	return work()
+}
+
+//tryLock takes the lock if it is free, expired or already held by the migrator, which renews it
+func (m *Migrator) tryLock() (locked bool, err error) {
+	params := map[string]interface{}{"name": m.LockName, "owner": m.owner}
+	err = Transact(m.dbDriver, func(tx *Tx) error {
+		// writing a property takes the write lock of the node, so its owner can't change until this transaction ends
+		record, err := neo4j.Single(tx.Run(
+			"merge (l:MigrationLock {name: $name}) set l.checked_at = $now return l.owner, l.expires_at",
+			withParam(params, "now", NeoDateTime(time.Now())),
+		))
+		if err != nil {
+			return err
+		}
+		owner, _ := record.GetByIndex(0).(string)
+		expiresAt, _ := record.GetByIndex(1).(time.Time)
+		if owner != "" && owner != m.owner && time.Now().Before(expiresAt) {
+			locked = false
+			return nil
+		}
+
+		_, err = neo4j.Collect(tx.Run(
+			"match (l:MigrationLock {name: $name}) set l.owner = $owner, l.expires_at = $expires_at",
+			withParam(params, "expires_at", NeoDateTime(time.Now().Add(m.LockTimeout))),
+		))
+		locked = err == nil
+		return err
+	})
+	return locked, err
+}
+
+func (m *Migrator) renewLock() error {
+	locked, err := m.tryLock()
+	if err == nil && !locked {
+		return errors.New("the migration lock was taken over by another process")
 	}
+	return err
+}
+
+func (m *Migrator) unlock() error {
+	return Transact(m.dbDriver, func(tx *Tx) error {
+		_, err := neo4j.Collect(tx.Run(
+			"match (l:MigrationLock {name: $name, owner: $owner}) remove l.owner, l.expires_at",
+			map[string]interface{}{"name": m.LockName, "owner": m.owner},
+		))
+		return err
+	})
 }
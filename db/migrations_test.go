@@ -0,0 +1,211 @@
+package db_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	//testNeo4jAddress is the address of the test database, see make neo4j-test
+	testNeo4jAddress      = "bolt://localhost:7687"
+	testMigrationLockName = "test-migrations"
+)
+
+func Test_NewMigrator(t *testing.T) {
+	up := db.Statements("match (n) return n")
+
+	_, err := db.NewMigrator(nil, []db.Migration{{Version: 2, Up: up}, {Version: 1, Up: up}})
+	assert.NoError(t, err)
+
+	invalid := map[string][]db.Migration{
+		"version 0":          {{Version: 0, Up: up}},
+		"duplicate versions": {{Version: 1, Up: up}, {Version: 2, Up: up}, {Version: 1, Up: up}},
+		"without up":         {{Version: 1}},
+	}
+	for name, migrations := range invalid {
+		migrator, err := db.NewMigrator(nil, migrations)
+		assert.Error(t, err, name)
+		assert.Nil(t, migrator, name)
+	}
+}
+
+//Test_Migrator needs neo4j like the tests of the actions. Its migrators record their migrations with their own label and lock,
+//so that the migrations of the application in the same database aren't mixed up with them
+func Test_Migrator(t *testing.T) {
+	dbDriver := db.Driver(testNeo4jAddress)
+	clear := func() {
+		err := db.Transact(dbDriver, func(tx *db.Tx) error {
+			_, err := neo4j.Collect(tx.Run(
+				"match (n) where n:TestMigration or n:TestCounter or (n:MigrationLock and n.name = $lock_name) detach delete n",
+				map[string]interface{}{"lock_name": testMigrationLockName},
+			))
+			return err
+		})
+		require.NoError(t, err)
+	}
+	defer clear()
+	newMigrator := func(migrations []db.Migration) *db.Migrator {
+		migrator, err := db.NewMigrator(dbDriver, migrations)
+		require.NoError(t, err)
+		migrator.Label = "TestMigration"
+		migrator.LockName = testMigrationLockName
+		return migrator
+	}
+
+	// each migration counts how often it was applied on a (:TestCounter) node
+	count := func(name string) func(tx *db.Tx) error {
+		return func(tx *db.Tx) error {
+			_, err := neo4j.Collect(tx.Run("merge (c:TestCounter {name: $name}) set c.count = coalesce(c.count, 0) + 1", map[string]interface{}{"name": name}))
+			return err
+		}
+	}
+	counted := func(name string) int64 {
+		var counted int64
+		err := db.ReadTransact(dbDriver, func(tx *db.Tx) error {
+			records, err := neo4j.Collect(tx.Run("match (c:TestCounter {name: $name}) return c.count", map[string]interface{}{"name": name}))
+			if err != nil || len(records) == 0 {
+				return err
+			}
+			counted, _ = records[0].GetByIndex(0).(int64)
+			return nil
+		})
+		require.NoError(t, err)
+		return counted
+	}
+	testMigrations := []db.Migration{
+		{Version: 1, Description: "first", Up: count("first up"), Down: count("first down")},
+		{Version: 2, Description: "second", Up: count("second up"), Down: count("second down")},
+		{Version: 3, Description: "third", Up: count("third up")},
+	}
+
+	t.Run("pending migrations are applied once in order", func(t *testing.T) {
+		clear()
+		migrator := newMigrator(testMigrations[:2])
+
+		applied, err := migrator.Up()
+		require.NoError(t, err)
+		require.Len(t, applied, 2)
+		assert.Equal(t, 1, applied[0].Version)
+		assert.Equal(t, 2, applied[1].Version)
+
+		migrator = newMigrator(testMigrations)
+		applied, err = migrator.Up()
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, 3, applied[0].Version)
+		assert.Equal(t, int64(1), counted("first up"))
+		assert.Equal(t, int64(1), counted("third up"))
+
+		statuses, err := migrator.Status()
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+		for _, status := range statuses {
+			assert.True(t, status.Applied())
+		}
+	})
+
+	t.Run("migrations are rolled back last one first", func(t *testing.T) {
+		clear()
+		migrator := newMigrator(testMigrations[:2])
+		_, err := migrator.Up()
+		require.NoError(t, err)
+
+		rolledBack, err := migrator.Down(1)
+		require.NoError(t, err)
+		require.Len(t, rolledBack, 1)
+		assert.Equal(t, 2, rolledBack[0].Version)
+		assert.Equal(t, int64(1), counted("second down"))
+		assert.Equal(t, int64(0), counted("first down"))
+
+		statuses, err := migrator.Status()
+		require.NoError(t, err)
+		assert.True(t, statuses[0].Applied())
+		assert.False(t, statuses[1].Applied())
+
+		migrator = newMigrator(testMigrations)
+		_, err = migrator.Up()
+		require.NoError(t, err)
+		_, err = migrator.Down(1)
+		assert.Error(t, err, "the third migration has no down")
+	})
+
+	t.Run("a failing migration isn't recorded and stops the ones after it", func(t *testing.T) {
+		clear()
+		failing := func(tx *db.Tx) error {
+			require.NoError(t, count("failing up")(tx))
+			return errors.New("failed")
+		}
+		migrator := newMigrator([]db.Migration{testMigrations[0], {Version: 2, Up: failing}, testMigrations[2]})
+
+		applied, err := migrator.Up()
+		require.Error(t, err)
+		assert.Len(t, applied, 1)
+		assert.Equal(t, int64(0), counted("failing up"), "the migration is rolled back")
+		assert.Equal(t, int64(0), counted("third up"))
+
+		statuses, err := migrator.Status()
+		require.NoError(t, err)
+		assert.False(t, statuses[1].Applied())
+	})
+
+	t.Run("migrators starting together apply each migration once", func(t *testing.T) {
+		clear()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			migrator := newMigrator(testMigrations)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := migrator.Up()
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(1), counted("first up"))
+		assert.Equal(t, int64(1), counted("second up"))
+		assert.Equal(t, int64(1), counted("third up"))
+	})
+
+	t.Run("migrators wait for the lock of other processes until it expires", func(t *testing.T) {
+		clear()
+		slow := func(tx *db.Tx) error {
+			time.Sleep(2 * time.Second)
+			return nil
+		}
+		holder := newMigrator([]db.Migration{{Version: 1, Up: slow}})
+		done := make(chan error)
+		go func() {
+			_, err := holder.Up()
+			done <- err
+		}()
+		time.Sleep(500 * time.Millisecond)
+
+		waiting := newMigrator(testMigrations)
+		waiting.LockWait = 100 * time.Millisecond
+		_, err := waiting.Up()
+		assert.Equal(t, db.ErrMigrationLocked, err)
+		require.NoError(t, <-done)
+
+		// a lock that wasn't released can be taken over once it expires
+		err = db.Transact(dbDriver, func(tx *db.Tx) error {
+			_, err := neo4j.Collect(tx.Run(
+				"match (l:MigrationLock {name: $lock_name}) set l.owner = 'crashed', l.expires_at = $expires_at",
+				map[string]interface{}{"lock_name": testMigrationLockName, "expires_at": db.NeoDateTime(time.Now().Add(time.Second))},
+			))
+			return err
+		})
+		require.NoError(t, err)
+		waiting.LockWait = 3 * time.Second
+		applied, err := waiting.Up()
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+	})
+}
@@ -0,0 +1,333 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//ErrInvalidCursor is returned for cursors that weren't created by a page with the same sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+//SortKey orders a page by a field of the model
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+//Filter compares a field of the model with a value, Op is one of the keys of FilterOperators
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+//FilterOperators are the comparisons filters can make, contains only works on text fields
+var FilterOperators = map[string]string{
+	"eq":       "=",
+	"ne":       "<>",
+	"lt":       "<",
+	"lte":      "<=",
+	"gt":       ">",
+	"gte":      ">=",
+	"contains": "contains",
+}
+
+//Page of a list of models, ordered by the sort keys and the uid and starting after the node the cursor points to.
+//Nodes without a value for a sort key come last in ascending and first in descending order, like neo4j orders them
+type Page struct {
+	Limit   int
+	Sort    []SortKey
+	Filters []Filter
+	after   *cursor
+	fields  map[string]reflect.Type
+}
+
+//cursor points to the last node of a page with its sort values and uid, Sort makes sure it is used with the same order
+type cursor struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+	UID    string    `json:"u"`
+}
+
+//NewPage of the models of the type of the prototype. Only the fields with a neo tag of a text, number, bool, uuid or time type can be sorted and filtered by.
+//The cursor is empty for the first page
+func NewPage(prototype Model, limit int, sort []SortKey, filters []Filter, encodedCursor string) (*Page, error) {
+	page := &Page{Limit: limit, Sort: sort, Filters: filters, fields: pageFields(prototype)}
+	if limit < 1 {
+		return nil, errors.New("limit has to be greater than 0")
+	}
+	for _, key := range sort {
+		if _, ok := page.fields[key.Field]; !ok {
+			return nil, fmt.Errorf("can't sort by %q", key.Field)
+		}
+	}
+	for _, filter := range filters {
+		fieldType, ok := page.fields[filter.Field]
+		if !ok {
+			return nil, fmt.Errorf("can't filter by %q", filter.Field)
+		}
+		if _, ok := FilterOperators[filter.Op]; !ok {
+			return nil, fmt.Errorf("unknown filter operator %q", filter.Op)
+		}
+		if filter.Op == "contains" && fieldType.Kind() != reflect.String {
+			return nil, fmt.Errorf("%q isn't text, contains can't filter it", filter.Field)
+		}
+		if _, err := neoValue(fieldType, filter.Value); err != nil {
+			return nil, fmt.Errorf("filter by %q: %v", filter.Field, err)
+		}
+	}
+
+	if encodedCursor != "" {
+		after, err := page.decodeCursor(encodedCursor)
+		if err != nil {
+			return nil, err
+		}
+		page.after = after
+	}
+	return page, nil
+}
+
+//FilterConditions on n with their values added to the params
+func (p *Page) FilterConditions(params map[string]interface{}) []string {
+	conditions := []string{}
+	for i, filter := range p.Filters {
+		name := fmt.Sprintf("page_filter_%v", i)
+		params[name], _ = neoValue(p.fields[filter.Field], filter.Value)
+		conditions = append(conditions, fmt.Sprintf("n.%v %v $%v", filter.Field, FilterOperators[filter.Op], name))
+	}
+	return conditions
+}
+
+//Conditions on n that match the nodes of the page, its filters and for all but the first page the nodes after the cursor
+func (p *Page) Conditions(params map[string]interface{}) []string {
+	conditions := p.FilterConditions(params)
+	if p.after == nil {
+		return conditions
+	}
+
+	// the nodes after the cursor are after it on the first sort key, or equal on it and after it on the next one and so on
+	params["page_uid"] = p.after.UID
+	alternatives := []string{}
+	equal := []string{}
+	for i, key := range p.Sort {
+		name := fmt.Sprintf("page_after_%v", i)
+		field := "n." + key.Field
+		value := p.after.Values[i]
+		if value != nil {
+			params[name], _ = neoValue(p.fields[key.Field], *value)
+		}
+
+		var after string
+		switch {
+		case value != nil && !key.Descending:
+			after = fmt.Sprintf("(%v > $%v or %v is null)", field, name, field)
+		case value != nil && key.Descending:
+			after = fmt.Sprintf("%v < $%v", field, name)
+		case value == nil && key.Descending:
+			after = fmt.Sprintf("%v is not null", field)
+		}
+		if after != "" {
+			alternatives = append(alternatives, strings.Join(append(append([]string{}, equal...), after), " and "))
+		}
+
+		if value != nil {
+			equal = append(equal, fmt.Sprintf("%v = $%v", field, name))
+		} else {
+			equal = append(equal, fmt.Sprintf("%v is null", field))
+		}
+	}
+	alternatives = append(alternatives, strings.Join(append(equal, "n.uid > $page_uid"), " and "))
+	return append(conditions, "(("+strings.Join(alternatives, ") or (")+"))")
+}
+
+//OrderBy the sort keys and the uid, which makes the order stable
+func (p *Page) OrderBy() string {
+	orderBy := []string{}
+	for _, key := range p.Sort {
+		if key.Descending {
+			orderBy = append(orderBy, fmt.Sprintf("n.%v desc", key.Field))
+		} else {
+			orderBy = append(orderBy, "n."+key.Field)
+		}
+	}
+	return strings.Join(append(orderBy, "n.uid"), ", ")
+}
+
+//ReadPage of the nodes n the match clause matches and that meet the conditions.
+//It returns their props and the cursor of the next page, which is empty if this is the last page
+func ReadPage(dbDriver neo4j.Driver, match string, conditions []string, params map[string]interface{}, page *Page) ([]map[string]interface{}, string, error) {
+	params = withParam(params, "page_limit", page.Limit+1)
+	conditions = append(append([]string{}, conditions...), page.Conditions(params)...)
+	query := match
+	if len(conditions) > 0 {
+		query = fmt.Sprintf("%v with * where %v", match, strings.Join(conditions, " and "))
+	}
+	query = fmt.Sprintf("%v return properties(n), %v order by %v limit $page_limit", query, page.sortValues(), page.OrderBy())
+
+	found := []map[string]interface{}{}
+	var next string
+	err := ReadTransact(dbDriver, func(tx *Tx) error {
+		records, err := neo4j.Collect(tx.Run(query, params))
+		if err != nil {
+			return err
+		}
+		found = found[:0]
+		next = ""
+		for i, record := range records {
+			if i == page.Limit {
+				next, err = page.encodeCursor(records[i-1].GetByIndex(1))
+				return err
+			}
+			if props, ok := record.GetByIndex(0).(map[string]interface{}); ok {
+				found = append(found, props)
+			}
+		}
+		return nil
+	})
+	return found, next, err
+}
+
+//sortValues returns the values of the sort keys and the uid of n as a list, which is what cursors are made of
+func (p *Page) sortValues() string {
+	values := []string{}
+	for _, key := range p.Sort {
+		values = append(values, "n."+key.Field)
+	}
+	return "[" + strings.Join(append(values, "n.uid"), ", ") + "]"
+}
+
+func (p *Page) sortString() string {
+	keys := []string{}
+	for _, key := range p.Sort {
+		if key.Descending {
+			keys = append(keys, "-"+key.Field)
+		} else {
+			keys = append(keys, key.Field)
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+func (p *Page) encodeCursor(sortValues interface{}) (string, error) {
+	values, ok := sortValues.([]interface{})
+	if !ok || len(values) != len(p.Sort)+1 {
+		return "", errors.New("unexpected sort values")
+	}
+	after := &cursor{Sort: p.sortString(), Values: []*string{}, UID: fmt.Sprint(values[len(p.Sort)])}
+	for _, value := range values[:len(p.Sort)] {
+		after.Values = append(after.Values, formatNeoValue(value))
+	}
+
+	encoded, err := json.Marshal(after)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func (p *Page) decodeCursor(encoded string) (*cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	after := &cursor{}
+	err = json.Unmarshal(decoded, after)
+	if err != nil || after.Sort != p.sortString() || len(after.Values) != len(p.Sort) || after.UID == "" {
+		return nil, ErrInvalidCursor
+	}
+	for i, value := range after.Values {
+		if value == nil {
+			continue
+		}
+		if _, err := neoValue(p.fields[p.Sort[i].Field], *value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return after, nil
+}
+
+//pageFieldTypes can be sorted and filtered by in addition to text, number and bool fields
+var pageFieldTypes = []reflect.Type{uuidType, timeType}
+
+//pageFields are the neo fields of the model with types pages can sort and filter by, keyed by their neo tag
+func pageFields(prototype Model) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	forEachSettableNeoStructField(reflect.ValueOf(prototype).Elem(), func(field reflect.Value, tag string) {
+		fieldType := field.Type()
+		switch fieldType.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			fields[tag] = fieldType
+			return
+		}
+		for _, pageFieldType := range pageFieldTypes {
+			if fieldType == pageFieldType {
+				fields[tag] = fieldType
+			}
+		}
+	})
+	return fields
+}
+
+//neoValue parses the value of a query parameter or cursor into what a field of the type is stored as
+func neoValue(fieldType reflect.Type, value string) (interface{}, error) {
+	switch fieldType {
+	case uuidType, stringType:
+		return value, nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, errors.New("has to be a time like 2019-06-01T10:00:00Z")
+		}
+		return NeoDateTime(t), nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("has to be true or false")
+		}
+		return b, nil
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("has to be a whole number")
+		}
+		return i, nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New("has to be a number")
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported type %v", fieldType)
+}
+
+//formatNeoValue formats a value read from neo4j so that neoValue can parse it again, nil stays nil
+func formatNeoValue(value interface{}) *string {
+	var formatted string
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		formatted = typed.Format(time.RFC3339Nano)
+	case neo4j.LocalDateTime:
+		formatted = typed.Time().Format(time.RFC3339Nano)
+	case float64:
+		formatted = strconv.FormatFloat(typed, 'g', -1, 64)
+	default:
+		formatted = fmt.Sprint(typed)
+	}
+	return &formatted
+}
@@ -0,0 +1,74 @@
+package db_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PageModel struct {
+	SomeModel
+}
+
+func (m *PageModel) Created() bool {
+	return false
+}
+
+func (m *PageModel) NodeName() string {
+	return "PageModel"
+}
+
+func Test_NewPage(t *testing.T) {
+	_, err := db.NewPage(&PageModel{}, 10, []db.SortKey{{Field: "a"}, {Field: "c", Descending: true}}, []db.Filter{{Field: "b", Op: "gte", Value: "2"}}, "")
+	assert.NoError(t, err)
+
+	invalid := []struct {
+		name    string
+		limit   int
+		sort    []db.SortKey
+		filters []db.Filter
+		cursor  string
+	}{
+		{name: "limit", limit: 0},
+		{name: "sort by unknown field", limit: 10, sort: []db.SortKey{{Field: "D"}}},
+		{name: "sort by list", limit: 10, sort: []db.SortKey{{Field: "e"}}},
+		{name: "unknown operator", limit: 10, filters: []db.Filter{{Field: "a", Op: "like", Value: "x"}}},
+		{name: "contains on a number", limit: 10, filters: []db.Filter{{Field: "b", Op: "contains", Value: "1"}}},
+		{name: "filter value of a time", limit: 10, filters: []db.Filter{{Field: "c", Op: "lt", Value: "tomorrow"}}},
+		{name: "cursor that isn't json", limit: 10, cursor: "not a cursor"},
+		{name: "cursor of another order", limit: 10, sort: []db.SortKey{{Field: "b"}}, cursor: encodeCursor(`{"s":"a","v":["x"],"u":"1"}`)},
+	}
+	for _, params := range invalid {
+		page, err := db.NewPage(&PageModel{}, params.limit, params.sort, params.filters, params.cursor)
+		assert.Error(t, err, params.name)
+		assert.Nil(t, page, params.name)
+	}
+}
+
+func Test_PageConditions(t *testing.T) {
+	sort := []db.SortKey{{Field: "a"}, {Field: "b", Descending: true}}
+	filters := []db.Filter{{Field: "a", Op: "contains", Value: "ab"}}
+
+	page, err := db.NewPage(&PageModel{}, 10, sort, filters, "")
+	require.NoError(t, err)
+	assert.Equal(t, "n.a, n.b desc, n.uid", page.OrderBy())
+	params := map[string]interface{}{}
+	assert.Equal(t, []string{"n.a contains $page_filter_0"}, page.Conditions(params))
+	assert.Equal(t, map[string]interface{}{"page_filter_0": "ab"}, params)
+
+	page, err = db.NewPage(&PageModel{}, 10, sort, filters, encodeCursor(`{"s":"a,-b","v":["abc",null],"u":"some-uid"}`))
+	require.NoError(t, err)
+	params = map[string]interface{}{}
+	assert.Equal(t, []string{
+		"n.a contains $page_filter_0",
+		"(((n.a > $page_after_0 or n.a is null)) or (n.a = $page_after_0 and n.b is not null) or (n.a = $page_after_0 and n.b is null and n.uid > $page_uid))",
+	}, page.Conditions(params))
+	assert.Equal(t, map[string]interface{}{"page_filter_0": "ab", "page_after_0": "abc", "page_uid": "some-uid"}, params)
+}
+
+func encodeCursor(cursor string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor))
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//wgs84 is the coordinate reference system of neo4j points given as latitude and longitude
+const wgs84 = 4326
+
+var errInvalidPoint = errors.New("latitude has to be between -90 and 90 and longitude between -180 and 180")
+
+//Point on earth, stored as a neo4j spatial point so distance() can be used in queries
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+//Validate that the point lies on earth
+func (p Point) Validate() error {
+	if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+		return errInvalidPoint
+	}
+	return nil
+}
+
+//NeoPoint prepares p to be given to a neo4j run call
+func (p Point) NeoPoint() *neo4j.Point {
+	return neo4j.NewPoint2D(wgs84, p.Longitude, p.Latitude)
+}
+
+//PointFromNeo reads a point returned by neo4j
+func PointFromNeo(point *neo4j.Point) Point {
+	return Point{Latitude: point.Y(), Longitude: point.X()}
+}
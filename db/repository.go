@@ -0,0 +1,247 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//Repository reads and writes the nodes of one model type, e.g. NewRepository(dbDriver, &models.Club{}).
+//The models it returns are new values of the type of the prototype, unmarshaled from the props of their nodes
+type Repository struct {
+	dbDriver  neo4j.Driver
+	modelType reflect.Type
+	label     string
+}
+
+//ListQuery narrows down, orders and pages the nodes of a list, n is the listed node in Match, Conditions and OrderBy
+type ListQuery struct {
+	//Match replaces the default (n:<label>) pattern, e.g. to list nodes with a certain relation
+	Match      string
+	Conditions []string
+	Params     map[string]interface{}
+	//OrderBy is a cypher expression like "n.name desc", nodes are ordered by uid if it is empty so that pages are stable
+	OrderBy string
+	Skip    int
+	//Limit of 0 lists all nodes
+	Limit int
+}
+
+//NewRepository for the type of the prototype, which has to be a pointer to a struct
+func NewRepository(dbDriver neo4j.Driver, prototype Model) *Repository {
+	modelType := reflect.TypeOf(prototype)
+	if modelType.Kind() != reflect.Ptr || modelType.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("repository prototype has to be a pointer to a struct, got %v", modelType))
+	}
+	return &Repository{dbDriver: dbDriver, modelType: modelType.Elem(), label: prototype.NodeName()}
+}
+
+//FromProps creates a model of the type of the repository with the fields from the props
+func (r *Repository) FromProps(props map[string]interface{}) Model {
+	model := reflect.New(r.modelType).Interface().(Model)
+	UnmarshalNeoFields(model, props)
+	return model
+}
+
+//Create the node of the model together with a CREATED_BY relation to the user and return it as stored
+func (r *Repository) Create(model Model, creatorUID uuid.UUID) (Model, error) {
+	props, err := CreateBy(r.dbDriver, model, creatorUID)
+	if err != nil {
+		return nil, err
+	}
+	return r.FromProps(props), nil
+}
+
+//Get the model with the uid, the error is a NotFoundError if there is none
+func (r *Repository) Get(uid string) (Model, error) {
+	props, err := FindByUID(r.dbDriver, r.Prototype(), uid)
+	if err != nil {
+		return nil, err
+	}
+	return r.FromProps(props), nil
+}
+
+//Update the node of the model and return it as stored
+func (r *Repository) Update(model Model) (Model, error) {
+	if model.Created() {
+		return nil, fmt.Errorf("%v has to be created before it can be updated", strings.ToLower(r.label))
+	}
+	props, err := Save(r.dbDriver, model)
+	if err != nil {
+		return nil, err
+	}
+	return r.FromProps(props), nil
+}
+
+//Delete the node with the uid and its relations, the error is a NotFoundError if there is none
+func (r *Repository) Delete(uid string) error {
+	return Transact(r.dbDriver, func(tx *Tx) error {
+		record, err := neo4j.Single(tx.Run(
+			fmt.Sprintf("optional match (n:%v {uid: $uid}) with collect(n) as nodes foreach (n in nodes | detach delete n) return size(nodes)", r.label),
+			map[string]interface{}{"uid": uid},
+		))
+		if err != nil {
+			return err
+		}
+		if deleted, _ := record.GetByIndex(0).(int64); deleted == 0 {
+			return &NotFoundError{Label: r.label, UID: uid}
+		}
+		tx.NotifyChange(Change{UID: uid, Label: r.label, Deleted: true})
+		return nil
+	})
+}
+
+//List the models matching the query
+func (r *Repository) List(query ListQuery) ([]Model, error) {
+	orderBy := query.OrderBy
+	if orderBy == "" {
+		orderBy = "n.uid"
+	}
+	cypher := fmt.Sprintf("%v return properties(n) order by %v skip $skip", r.matchClause(query), orderBy)
+	params := withParam(query.Params, "skip", query.Skip)
+	if query.Limit > 0 {
+		cypher += " limit $limit"
+		params["limit"] = query.Limit
+	}
+
+	found := []Model{}
+	err := ReadTransact(r.dbDriver, func(tx *Tx) error {
+		records, err := neo4j.Collect(tx.Run(cypher, params))
+		if err != nil {
+			return err
+		}
+		found = found[:0]
+		for _, record := range records {
+			if props, ok := record.GetByIndex(0).(map[string]interface{}); ok {
+				found = append(found, r.FromProps(props))
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+//ListPage of the models matching the query, ordered by the page instead of the query.
+//It returns the cursor of the next page as well, which is empty if this is the last page
+func (r *Repository) ListPage(query ListQuery, page *Page) ([]Model, string, error) {
+	match := query.Match
+	if match == "" {
+		match = fmt.Sprintf("match (n:%v)", r.label)
+	}
+	found, next, err := ReadPage(r.dbDriver, match, query.Conditions, query.Params, page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	models := []Model{}
+	for _, props := range found {
+		models = append(models, r.FromProps(props))
+	}
+	return models, next, nil
+}
+
+//Count the models matching the query, ignoring its order and page
+func (r *Repository) Count(query ListQuery) (total int64, err error) {
+	err = ReadTransact(r.dbDriver, func(tx *Tx) error {
+		record, err := neo4j.Single(tx.Run(fmt.Sprintf("%v return count(distinct n)", r.matchClause(query)), query.Params))
+		if err != nil {
+			return err
+		}
+		total, _ = record.GetByIndex(0).(int64)
+		return nil
+	})
+	return total, err
+}
+
+//RelatedTo lists the models that have the relation to the related node with the uid,
+//e.g. RelatedTo(OFFERS, &models.Sport{}, uid, query) lists the clubs that offer a sport
+func (r *Repository) RelatedTo(relation string, related Model, uid string, query ListQuery) ([]Model, error) {
+	query.Match = fmt.Sprintf("match (n:%v)-[:%v]->(:%v {uid: $related_uid})", r.label, relation, related.NodeName())
+	query.Params = withParam(query.Params, "related_uid", uid)
+	return r.List(query)
+}
+
+//RelatedFrom lists the models the related node with the uid has the relation to,
+//e.g. RelatedFrom(OFFERS, &models.Club{}, uid, query) lists the sports a club offers
+func (r *Repository) RelatedFrom(relation string, related Model, uid string, query ListQuery) ([]Model, error) {
+	query.Match = fmt.Sprintf("match (:%v {uid: $related_uid})-[:%v]->(n:%v)", related.NodeName(), relation, r.label)
+	query.Params = withParam(query.Params, "related_uid", uid)
+	return r.List(query)
+}
+
+//Relate the model with the uid to the node of the type of to with toUID, relating them twice doesn't change anything.
+//The error is a NotFoundError if either of them doesn't exist
+func (r *Repository) Relate(uid uuid.UUID, relation string, to Model, toUID uuid.UUID) error {
+	return r.writeRelation(uid, to, toUID, fmt.Sprintf("merge (n)-[relation:%v]->(to)", relation))
+}
+
+//Unrelate the model with the uid from the node of the type of to with toUID.
+//The error is a NotFoundError if either of them doesn't exist and a RelationNotFoundError if they aren't related
+func (r *Repository) Unrelate(uid uuid.UUID, relation string, to Model, toUID uuid.UUID) error {
+	err := r.writeRelation(uid, to, toUID, fmt.Sprintf("optional match (n)-[relation:%v]->(to) delete relation", relation))
+	if err == errNoRelation {
+		return &RelationNotFoundError{Relation: relation, FromUID: uid.String(), ToUID: toUID.String()}
+	}
+	return err
+}
+
+//errNoRelation is returned by writeRelation when the write didn't match a relation
+var errNoRelation = errors.New("no relation")
+
+func (r *Repository) writeRelation(uid uuid.UUID, to Model, toUID uuid.UUID, write string) error {
+	return Transact(r.dbDriver, func(tx *Tx) error {
+		records, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf("match (n:%v {uid: $uid}), (to:%v {uid: $to_uid}) %v return n.uid, count(relation)", r.label, to.NodeName(), write),
+			map[string]interface{}{"uid": uid.String(), "to_uid": toUID.String()},
+		))
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			exists, err := tx.Exists(r.Prototype(), uid.String())
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return &NotFoundError{Label: r.label, UID: uid.String()}
+			}
+			return &NotFoundError{Label: to.NodeName(), UID: toUID.String()}
+		}
+		if related, _ := records[0].GetByIndex(1).(int64); related == 0 {
+			return errNoRelation
+		}
+		tx.NotifyRelationChange(Change{UID: uid.String(), Label: r.label}, Change{UID: toUID.String(), Label: to.NodeName()})
+		return nil
+	})
+}
+
+//Prototype returns a new, empty model of the type of the repository
+func (r *Repository) Prototype() Model {
+	return reflect.New(r.modelType).Interface().(Model)
+}
+
+func (r *Repository) matchClause(query ListQuery) string {
+	match := query.Match
+	if match == "" {
+		match = fmt.Sprintf("match (n:%v)", r.label)
+	}
+	if len(query.Conditions) > 0 {
+		// like ReadPage, so that the match clause can end with a with clause, e.g. to compute a distance
+		match += " with * where " + strings.Join(query.Conditions, " and ")
+	}
+	return match
+}
+
+//withParam copies the params and adds the value, queries keep their params as they are
+func withParam(params map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	withValue := map[string]interface{}{}
+	for existingKey, existingValue := range params {
+		withValue[existingKey] = existingValue
+	}
+	withValue[key] = value
+	return withValue
+}
@@ -0,0 +1,38 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/stretchr/testify/assert"
+)
+
+type RepositoryModel struct {
+	SomeBaseModel
+}
+
+func (m *RepositoryModel) Created() bool {
+	return false
+}
+
+func (m *RepositoryModel) NodeName() string {
+	return "RepositoryModel"
+}
+
+type NotAStructModel string
+
+func (m NotAStructModel) Created() bool {
+	return false
+}
+
+func (m NotAStructModel) NodeName() string {
+	return "NotAStructModel"
+}
+
+func Test_RepositoryFromProps(t *testing.T) {
+	repository := db.NewRepository(nil, &RepositoryModel{})
+	model := repository.FromProps(map[string]interface{}{"a": "some a", "b": int64(2)})
+	assert.Equal(t, &RepositoryModel{SomeBaseModel{A: "some a", B: 2}}, model)
+
+	assert.Panics(t, func() { db.NewRepository(nil, NotAStructModel("")) })
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//maxTxAttempts is how often a unit of work is tried before its error is returned
+const maxTxAttempts = 5
+
+//txRetryDelay before the first retry, it doubles with every further retry
+const txRetryDelay = 50 * time.Millisecond
+
+//Tx is a unit of work, everything run in it is committed together or not at all.
+//Listeners are told about the changes made in it only once it is committed
+type Tx struct {
+	tx      neo4j.Transaction
+	changes []Change
+}
+
+//Run a statement in the transaction
+func (tx *Tx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	return tx.tx.Run(cypher, params)
+}
+
+//NotifyChange tells all listeners about the change after the transaction is committed,
+//queries that write nodes without the methods of Tx should call it themselves
+func (tx *Tx) NotifyChange(change Change) {
+	tx.changes = append(tx.changes, change)
+}
+
+//NotifyRelationChange tells all listeners about both nodes of a relation that was written,
+//the documents of nodes can include what they are related to
+func (tx *Tx) NotifyRelationChange(from, to Change) {
+	tx.NotifyChange(from)
+	tx.NotifyChange(to)
+}
+
+//Transact runs work in a write transaction, which is committed if work returns no error and rolled back otherwise.
+//Work that fails because of a transient neo4j error (e.g. a deadlock) is retried in a new transaction,
+//so it shouldn't have effects outside of tx
+func Transact(dbDriver neo4j.Driver, work func(tx *Tx) error) error {
+	return transact(dbDriver, neo4j.AccessModeWrite, work)
+}
+
+//ReadTransact runs work in a read transaction, it is retried like the work of Transact
+func ReadTransact(dbDriver neo4j.Driver, work func(tx *Tx) error) error {
+	return transact(dbDriver, neo4j.AccessModeRead, work)
+}
+
+func transact(dbDriver neo4j.Driver, accessMode neo4j.AccessMode, work func(tx *Tx) error) error {
+	var changes []Change
+	err := retryTransient(func() error {
+		// a new session for every attempt, the connection of the last one might be broken
+		dbSession, err := dbDriver.Session(accessMode)
+		if err != nil {
+			return err
+		}
+		defer dbSession.Close()
+		neoTx, err := dbSession.BeginTransaction()
+		if err != nil {
+			return err
+		}
+		defer neoTx.Close()
+
+		tx := &Tx{tx: neoTx}
+		err = work(tx)
+		if err != nil {
+			neoTx.Rollback()
+			return err
+		}
+		err = neoTx.Commit()
+		if err != nil {
+			return err
+		}
+		changes = tx.changes
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		NotifyChange(change)
+	}
+	return nil
+}
+
+//retryTransient calls work until it succeeds, fails with an error that isn't transient or was tried maxTxAttempts times
+func retryTransient(work func() error) error {
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := work()
+		if err == nil || attempt == maxTxAttempts || !isTransient(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+//isTransient errors can go away when the work is tried again
+func isTransient(err error) bool {
+	return neo4j.IsTransientError(err) || neo4j.IsSessionExpired(err) || neo4j.IsServiceUnavailable(err)
+}
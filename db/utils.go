@@ -17,12 +17,20 @@ var timeType = reflect.TypeOf(time.Time{})
 var stringType = reflect.TypeOf("")
 var uuidType = reflect.TypeOf(uuid.UUID{})
 var localDateTimeType = reflect.TypeOf(neo4j.LocalDateTime{})
+var timeSliceType = reflect.TypeOf([]time.Time{})
+var interfaceSliceType = reflect.TypeOf([]interface{}{})
+var pointType = reflect.TypeOf(Point{})
+var pointPointerType = reflect.TypeOf(&Point{})
+var neoPointType = reflect.TypeOf(&neo4j.Point{})
 
 //UnmarshalNeoFields of the given interface
 //interface should be a pointer to some struct
 func UnmarshalNeoFields(obj interface{}, props map[string]interface{}) {
 	forEachSettableNeoStructField(reflect.ValueOf(obj).Elem(), func(field reflect.Value, tag string) {
 		prop := props[tag]
+		if prop == nil {
+			return
+		}
 		propVal := reflect.ValueOf(prop)
 		propType := propVal.Type()
 		fieldType := field.Type()
@@ -44,6 +52,30 @@ func UnmarshalNeoFields(obj interface{}, props map[string]interface{}) {
 					localDateTime := prop.(neo4j.LocalDateTime)
 					field.Set(reflect.ValueOf(localDateTime.Time()))
 				}
+			case timeSliceType:
+				if propType == interfaceSliceType {
+					times := []time.Time{}
+					for _, element := range prop.([]interface{}) {
+						if t, ok := element.(time.Time); ok {
+							times = append(times, t)
+						}
+					}
+					field.Set(reflect.ValueOf(times))
+				}
+			case pointType:
+				if propType == neoPointType {
+					field.Set(reflect.ValueOf(PointFromNeo(prop.(*neo4j.Point))))
+				}
+			case pointPointerType:
+				if propType == neoPointType {
+					point := PointFromNeo(prop.(*neo4j.Point))
+					field.Set(reflect.ValueOf(&point))
+				}
+			default:
+				// neo4j returns all integers as int64
+				if fieldType.Kind() == reflect.Int && propType.Kind() == reflect.Int64 {
+					field.SetInt(propVal.Int())
+				}
 			}
 		}
 	})
@@ -60,7 +92,31 @@ func MarshalNeoFields(obj interface{}) map[string]interface{} {
 			props[tag] = uid.String()
 		case time.Time:
 			timeValue := fieldInterface.(time.Time)
-			props[tag] = neo4j.LocalDateTimeOf(timeValue)
+			if timeValue.IsZero() {
+				props[tag] = nil
+			} else {
+				props[tag] = NeoDateTime(timeValue)
+			}
+		case []time.Time:
+			timeValues := fieldInterface.([]time.Time)
+			if len(timeValues) == 0 {
+				props[tag] = nil
+			} else {
+				neoTimes := []interface{}{}
+				for _, timeValue := range timeValues {
+					neoTimes = append(neoTimes, NeoDateTime(timeValue))
+				}
+				props[tag] = neoTimes
+			}
+		case Point:
+			props[tag] = fieldInterface.(Point).NeoPoint()
+		case *Point:
+			point := fieldInterface.(*Point)
+			if point == nil {
+				props[tag] = nil
+			} else {
+				props[tag] = point.NeoPoint()
+			}
 		default:
 			props[tag] = fieldInterface
 		}
@@ -68,6 +124,20 @@ func MarshalNeoFields(obj interface{}) map[string]interface{} {
 	return props
 }
 
+//NeoDateTime prepares t to be stored as a zoned neo4j DateTime.
+//Named (IANA) locations are kept as they are, everything else (Local, parsed offsets) is stored with its fixed offset
+func NeoDateTime(t time.Time) time.Time {
+	location := t.Location()
+	if location != time.Local && location.String() != "" {
+		if _, err := time.LoadLocation(location.String()); err == nil {
+			return t
+		}
+	}
+
+	_, offset := t.Zone()
+	return t.In(time.FixedZone("Offset", offset))
+}
+
 //NeoFields returns the fields of the given struct tag have the tag `neo:"<something>"`
 func NeoFields(obj interface{}) (neoFieldNames []string) {
 	forEachSettableNeoStructField(reflect.ValueOf(obj).Elem(), func(field reflect.Value, tag string) {
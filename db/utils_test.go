@@ -10,6 +10,7 @@ import (
 
 	"github.com/alexmorten/events-api/db"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type SomeBaseModel struct {
@@ -20,8 +21,11 @@ type SomeBaseModel struct {
 
 type SomeModel struct {
 	SomeBaseModel
-	C time.Time `neo:"c"`
-	D string    `something:"else"`
+	C time.Time   `neo:"c"`
+	D string      `something:"else"`
+	E []time.Time `neo:"e"`
+	F db.Point    `neo:"f"`
+	G *db.Point   `neo:"g"`
 }
 
 func Test_UnmarshalNeoFields(t *testing.T) {
@@ -44,7 +48,7 @@ func Test_UnmarshalNeoFields(t *testing.T) {
 
 func Test_MarshalNeoFields(t *testing.T) {
 	uid := uuid.New()
-	timeValue := time.Time{}
+	timeValue := time.Date(2019, 3, 31, 18, 30, 0, 0, time.UTC)
 	m := &SomeModel{}
 	m.A = "123"
 	m.B = 123
@@ -55,12 +59,87 @@ func Test_MarshalNeoFields(t *testing.T) {
 	props := db.MarshalNeoFields(m)
 	assert.Equal(t, "123", props["a"])
 	assert.Equal(t, 123, props["b"])
-	assert.Equal(t, neo4j.LocalDateTimeOf(timeValue), props["c"])
+	assert.Equal(t, timeValue, props["c"])
 	assert.Equal(t, uid.String(), props["uid"])
 	assert.Equal(t, nil, props["d"])
 }
 
+func Test_MarshalNeoFields_ZeroTime(t *testing.T) {
+	m := &SomeModel{}
+
+	props := db.MarshalNeoFields(m)
+	assert.Contains(t, props, "c")
+	assert.Nil(t, props["c"])
+}
+
+func Test_UnmarshalNeoFields_MissingProps(t *testing.T) {
+	m := &SomeModel{}
+	db.UnmarshalNeoFields(m, map[string]interface{}{"a": "123"})
+	assert.Equal(t, "123", m.A)
+	assert.True(t, m.C.IsZero())
+}
+
+func Test_UnmarshalNeoFields_LocalDateTime(t *testing.T) {
+	timeValue := time.Date(2019, 3, 31, 18, 30, 0, 0, time.UTC)
+	m := &SomeModel{}
+	db.UnmarshalNeoFields(m, map[string]interface{}{"c": neo4j.LocalDateTimeOf(timeValue)})
+	assert.True(t, timeValue.Equal(m.C))
+}
+
+func Test_UnmarshalNeoFields_Int64(t *testing.T) {
+	m := &SomeModel{}
+	db.UnmarshalNeoFields(m, map[string]interface{}{"b": int64(123)})
+	assert.Equal(t, 123, m.B)
+}
+
+func Test_TimeSliceFields(t *testing.T) {
+	timeValue := time.Date(2019, 3, 31, 18, 30, 0, 0, time.UTC)
+	m := &SomeModel{E: []time.Time{timeValue}}
+
+	props := db.MarshalNeoFields(m)
+	assert.Equal(t, []interface{}{timeValue}, props["e"])
+
+	unmarshaled := &SomeModel{}
+	db.UnmarshalNeoFields(unmarshaled, props)
+	assert.Equal(t, []time.Time{timeValue}, unmarshaled.E)
+
+	assert.Nil(t, db.MarshalNeoFields(&SomeModel{})["e"])
+}
+
+func Test_PointFields(t *testing.T) {
+	m := &SomeModel{F: db.Point{Latitude: 52.52, Longitude: 13.405}, G: &db.Point{Latitude: -33.87, Longitude: 151.21}}
+
+	props := db.MarshalNeoFields(m)
+	point, ok := props["f"].(*neo4j.Point)
+	require.True(t, ok)
+	assert.Equal(t, 4326, point.SrId())
+	assert.Equal(t, 13.405, point.X())
+	assert.Equal(t, 52.52, point.Y())
+
+	unmarshaled := &SomeModel{}
+	db.UnmarshalNeoFields(unmarshaled, props)
+	assert.Equal(t, m.F, unmarshaled.F)
+	assert.Equal(t, m.G, unmarshaled.G)
+
+	assert.Nil(t, db.MarshalNeoFields(&SomeModel{})["g"])
+}
+
+func Test_NeoDateTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	inBerlin := time.Date(2019, 3, 31, 18, 30, 0, 0, berlin)
+	assert.Equal(t, "Europe/Berlin", db.NeoDateTime(inBerlin).Location().String())
+
+	withOffset, err := time.Parse(time.RFC3339, "2019-03-31T18:30:00+02:00")
+	require.NoError(t, err)
+	converted := db.NeoDateTime(withOffset)
+	name, offset := converted.Zone()
+	assert.Equal(t, "Offset", name)
+	assert.Equal(t, 2*60*60, offset)
+	assert.True(t, withOffset.Equal(converted))
+}
+
 func Test_NeoFields(t *testing.T) {
 	m := &SomeModel{}
-	assert.Equal(t, []string{"uid", "a", "b", "c"}, db.NeoFields(m))
+	assert.Equal(t, []string{"uid", "a", "b", "c", "e", "f", "g"}, db.NeoFields(m))
 }
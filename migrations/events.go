@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//backfillRecurrenceEnds sets recurrence_ends_at on finite recurring events that were stored without it,
+//lists of events in a time window treat series without it as endless
+func backfillRecurrenceEnds(tx *db.Tx) error {
+	records, err := neo4j.Collect(tx.Run("match (n:Event) where n.rrule is not null and n.recurrence_ends_at is null return properties(n)", nil))
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		props, ok := record.GetByIndex(0).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		event := models.EventFromProps(props)
+		event.Normalize()
+		if event.RecurrenceEndsAt.IsZero() {
+			continue
+		}
+
+		_, err := neo4j.Collect(tx.Run(
+			"match (n:Event {uid: $uid}) set n.recurrence_ends_at = $recurrence_ends_at",
+			map[string]interface{}{"uid": event.UID.String(), "recurrence_ends_at": db.NeoDateTime(event.RecurrenceEndsAt)},
+		))
+		if err != nil {
+			return err
+		}
+		tx.NotifyChange(db.Change{UID: event.UID.String(), Label: event.NodeName()})
+	}
+	return nil
+}
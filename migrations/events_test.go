@@ -0,0 +1,42 @@
+package migrations_test
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/alexmorten/events-api"
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/migrations"
+	"github.com/alexmorten/events-api/models"
+	"github.com/alexmorten/events-api/testhelpers"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Events(t *testing.T) {
+	config := api.DefaultServerConfig()
+	dbDriver := db.Driver(config.Neo4jAddress)
+
+	t.Run("finite recurring events stored without their end get it", func(t *testing.T) {
+		testhelpers.Clear(dbDriver)
+		event := models.NewEvent()
+		event.Name = "weekly"
+		event.StartsAt = time.Date(2019, 6, 3, 18, 0, 0, 0, time.UTC)
+		event.EndsAt = event.StartsAt.Add(2 * time.Hour)
+		event.RecurrenceRule = "FREQ=WEEKLY;COUNT=3"
+		event.Normalize()
+		_, err := db.Save(dbDriver, event)
+		require.NoError(t, err)
+		err = db.Transact(dbDriver, func(tx *db.Tx) error {
+			_, err := neo4j.Collect(tx.Run("match (n:Event {uid: $uid}) remove n.recurrence_ends_at", map[string]interface{}{"uid": event.UID.String()}))
+			return err
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, db.Migrate(dbDriver, migrations.All))
+		migrated, err := models.FindEvent(dbDriver, event.UID.String())
+		require.NoError(t, err)
+		assert.True(t, migrated.RecurrenceEndsAt.Equal(time.Date(2019, 6, 17, 20, 0, 0, 0, time.UTC)), migrated.RecurrenceEndsAt)
+	})
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/alexmorten/events-api/db"
+)
+
+//All migrations of the database, the server applies the pending ones when it starts.
+//New migrations are appended with the next version, the versions of released migrations must not change
+var All = []db.Migration{
+	{
+		Version:     1,
+		Description: "create uniqueness constraints and indexes",
+		Schema:      true,
+		Up: db.Statements(
+			"CREATE CONSTRAINT ON (u:User) ASSERT u.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (u:User) ASSERT u.email IS UNIQUE",
+			"CREATE CONSTRAINT ON (e:Event) ASSERT e.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (c:Club) ASSERT c.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (g:Group) ASSERT g.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (s:Sport) ASSERT s.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (v:Venue) ASSERT v.uid IS UNIQUE",
+			"CREATE INDEX ON :Venue(location)",
+			"CREATE CONSTRAINT ON (t:Tag) ASSERT t.uid IS UNIQUE",
+			"CREATE CONSTRAINT ON (t:Tag) ASSERT t.name IS UNIQUE",
+			"CREATE CONSTRAINT ON (p:PendingIndexWrite) ASSERT p.node_uid IS UNIQUE",
+		),
+		Down: db.Statements(
+			"DROP CONSTRAINT ON (u:User) ASSERT u.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (u:User) ASSERT u.email IS UNIQUE",
+			"DROP CONSTRAINT ON (e:Event) ASSERT e.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (c:Club) ASSERT c.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (g:Group) ASSERT g.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (s:Sport) ASSERT s.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (v:Venue) ASSERT v.uid IS UNIQUE",
+			"DROP INDEX ON :Venue(location)",
+			"DROP CONSTRAINT ON (t:Tag) ASSERT t.uid IS UNIQUE",
+			"DROP CONSTRAINT ON (t:Tag) ASSERT t.name IS UNIQUE",
+			"DROP CONSTRAINT ON (p:PendingIndexWrite) ASSERT p.node_uid IS UNIQUE",
+		),
+	},
+	{
+		Version:     2,
+		Description: "backfill the end of finite recurring events",
+		Up:          backfillRecurrenceEnds,
+		// the ends are right with or without the migration, there is nothing to undo
+		Down: func(tx *db.Tx) error { return nil },
+	},
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+var (
+	//ErrNotAnAdmin is returned when removing admin rights from users that don't have them
+	ErrNotAnAdmin = errors.New("user is not an admin")
+	//ErrLastAdmin is returned when removing the last admin of a club, which would leave nobody to manage it
+	ErrLastAdmin = errors.New("the last admin of a club can't be removed")
+)
+
+//EffectiveAdmin administers a club or group, either directly or through one of the groups or the club above it
+type EffectiveAdmin struct {
+	PublicUserAttributes
+	//InheritedFrom is the closest ancestor the user administers, nil if they administer the club or group directly
+	InheritedFrom *GroupOrClubReference `json:"inherited_from"`
+}
+
+//RemoveAdminFromClub unless the user is its last admin.
+//The admins are counted and removed in one transaction holding a lock on the club, so concurrent removals can't leave it without admins
+func RemoveAdminFromClub(dbDriver neo4j.Driver, clubUID, userUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	_, err = dbSession.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		record, err := neo4j.Single(tx.Run(
+			fmt.Sprintf(
+				`
+				match (c:Club {uid: $club_uid})
+				set c._admin_lock = true remove c._admin_lock
+				with c
+				optional match (u:User)-[r:%v]->(c)
+				return count(r), sum(case when u.uid = $user_uid then 1 else 0 end)
+				`,
+				UserAdministersGroupOrClub,
+			),
+			map[string]interface{}{"club_uid": clubUID.String(), "user_uid": userUID.String()},
+		))
+		if err != nil {
+			return nil, err
+		}
+		adminCount, _ := record.GetByIndex(0).(int64)
+		isAdmin, _ := record.GetByIndex(1).(int64)
+		if isAdmin == 0 {
+			return nil, ErrNotAnAdmin
+		}
+		if adminCount <= 1 {
+			return nil, ErrLastAdmin
+		}
+
+		return neo4j.Collect(tx.Run(
+			fmt.Sprintf("match (:User {uid: $user_uid})-[r:%v]->(:Club {uid: $club_uid}) delete r", UserAdministersGroupOrClub),
+			map[string]interface{}{"club_uid": clubUID.String(), "user_uid": userUID.String()},
+		))
+	})
+	return err
+}
+
+//RemoveAdminFromGroup takes away the direct admin rights of the user, admins of the groups or club above it keep administering it
+func RemoveAdminFromGroup(dbDriver neo4j.Driver, groupUID, userUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	record, err := neo4j.Single(dbSession.Run(
+		fmt.Sprintf(
+			"optional match (:User {uid: $user_uid})-[r:%v]->(:Group {uid: $group_uid}) delete r return count(r)",
+			UserAdministersGroupOrClub,
+		),
+		map[string]interface{}{"group_uid": groupUID.String(), "user_uid": userUID.String()},
+	))
+	if err != nil {
+		return err
+	}
+	if removed, _ := record.GetByIndex(0).(int64); removed == 0 {
+		return ErrNotAnAdmin
+	}
+	return nil
+}
+
+//FindEffectiveAdmins of the club or group, the same users Group.AdministeredByUser lets through apart from global admins.
+//Users administering several ancestors are listed once with the closest one
+func FindEffectiveAdmins(dbDriver neo4j.Driver, groupOrClub GroupOrClub) ([]*EffectiveAdmin, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			`
+			match p = (:%v {uid: $uid})-[:%v*0..10]->(n)<-[:%v]-(u:User)
+			where n:Club or n:Group
+			with u, n, length(p) as distance order by distance
+			with u, collect(n)[0] as n, min(distance) as distance
+			return properties(u), properties(n), labels(n), distance
+			order by distance, u.name, u.email
+			`,
+			groupOrClub.NodeName(),
+			GroupBelongsToGroupOrClub,
+			UserAdministersGroupOrClub,
+		),
+		map[string]interface{}{"uid": groupOrClub.GetUID().String()},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	admins := []*EffectiveAdmin{}
+	for _, record := range records {
+		userProps, _ := record.GetByIndex(0).(map[string]interface{})
+		admin := &EffectiveAdmin{PublicUserAttributes: UserFromProps(userProps).PublicAttributes()}
+		if distance, _ := record.GetByIndex(3).(int64); distance > 1 {
+			if ancestor := groupOrClubFromRecord(record); ancestor != nil {
+				reference := ReferenceTo(ancestor)
+				admin.InheritedFrom = &reference
+			}
+		}
+		admins = append(admins, admin)
+	}
+	return admins, nil
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+const (
+	//AttendanceGoing users take one of the places of the event
+	AttendanceGoing = "going"
+	//AttendanceMaybe users might come
+	AttendanceMaybe = "maybe"
+	//AttendanceDeclined users won't come
+	AttendanceDeclined = "declined"
+	//AttendanceWaitlisted users wanted to go while the event was full, they are promoted to going in order once places free up
+	AttendanceWaitlisted = "waitlisted"
+)
+
+//Attendance of a user at an event
+type Attendance struct {
+	EventUID     uuid.UUID  `json:"event_uid"`
+	UserUID      uuid.UUID  `json:"user_uid"`
+	Status       string     `json:"status"`
+	WaitlistedAt *time.Time `json:"waitlisted_at,omitempty"`
+}
+
+//ValidAttendanceStatus that users can respond with, waitlisted is only ever assigned
+func ValidAttendanceStatus(status string) bool {
+	return status == AttendanceGoing || status == AttendanceMaybe || status == AttendanceDeclined
+}
+
+//Attend sets the response of the user to the event.
+//Users that want to go to a full event are waitlisted instead.
+//The capacity check and the relation update happen in a single transaction holding a lock on the event, so concurrent sign-ups can't overbook it,
+//a transaction that fails because of a deadlock is retried.
+//The error is a db.NotFoundError if the event or the user doesn't exist
+func Attend(dbDriver neo4j.Driver, eventUID, userUID uuid.UUID, status string) (*Attendance, error) {
+	if !ValidAttendanceStatus(status) {
+		return nil, fmt.Errorf("status has to be one of %v, %v or %v", AttendanceGoing, AttendanceMaybe, AttendanceDeclined)
+	}
+
+	var attendance *Attendance
+	err := db.Transact(dbDriver, func(tx *db.Tx) error {
+		capacity, err := lockEventForAttendance(tx, eventUID)
+		if err != nil {
+			return err
+		}
+		err = ensureUserExists(tx, userUID)
+		if err != nil {
+			return err
+		}
+
+		previous, err := attendanceStatus(tx, eventUID, userUID)
+		if err != nil {
+			return err
+		}
+		// decided in every attempt, a retried transaction can find a different number of places left
+		newStatus := status
+		if newStatus == AttendanceGoing && previous != AttendanceGoing {
+			going, err := countGoing(tx, eventUID)
+			if err != nil {
+				return err
+			}
+			if capacity > 0 && going >= capacity {
+				newStatus = AttendanceWaitlisted
+			}
+		}
+		if newStatus == previous {
+			attendance, err = findAttendance(tx, eventUID, userUID)
+			return err
+		}
+
+		_, err = neo4j.Collect(tx.Run(
+			fmt.Sprintf(
+				`
+				match (u:User {uid: $user_uid}), (e:Event {uid: $event_uid})
+				merge (u)-[r:%v]->(e)
+				set r.status = $status, r.updated_at = $now,
+					r.waitlisted_at = case when $status = $waitlisted then $now else null end
+				`,
+				UserAttendsEvent,
+			),
+			map[string]interface{}{
+				"user_uid":   userUID.String(),
+				"event_uid":  eventUID.String(),
+				"status":     newStatus,
+				"waitlisted": AttendanceWaitlisted,
+				"now":        db.NeoDateTime(time.Now()),
+			},
+		))
+		if err != nil {
+			return err
+		}
+
+		if previous == AttendanceGoing {
+			err = promoteWaitlisted(tx, eventUID, capacity)
+			if err != nil {
+				return err
+			}
+		}
+		tx.NotifyChange(db.Change{UID: eventUID.String(), Label: "Event"})
+		attendance, err = findAttendance(tx, eventUID, userUID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+//CancelAttendance removes the response of the user to the event, promoting the next waitlisted user if a place became free
+func CancelAttendance(dbDriver neo4j.Driver, eventUID, userUID uuid.UUID) error {
+	return db.Transact(dbDriver, func(tx *db.Tx) error {
+		capacity, err := lockEventForAttendance(tx, eventUID)
+		if err != nil {
+			return err
+		}
+
+		_, err = neo4j.Collect(tx.Run(
+			fmt.Sprintf("match (:User {uid: $user_uid})-[r:%v]->(:Event {uid: $event_uid}) delete r", UserAttendsEvent),
+			map[string]interface{}{"user_uid": userUID.String(), "event_uid": eventUID.String()},
+		))
+		if err != nil {
+			return err
+		}
+		tx.NotifyChange(db.Change{UID: eventUID.String(), Label: "Event"})
+		return promoteWaitlisted(tx, eventUID, capacity)
+	})
+}
+
+//PromoteWaitlistedAttendees fills free places of the event from its waitlist, e.g. after its capacity was raised
+func PromoteWaitlistedAttendees(dbDriver neo4j.Driver, eventUID uuid.UUID) error {
+	return db.Transact(dbDriver, func(tx *db.Tx) error {
+		capacity, err := lockEventForAttendance(tx, eventUID)
+		if err != nil {
+			return err
+		}
+		return promoteWaitlisted(tx, eventUID, capacity)
+	})
+}
+
+//FindAttendees of the event that responded with the given status
+func FindAttendees(dbDriver neo4j.Driver, eventUID uuid.UUID, status string) ([]*User, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			"match (n:User)-[r:%v {status: $status}]->(:Event {uid: $event_uid}) return properties(n) order by coalesce(r.waitlisted_at, r.updated_at)",
+			UserAttendsEvent,
+		),
+		map[string]interface{}{"event_uid": eventUID.String(), "status": status},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*User{}
+	for _, record := range records {
+		propInterface, ok := record.Get("properties(n)")
+		if ok {
+			props, ok := propInterface.(map[string]interface{})
+			if ok {
+				users = append(users, UserFromProps(props))
+			}
+		}
+	}
+	return users, nil
+}
+
+//lockEventForAttendance takes a write lock on the event node that is held until the transaction ends
+//and returns the capacity of the event, 0 meaning unlimited
+func lockEventForAttendance(tx *db.Tx, eventUID uuid.UUID) (int64, error) {
+	records, err := neo4j.Collect(tx.Run(
+		"match (e:Event {uid: $uid}) set e._attendance_lock = true remove e._attendance_lock return coalesce(e.capacity, 0)",
+		map[string]interface{}{"uid": eventUID.String()},
+	))
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		return 0, &db.NotFoundError{Label: "Event", UID: eventUID.String()}
+	}
+	capacity, _ := records[0].GetByIndex(0).(int64)
+	return capacity, nil
+}
+
+//ensureUserExists returns a db.NotFoundError if there is no user with the uid, e.g. because it was deleted after its token was issued
+func ensureUserExists(tx *db.Tx, userUID uuid.UUID) error {
+	records, err := neo4j.Collect(tx.Run("match (u:User {uid: $uid}) return u.uid", map[string]interface{}{"uid": userUID.String()}))
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return &db.NotFoundError{Label: "User", UID: userUID.String()}
+	}
+	return nil
+}
+
+func attendanceStatus(tx *db.Tx, eventUID, userUID uuid.UUID) (string, error) {
+	attendance, err := findAttendance(tx, eventUID, userUID)
+	if err != nil || attendance == nil {
+		return "", err
+	}
+	return attendance.Status, nil
+}
+
+func findAttendance(tx *db.Tx, eventUID, userUID uuid.UUID) (*Attendance, error) {
+	records, err := neo4j.Collect(tx.Run(
+		fmt.Sprintf("match (:User {uid: $user_uid})-[r:%v]->(:Event {uid: $event_uid}) return r.status, r.waitlisted_at", UserAttendsEvent),
+		map[string]interface{}{"user_uid": userUID.String(), "event_uid": eventUID.String()},
+	))
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	attendance := &Attendance{EventUID: eventUID, UserUID: userUID}
+	attendance.Status, _ = records[0].GetByIndex(0).(string)
+	if waitlistedAt, ok := records[0].GetByIndex(1).(time.Time); ok {
+		attendance.WaitlistedAt = &waitlistedAt
+	}
+	return attendance, nil
+}
+
+func countGoing(tx *db.Tx, eventUID uuid.UUID) (int64, error) {
+	record, err := neo4j.Single(tx.Run(
+		fmt.Sprintf("match (:User)-[r:%v {status: $going}]->(:Event {uid: $uid}) return count(r)", UserAttendsEvent),
+		map[string]interface{}{"uid": eventUID.String(), "going": AttendanceGoing},
+	))
+	if err != nil {
+		return 0, err
+	}
+	going, _ := record.GetByIndex(0).(int64)
+	return going, nil
+}
+
+//promoteWaitlisted moves waitlisted users to going in the order they were waitlisted until the event is full
+func promoteWaitlisted(tx *db.Tx, eventUID uuid.UUID, capacity int64) error {
+	limit := int64(-1)
+	if capacity > 0 {
+		going, err := countGoing(tx, eventUID)
+		if err != nil {
+			return err
+		}
+		limit = capacity - going
+		if limit <= 0 {
+			return nil
+		}
+	}
+
+	limitClause := ""
+	if limit > 0 {
+		limitClause = "limit $limit"
+	}
+	query := fmt.Sprintf(
+		`
+		match (:User)-[r:%v {status: $waitlisted}]->(:Event {uid: $uid})
+		with r order by r.waitlisted_at %v
+		set r.status = $going, r.updated_at = $now, r.waitlisted_at = null
+		`,
+		UserAttendsEvent,
+		limitClause,
+	)
+	_, err := neo4j.Collect(tx.Run(query, map[string]interface{}{
+		"uid":        eventUID.String(),
+		"waitlisted": AttendanceWaitlisted,
+		"going":      AttendanceGoing,
+		"limit":      limit,
+		"now":        db.NeoDateTime(time.Now()),
+	}))
+	return err
+}
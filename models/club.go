@@ -24,9 +24,9 @@ func NewClub() *Club {
 	}
 }
 
-//FindClub with its uid
+//FindClub with its uid, the error is a db.NotFoundError if there is no club with it
 func FindClub(dbDriver neo4j.Driver, ClubUID string) (*Club, error) {
-	props, err := db.FindNode(dbDriver, ClubUID)
+	props, err := db.FindByUID(dbDriver, &Club{}, ClubUID)
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +39,60 @@ func (c *Club) NodeName() string {
 	return "Club"
 }
 
+// AdministeredByUser , is the club administered by the user?
+func (c *Club) AdministeredByUser(dbDriver neo4j.Driver, userUID uuid.UUID) bool {
+	user, err := FindUser(dbDriver, userUID.String())
+	if err != nil || user == nil {
+		return false
+	}
+
+	if user.Admin {
+		return true
+	}
+
+	relationProps, err := db.FindRelation(dbDriver, user.UID.String(), c.UID.String(), UserAdministersGroupOrClub)
+	return err == nil && relationProps != nil
+}
+
+//FindClubsOfUser returns the clubs the user created, administers or is an active member of
+func FindClubsOfUser(dbDriver neo4j.Driver, userUID uuid.UUID) ([]*Club, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		`
+		match (u:User {uid: $uid})-[r]-(n:Club)
+		where type(r) in $relations or (type(r) = $member_of and r.status = $active)
+		with distinct n
+		return properties(n) order by n.name
+		`,
+		map[string]interface{}{
+			"uid":       userUID.String(),
+			"relations": []interface{}{string(ModelCreatedByUser), UserAdministersGroupOrClub},
+			"member_of": UserMemberOfGroupOrClub,
+			"active":    MembershipActive,
+		},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	clubs := []*Club{}
+	for _, record := range records {
+		propInterface, ok := record.Get("properties(n)")
+		if ok {
+			props, ok := propInterface.(map[string]interface{})
+			if ok {
+				clubs = append(clubs, ClubFromProps(props))
+			}
+		}
+	}
+	return clubs, nil
+}
+
 //ClubFromProps tries to get struct fields from the neo4j record
 func ClubFromProps(props map[string]interface{}) *Club {
 	if props == nil {
@@ -1,20 +1,65 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexmorten/events-api/calendar"
 	"github.com/alexmorten/events-api/db"
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
 )
 
+const (
+	//SkillLevelBeginner events are for people new to the sport
+	SkillLevelBeginner = "beginner"
+	//SkillLevelIntermediate events are for people who know the basics
+	SkillLevelIntermediate = "intermediate"
+	//SkillLevelAdvanced events are for experienced people
+	SkillLevelAdvanced = "advanced"
+)
+
+//ValidSkillLevel is true for the skill levels events can be meant for
+func ValidSkillLevel(skillLevel string) bool {
+	return skillLevel == SkillLevelBeginner || skillLevel == SkillLevelIntermediate || skillLevel == SkillLevelAdvanced
+}
+
 //EventAttributes ...
 type EventAttributes struct {
-	Name string `json:"name" neo:"name"`
+	Name     string    `json:"name" neo:"name"`
+	StartsAt time.Time `json:"starts_at" neo:"starts_at"`
+	EndsAt   time.Time `json:"ends_at" neo:"ends_at"`
+	TimeZone string    `json:"time_zone" neo:"time_zone"`
+	AllDay   bool      `json:"all_day" neo:"all_day"`
+
+	//Capacity is the number of attendees that can go to the event, 0 means unlimited
+	Capacity int `json:"capacity" neo:"capacity"`
+	//SkillLevel the event is meant for, empty if everyone is welcome
+	SkillLevel string `json:"skill_level" neo:"skill_level"`
+	//PriceCents is what taking part costs in cents, 0 means free
+	PriceCents int `json:"price_cents" neo:"price_cents"`
+
+	//RecurrenceRule is a RFC 5545 RRULE, the event is a recurring series if it is set
+	RecurrenceRule string      `json:"rrule" neo:"rrule"`
+	ExDates        []time.Time `json:"exdates" neo:"exdates"`
+	RDates         []time.Time `json:"rdates" neo:"rdates"`
+
+	//RecurrenceEndsAt is the end of the last occurrence of a finite series, used to query series overlapping a time window
+	RecurrenceEndsAt time.Time `json:"-" neo:"recurrence_ends_at"`
 }
 
 //Event ...
 type Event struct {
 	Model
 	EventAttributes
+
+	//SourceUID is the uid the event had in the calendar or spreadsheet it was imported from
+	SourceUID string `json:"source_uid,omitempty" neo:"source_uid"`
+
+	//RecurrenceID is the original start of an occurrence that was expanded from a recurring event
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
 }
 
 //NewEvent ...
@@ -24,9 +69,9 @@ func NewEvent() *Event {
 	}
 }
 
-//FindEvent with its uid
+//FindEvent with its uid, the error is a db.NotFoundError if there is no event with it
 func FindEvent(dbDriver neo4j.Driver, eventUID string) (*Event, error) {
-	props, err := db.FindNode(dbDriver, eventUID)
+	props, err := db.FindByUID(dbDriver, &Event{}, eventUID)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +96,432 @@ func EventFromProps(props map[string]interface{}) *Event {
 	return event
 }
 
-//CanBeEditedBy user with given uid
+//QueryEvents runs the query and returns the events it returns as properties(n)
+func QueryEvents(dbDriver neo4j.Driver, query string, params map[string]interface{}) ([]*Event, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*Event{}
+	for _, record := range records {
+		propInterface, ok := record.Get("properties(n)")
+		if ok {
+			props, ok := propInterface.(map[string]interface{})
+			if ok {
+				events = append(events, EventFromProps(props))
+			}
+		}
+	}
+	return events, nil
+}
+
+//FindEventsHostedWithin the club or group with the given uid, including events hosted by groups below it.
+//Clubs and groups are matched by label, so that the uid constraints are used as index
+func FindEventsHostedWithin(dbDriver neo4j.Driver, hostUID string) ([]*Event, error) {
+	return QueryEvents(
+		dbDriver,
+		fmt.Sprintf(
+			`
+			match (n:Event)-[:%[1]v]->()-[:%[2]v*0..%[3]d]->(:Club {uid: $uid})
+			return properties(n)
+			union
+			match (n:Event)-[:%[1]v]->()-[:%[2]v*0..%[3]d]->(:Group {uid: $uid})
+			return properties(n)
+			`,
+			EventHostedByGroupOrClub,
+			GroupBelongsToGroupOrClub,
+			MaxGroupDepth,
+		),
+		map[string]interface{}{"uid": hostUID},
+	)
+}
+
+//FindEventsOfUser returns the events the user with the given uid created, responded to without declining
+//and the events hosted within the clubs and groups the user is an active member or admin of
+func FindEventsOfUser(dbDriver neo4j.Driver, userUID string) ([]*Event, error) {
+	return QueryEvents(
+		dbDriver,
+		fmt.Sprintf(
+			`
+			match (n:Event)-[:%[1]v]->(:User {uid: $uid})
+			return properties(n)
+			union
+			match (:User {uid: $uid})-[r:%[2]v]->(n:Event) where r.status <> $declined
+			return properties(n)
+			union
+			match (:User {uid: $uid})-[r:%[3]v|%[4]v]->(host) where type(r) = '%[4]v' or r.status = $active
+			match (n:Event)-[:%[5]v]->()-[:%[6]v*0..10]->(host)
+			return properties(n)
+			`,
+			ModelCreatedByUser, UserAttendsEvent, UserMemberOfGroupOrClub, UserAdministersGroupOrClub,
+			EventHostedByGroupOrClub, GroupBelongsToGroupOrClub,
+		),
+		map[string]interface{}{"uid": userUID, "declined": AttendanceDeclined, "active": MembershipActive},
+	)
+}
+
+//CalendarEvent returns the event as it should be rendered in iCalendar feeds
+func (e *Event) CalendarEvent() calendar.Event {
+	return calendar.Event{
+		UID:          e.UID.String(),
+		Summary:      e.Name,
+		Start:        e.StartsAt,
+		End:          e.EndsAt,
+		AllDay:       e.AllDay,
+		TimeZone:     e.TimeZone,
+		RRule:        e.RecurrenceRule,
+		ExDates:      e.ExDates,
+		RDates:       e.RDates,
+		Created:      e.CreatedAt,
+		LastModified: e.LastModified(),
+	}
+}
+
+//EventAttributesFromCalendar converts an event read from an iCalendar file
+func EventAttributesFromCalendar(calendarEvent calendar.Event) EventAttributes {
+	return EventAttributes{
+		Name:           calendarEvent.Summary,
+		StartsAt:       calendarEvent.Start,
+		EndsAt:         calendarEvent.End,
+		TimeZone:       calendarEvent.TimeZone,
+		AllDay:         calendarEvent.AllDay,
+		RecurrenceRule: strings.TrimPrefix(calendarEvent.RRule, "RRULE:"),
+		ExDates:        calendarEvent.ExDates,
+		RDates:         calendarEvent.RDates,
+	}
+}
+
+//Equal is true if both attributes describe the same event at the same time
+func (a *EventAttributes) Equal(other *EventAttributes) bool {
+	return a.Name == other.Name &&
+		a.StartsAt.Equal(other.StartsAt) &&
+		a.EndsAt.Equal(other.EndsAt) &&
+		a.TimeZone == other.TimeZone &&
+		a.AllDay == other.AllDay &&
+		a.Capacity == other.Capacity &&
+		a.SkillLevel == other.SkillLevel &&
+		a.PriceCents == other.PriceCents &&
+		a.RecurrenceRule == other.RecurrenceRule &&
+		sameTimes(a.ExDates, other.ExDates) &&
+		sameTimes(a.RDates, other.RDates)
+}
+
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+//CanBeEditedBy user with given uid, either the creator of the event or an admin of the club or group hosting it
 func (e *Event) CanBeEditedBy(dbDriver neo4j.Driver, userUID uuid.UUID) bool {
 	relationProps, err := db.FindRelation(dbDriver, e.UID.String(), userUID.String(), "CREATED_BY")
-	return err == nil && relationProps != nil
+	if err == nil && relationProps != nil {
+		return true
+	}
+
+	host, err := e.FindHost(dbDriver)
+	if err != nil || host == nil {
+		return false
+	}
+	return host.AdministeredByUser(dbDriver, userUID)
+}
+
+//FindHost returns the club or group hosting the event, nil if the event isn't hosted
+func (e *Event) FindHost(dbDriver neo4j.Driver) (GroupOrClub, error) {
+	return queryGroupOrClub(
+		dbDriver,
+		fmt.Sprintf("match (e:Event {uid: $uid})-[:%v]->(n) where n:Club or n:Group return properties(n), labels(n)", EventHostedByGroupOrClub),
+		map[string]interface{}{"uid": e.UID.String()},
+	)
+}
+
+//CopyEventHost lets the club or group hosting one event host another event as well and places it at the same venue,
+//used when events are split off a series
+func CopyEventHost(tx *db.Tx, fromEventUID, toEventUID uuid.UUID) error {
+	for _, relation := range []string{EventHostedByGroupOrClub, EventOrClubAtVenue} {
+		_, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf(
+				"match (from:Event {uid: $from_uid})-[:%[1]v]->(host), (to:Event {uid: $to_uid}) merge (to)-[:%[1]v]->(host)",
+				relation,
+			),
+			map[string]interface{}{"from_uid": fromEventUID.String(), "to_uid": toEventUID.String()},
+		))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Location the event takes place in, UTC if no time zone is set
+func (a *EventAttributes) Location() (*time.Location, error) {
+	if a.TimeZone == "" {
+		return time.UTC, nil
+	}
+	if a.TimeZone == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", a.TimeZone)
+	}
+
+	location, err := time.LoadLocation(a.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", a.TimeZone)
+	}
+	return location, nil
+}
+
+//Validate the scheduling attributes of the event
+func (a *EventAttributes) Validate() error {
+	if _, err := a.Location(); err != nil {
+		return err
+	}
+	if a.Capacity < 0 {
+		return errors.New("capacity can't be negative")
+	}
+	if a.SkillLevel != "" && !ValidSkillLevel(a.SkillLevel) {
+		return fmt.Errorf("skill_level has to be one of %v, %v or %v", SkillLevelBeginner, SkillLevelIntermediate, SkillLevelAdvanced)
+	}
+	if a.PriceCents < 0 {
+		return errors.New("price_cents can't be negative")
+	}
+	if !a.EndsAt.IsZero() && a.StartsAt.IsZero() {
+		return errors.New("starts_at is required when ends_at is set")
+	}
+	if !a.EndsAt.IsZero() && !a.EndsAt.After(a.StartsAt) {
+		return errors.New("ends_at has to be after starts_at")
+	}
+	if a.AllDay && a.StartsAt.IsZero() {
+		return errors.New("starts_at is required for all day events")
+	}
+	if a.RecurrenceRule == "" {
+		if len(a.ExDates) > 0 || len(a.RDates) > 0 {
+			return errors.New("exdates and rdates can only be set together with a rrule")
+		}
+		return nil
+	}
+	if a.StartsAt.IsZero() {
+		return errors.New("starts_at is required for recurring events")
+	}
+	if _, err := calendar.ParseRRule(a.RecurrenceRule); err != nil {
+		return err
+	}
+	return nil
+}
+
+//Normalize moves start and end into the event's time zone.
+//All day events start at midnight of their first day and end at midnight after their last day
+func (a *EventAttributes) Normalize() {
+	location, err := a.Location()
+	if err != nil {
+		return
+	}
+
+	if !a.StartsAt.IsZero() {
+		a.StartsAt = a.StartsAt.In(location)
+	}
+	if !a.EndsAt.IsZero() {
+		a.EndsAt = a.EndsAt.In(location)
+	}
+	for i := range a.ExDates {
+		a.ExDates[i] = a.ExDates[i].In(location)
+	}
+	for i := range a.RDates {
+		a.RDates[i] = a.RDates[i].In(location)
+	}
+	defer a.updateRecurrenceEnd()
+
+	if !a.AllDay || a.StartsAt.IsZero() {
+		return
+	}
+
+	a.StartsAt = startOfDay(a.StartsAt)
+	if a.EndsAt.IsZero() || !a.EndsAt.After(a.StartsAt) {
+		a.EndsAt = a.StartsAt.AddDate(0, 0, 1)
+	} else if !a.EndsAt.Equal(startOfDay(a.EndsAt)) {
+		a.EndsAt = startOfDay(a.EndsAt).AddDate(0, 0, 1)
+	}
+}
+
+//IsRecurring is true for events with a recurrence rule
+func (a *EventAttributes) IsRecurring() bool {
+	return a.RecurrenceRule != ""
+}
+
+//Recurrence of the event, nil if the event doesn't recur
+func (a *EventAttributes) Recurrence() (*calendar.Recurrence, error) {
+	if !a.IsRecurring() {
+		return nil, nil
+	}
+	rule, err := calendar.ParseRRule(a.RecurrenceRule)
+	if err != nil {
+		return nil, err
+	}
+	return &calendar.Recurrence{Rule: rule, ExDates: a.ExDates, RDates: a.RDates}, nil
+}
+
+//endFor returns the end of the occurrence starting at start, all day events keep lasting whole days
+func (a *EventAttributes) endFor(start time.Time) time.Time {
+	if a.EndsAt.IsZero() {
+		return time.Time{}
+	}
+	if a.AllDay {
+		days := int(startOfDay(a.EndsAt).Sub(startOfDay(a.StartsAt)).Hours()+12) / 24
+		return start.AddDate(0, 0, days)
+	}
+	return start.Add(a.EndsAt.Sub(a.StartsAt))
+}
+
+func (a *EventAttributes) updateRecurrenceEnd() {
+	a.RecurrenceEndsAt = time.Time{}
+	recurrence, err := a.Recurrence()
+	if err != nil || recurrence == nil {
+		return
+	}
+	last, ok := recurrence.Last(a.StartsAt)
+	if !ok {
+		return
+	}
+	a.RecurrenceEndsAt = last
+	if end := a.endFor(last); !end.IsZero() {
+		a.RecurrenceEndsAt = end
+	}
+}
+
+//Occurrences of the event that overlap [from, to).
+//Events that don't recur are returned as they are if they overlap the window
+func (e *Event) Occurrences(from, to time.Time) ([]*Event, error) {
+	recurrence, err := e.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	if recurrence == nil {
+		return []*Event{e}, nil
+	}
+
+	duration := time.Duration(0)
+	if !e.EndsAt.IsZero() {
+		duration = e.EndsAt.Sub(e.StartsAt)
+	}
+
+	occurrences := []*Event{}
+	for _, start := range recurrence.Between(e.StartsAt, from.Add(-duration), to) {
+		occurrence := e.occurrenceAt(start)
+		if !occurrence.EndsAt.IsZero() && !occurrence.EndsAt.After(from) {
+			continue
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+func (e *Event) occurrenceAt(start time.Time) *Event {
+	occurrence := *e
+	occurrence.EndsAt = e.endFor(start)
+	occurrence.StartsAt = start
+	recurrenceID := start
+	occurrence.RecurrenceID = &recurrenceID
+	return &occurrence
+}
+
+func (e *Event) mustHaveOccurrenceAt(recurrenceID time.Time) (*calendar.Recurrence, error) {
+	recurrence, err := e.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	if recurrence == nil {
+		return nil, errors.New("event is not recurring")
+	}
+	if !recurrence.Includes(e.StartsAt, recurrenceID.In(e.StartsAt.Location())) {
+		return nil, fmt.Errorf("event has no occurrence at %v", recurrenceID.Format(time.RFC3339))
+	}
+	return recurrence, nil
+}
+
+//DetachOccurrence excludes the occurrence starting at recurrenceID from the series
+//and returns a new (not yet saved) single event in its place
+func (e *Event) DetachOccurrence(recurrenceID time.Time) (*Event, error) {
+	if _, err := e.mustHaveOccurrenceAt(recurrenceID); err != nil {
+		return nil, err
+	}
+
+	start := recurrenceID.In(e.StartsAt.Location())
+	detached := NewEvent()
+	detached.EventAttributes = e.EventAttributes
+	detached.StartsAt = start
+	detached.EndsAt = e.endFor(start)
+	detached.RecurrenceRule = ""
+	detached.ExDates = nil
+	detached.RDates = nil
+	detached.RecurrenceEndsAt = time.Time{}
+
+	e.ExDates = append(e.ExDates, start)
+	e.updateRecurrenceEnd()
+	return detached, nil
+}
+
+//SplitAt ends the series before the occurrence starting at recurrenceID
+//and returns a new (not yet saved) series continuing from that occurrence with the remaining occurrences
+func (e *Event) SplitAt(recurrenceID time.Time) (*Event, error) {
+	recurrence, err := e.mustHaveOccurrenceAt(recurrenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := recurrenceID.In(e.StartsAt.Location())
+	if start.Equal(e.StartsAt) {
+		return nil, errors.New("the first occurrence can't be split off, edit all occurrences instead")
+	}
+
+	following := NewEvent()
+	following.EventAttributes = e.EventAttributes
+	following.StartsAt = start
+	following.EndsAt = e.endFor(start)
+	following.ExDates = nil
+	following.RDates = nil
+
+	followingRule := *recurrence.Rule
+	if followingRule.Count > 0 {
+		followingRule.Count -= recurrence.CountBefore(e.StartsAt, start)
+	}
+	following.RecurrenceRule = followingRule.String()
+
+	precedingRule := *recurrence.Rule
+	precedingRule.Count = 0
+	precedingRule.Until = start.Add(-time.Second).UTC()
+	e.RecurrenceRule = precedingRule.String()
+
+	e.ExDates, following.ExDates = splitTimes(e.ExDates, start)
+	e.RDates, following.RDates = splitTimes(e.RDates, start)
+
+	e.updateRecurrenceEnd()
+	following.updateRecurrenceEnd()
+	return following, nil
+}
+
+func splitTimes(times []time.Time, at time.Time) (before, after []time.Time) {
+	for _, t := range times {
+		if t.Before(at) {
+			before = append(before, t)
+		} else {
+			after = append(after, t)
+		}
+	}
+	return
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
 }
@@ -0,0 +1,127 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EventAttributesValidate(t *testing.T) {
+	startsAt := time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+
+	valid := &models.EventAttributes{StartsAt: startsAt, EndsAt: startsAt.Add(time.Hour), TimeZone: "Europe/Berlin"}
+	assert.NoError(t, valid.Validate())
+
+	unscheduled := &models.EventAttributes{Name: "no time yet"}
+	assert.NoError(t, unscheduled.Validate())
+
+	endBeforeStart := &models.EventAttributes{StartsAt: startsAt, EndsAt: startsAt.Add(-time.Hour)}
+	assert.Error(t, endBeforeStart.Validate())
+
+	endWithoutStart := &models.EventAttributes{EndsAt: startsAt}
+	assert.Error(t, endWithoutStart.Validate())
+
+	unknownZone := &models.EventAttributes{StartsAt: startsAt, TimeZone: "Mars/Olympus_Mons"}
+	assert.Error(t, unknownZone.Validate())
+
+	localZone := &models.EventAttributes{StartsAt: startsAt, TimeZone: "Local"}
+	assert.Error(t, localZone.Validate())
+
+	forBeginners := &models.EventAttributes{StartsAt: startsAt, SkillLevel: models.SkillLevelBeginner, PriceCents: 500}
+	assert.NoError(t, forBeginners.Validate())
+
+	unknownSkillLevel := &models.EventAttributes{StartsAt: startsAt, SkillLevel: "expert"}
+	assert.Error(t, unknownSkillLevel.Validate())
+
+	negativePrice := &models.EventAttributes{StartsAt: startsAt, PriceCents: -1}
+	assert.Error(t, negativePrice.Validate())
+}
+
+func Test_EventAttributesNormalize(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	attributes := &models.EventAttributes{
+		StartsAt: time.Date(2019, 4, 2, 16, 0, 0, 0, time.UTC),
+		TimeZone: "Europe/Berlin",
+	}
+	attributes.Normalize()
+	assert.Equal(t, berlin, attributes.StartsAt.Location())
+	assert.Equal(t, 18, attributes.StartsAt.Hour())
+
+	allDay := &models.EventAttributes{
+		StartsAt: time.Date(2019, 4, 2, 16, 0, 0, 0, berlin),
+		EndsAt:   time.Date(2019, 4, 3, 10, 0, 0, 0, berlin),
+		TimeZone: "Europe/Berlin",
+		AllDay:   true,
+	}
+	allDay.Normalize()
+	assert.Equal(t, time.Date(2019, 4, 2, 0, 0, 0, 0, berlin), allDay.StartsAt)
+	assert.Equal(t, time.Date(2019, 4, 4, 0, 0, 0, 0, berlin), allDay.EndsAt)
+
+	singleDay := &models.EventAttributes{
+		StartsAt: time.Date(2019, 4, 2, 16, 0, 0, 0, time.UTC),
+		AllDay:   true,
+	}
+	singleDay.Normalize()
+	assert.Equal(t, time.Date(2019, 4, 3, 0, 0, 0, 0, time.UTC), singleDay.EndsAt)
+}
+
+func weeklyTraining() *models.Event {
+	event := models.NewEvent()
+	event.Name = "training"
+	event.StartsAt = time.Date(2019, 4, 2, 18, 0, 0, 0, time.UTC)
+	event.EndsAt = time.Date(2019, 4, 2, 20, 0, 0, 0, time.UTC)
+	event.RecurrenceRule = "FREQ=WEEKLY;COUNT=4"
+	event.Normalize()
+	return event
+}
+
+func Test_EventOccurrences(t *testing.T) {
+	event := weeklyTraining()
+	assert.Equal(t, time.Date(2019, 4, 23, 20, 0, 0, 0, time.UTC), event.RecurrenceEndsAt)
+
+	occurrences, err := event.Occurrences(time.Date(2019, 4, 9, 19, 0, 0, 0, time.UTC), time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, time.Date(2019, 4, 9, 18, 0, 0, 0, time.UTC), occurrences[0].StartsAt)
+	assert.Equal(t, time.Date(2019, 4, 9, 20, 0, 0, 0, time.UTC), occurrences[0].EndsAt)
+	assert.Equal(t, occurrences[0].StartsAt, *occurrences[0].RecurrenceID)
+	assert.Equal(t, event.UID, occurrences[2].UID)
+}
+
+func Test_EventDetachOccurrence(t *testing.T) {
+	event := weeklyTraining()
+
+	_, err := event.DetachOccurrence(time.Date(2019, 4, 10, 18, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+
+	detached, err := event.DetachOccurrence(time.Date(2019, 4, 9, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.NotEqual(t, event.UID, detached.UID)
+	assert.False(t, detached.IsRecurring())
+	assert.Equal(t, time.Date(2019, 4, 9, 20, 0, 0, 0, time.UTC), detached.EndsAt)
+	assert.Len(t, event.ExDates, 1)
+
+	occurrences, err := event.Occurrences(event.StartsAt, event.RecurrenceEndsAt)
+	require.NoError(t, err)
+	assert.Len(t, occurrences, 3)
+}
+
+func Test_EventSplitAt(t *testing.T) {
+	event := weeklyTraining()
+
+	following, err := event.SplitAt(time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;UNTIL=20190416T175959Z", event.RecurrenceRule)
+	assert.Equal(t, "FREQ=WEEKLY;COUNT=2", following.RecurrenceRule)
+	assert.Equal(t, time.Date(2019, 4, 16, 18, 0, 0, 0, time.UTC), following.StartsAt)
+	assert.Equal(t, time.Date(2019, 4, 9, 20, 0, 0, 0, time.UTC), event.RecurrenceEndsAt)
+	assert.Equal(t, time.Date(2019, 4, 23, 20, 0, 0, 0, time.UTC), following.RecurrenceEndsAt)
+
+	_, err = event.SplitAt(event.StartsAt)
+	assert.Error(t, err)
+}
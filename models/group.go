@@ -26,9 +26,9 @@ func NewGroup() *Group {
 	}
 }
 
-//FindGroup with its uid
+//FindGroup with its uid, the error is a db.NotFoundError if there is no group with it
 func FindGroup(dbDriver neo4j.Driver, GroupUID string) (*Group, error) {
-	props, err := db.FindNode(dbDriver, GroupUID)
+	props, err := db.FindByUID(dbDriver, &Group{}, GroupUID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +74,45 @@ func (g *Group) AdministeredByUser(dbDriver neo4j.Driver, userUID uuid.UUID) boo
 	return len(records) > 0
 }
 
+//FindGroupsOfUser returns the groups the user created, administers or is an active member of
+func FindGroupsOfUser(dbDriver neo4j.Driver, userUID uuid.UUID) ([]*Group, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		`
+		match (u:User {uid: $uid})-[r]-(n:Group)
+		where type(r) in $relations or (type(r) = $member_of and r.status = $active)
+		with distinct n
+		return properties(n) order by n.name
+		`,
+		map[string]interface{}{
+			"uid":       userUID.String(),
+			"relations": []interface{}{string(ModelCreatedByUser), UserAdministersGroupOrClub},
+			"member_of": UserMemberOfGroupOrClub,
+			"active":    MembershipActive,
+		},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*Group{}
+	for _, record := range records {
+		propInterface, ok := record.Get("properties(n)")
+		if ok {
+			props, ok := propInterface.(map[string]interface{})
+			if ok {
+				groups = append(groups, GroupFromProps(props))
+			}
+		}
+	}
+	return groups, nil
+}
+
 //GroupFromProps tries to get struct fields from the neo4j record
 func GroupFromProps(props map[string]interface{}) *Group {
 	if props == nil {
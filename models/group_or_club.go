@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//GroupOrClub is a club or a group, both can host events and have admins and members
+type GroupOrClub interface {
+	NodeName() string
+	GetUID() uuid.UUID
+	GetName() string
+	AdministeredByUser(dbDriver neo4j.Driver, userUID uuid.UUID) bool
+}
+
+//GroupOrClubReference identifies a club or group in responses about something else
+type GroupOrClubReference struct {
+	UID  uuid.UUID `json:"uid"`
+	Type string    `json:"type"`
+	Name string    `json:"name"`
+}
+
+//ReferenceTo the club or group
+func ReferenceTo(groupOrClub GroupOrClub) GroupOrClubReference {
+	return GroupOrClubReference{
+		UID:  groupOrClub.GetUID(),
+		Type: groupOrClub.NodeName(),
+		Name: groupOrClub.GetName(),
+	}
+}
+
+//GetUID of the club
+func (c *Club) GetUID() uuid.UUID {
+	return c.UID
+}
+
+//GetName of the club
+func (c *Club) GetName() string {
+	return c.Name
+}
+
+//GetUID of the group
+func (g *Group) GetUID() uuid.UUID {
+	return g.UID
+}
+
+//GetName of the group
+func (g *Group) GetName() string {
+	return g.Name
+}
+
+//FindGroupOrClub with its uid, nil if there is neither a club nor a group with that uid
+func FindGroupOrClub(dbDriver neo4j.Driver, uid string) (GroupOrClub, error) {
+	return queryGroupOrClub(
+		dbDriver,
+		`
+		match (n:Club {uid: $uid}) return properties(n), labels(n)
+		union
+		match (n:Group {uid: $uid}) return properties(n), labels(n)
+		`,
+		map[string]interface{}{"uid": uid},
+	)
+}
+
+//FindGroupOrClubWithLabel is FindGroupOrClub for uids that have to belong to a node with the label, Club or Group,
+//e.g. the uid of a /clubs/:uid route
+func FindGroupOrClubWithLabel(dbDriver neo4j.Driver, label, uid string) (GroupOrClub, error) {
+	if label != "Club" && label != "Group" {
+		return nil, nil
+	}
+	return queryGroupOrClub(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid}) return properties(n), labels(n)", label),
+		map[string]interface{}{"uid": uid},
+	)
+}
+
+//queryGroupOrClub runs a query returning properties(n) and labels(n) of a single club or group
+func queryGroupOrClub(dbDriver neo4j.Driver, query string, params map[string]interface{}) (GroupOrClub, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return groupOrClubFromRecord(records[0]), nil
+}
+
+func groupOrClubFromRecord(record neo4j.Record) GroupOrClub {
+	propInterface, _ := record.Get("properties(n)")
+	props, ok := propInterface.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	labelsInterface, _ := record.Get("labels(n)")
+	labels, _ := labelsInterface.([]interface{})
+	for _, label := range labels {
+		switch label {
+		case "Club":
+			return ClubFromProps(props)
+		case "Group":
+			return GroupFromProps(props)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+var (
+	//ErrGroupNotFound is returned when one of the groups to restructure doesn't exist
+	ErrGroupNotFound = errors.New("group not found")
+	//ErrGroupCycle is returned when a group would end up below itself
+	ErrGroupCycle = errors.New("a group can't be moved below itself or one of the groups below it")
+	//ErrGroupTooDeep is returned when groups would be nested deeper than MaxGroupDepth
+	ErrGroupTooDeep = fmt.Errorf("groups can't be nested more than %v levels deep", MaxGroupDepth)
+)
+
+//FindClubOf the group, nil if it doesn't belong to a club. The club itself is returned for the uid of a club
+func FindClubOf(dbDriver neo4j.Driver, groupOrClubUID uuid.UUID) (*Club, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			"match (start {uid: $uid})-[:%v*0..]->(n:Club) where start:Group or start:Club return properties(n) limit 1",
+			GroupBelongsToGroupOrClub,
+		),
+		map[string]interface{}{"uid": groupOrClubUID.String()},
+	))
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	props, _ := records[0].GetByIndex(0).(map[string]interface{})
+	return ClubFromProps(props), nil
+}
+
+//MoveGroup below another group or club, taking all groups below it along
+func MoveGroup(dbDriver neo4j.Driver, groupUID, parentUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	_, err = dbSession.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		err := checkGroupCanBeMovedBelow(tx, groupUID, parentUID, 1, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return neo4j.Collect(tx.Run(
+			fmt.Sprintf(
+				`
+				match (g:Group {uid: $uid}), (parent {uid: $parent_uid}) where parent:Group or parent:Club
+				optional match (g)-[r:%[1]v]->()
+				delete r
+				create (g)-[:%[1]v]->(parent)
+				`,
+				GroupBelongsToGroupOrClub,
+			),
+			map[string]interface{}{"uid": groupUID.String(), "parent_uid": parentUID.String()},
+		))
+	})
+	if err != nil {
+		return err
+	}
+	return notifyChangesWithin(dbDriver, groupUID)
+}
+
+//MergeGroups moves the groups below, the admins, members, events, tags and offered sports of the source group to the target group and deletes the source group.
+//The venue of the source group moves as well if the target group has none.
+//Members of both groups keep their membership in the target group, an active membership wins over a request
+func MergeGroups(dbDriver neo4j.Driver, sourceUID, targetUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	sportUIDs := []string{}
+	_, err = dbSession.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		// the groups below the source group end up one level below the target group
+		err := checkGroupCanBeMovedBelow(tx, sourceUID, targetUID, 0, false)
+		if err != nil {
+			return nil, err
+		}
+
+		params := map[string]interface{}{
+			"source_uid": sourceUID.String(),
+			"target_uid": targetUID.String(),
+			"active":     MembershipActive,
+		}
+		queries := []string{
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})<-[r:%v]-(n), (target:Group {uid: $target_uid}) merge (n)-[:%v]->(target) delete r",
+				GroupBelongsToGroupOrClub, GroupBelongsToGroupOrClub,
+			),
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})<-[r:%v]-(n), (target:Group {uid: $target_uid}) merge (n)-[:%v]->(target) delete r",
+				UserAdministersGroupOrClub, UserAdministersGroupOrClub,
+			),
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})<-[r:%v]-(n), (target:Group {uid: $target_uid}) merge (n)-[:%v]->(target) delete r",
+				EventHostedByGroupOrClub, EventHostedByGroupOrClub,
+			),
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})-[r:%v]->(n), (target:Group {uid: $target_uid}) merge (target)-[:%v]->(n) delete r",
+				NodeTaggedWithTag, NodeTaggedWithTag,
+			),
+			fmt.Sprintf(
+				`
+				match (source:Group {uid: $source_uid})<-[r:%[1]v]-(n), (target:Group {uid: $target_uid})
+				merge (n)-[m:%[1]v]->(target)
+				on create set m = properties(r)
+				on match set m += case when m.status <> $active and r.status = $active then properties(r) else {} end
+				delete r
+				`,
+				UserMemberOfGroupOrClub,
+			),
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})-[r:%v]->(v:Venue), (target:Group {uid: $target_uid}) where not (target)-[:%v]->() merge (target)-[:%v]->(v) delete r",
+				EventOrClubAtVenue, EventOrClubAtVenue, EventOrClubAtVenue,
+			),
+			"match (source:Group {uid: $source_uid}) detach delete source",
+		}
+
+		// the popularity of the offered sports changes if both groups offered them
+		records, err := neo4j.Collect(tx.Run(
+			fmt.Sprintf(
+				"match (source:Group {uid: $source_uid})-[r:%v]->(s:Sport), (target:Group {uid: $target_uid}) merge (target)-[:%v]->(s) delete r return s.uid",
+				ClubOrGroupOffersSport, ClubOrGroupOffersSport,
+			),
+			params,
+		))
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if uid, ok := record.GetByIndex(0).(string); ok {
+				sportUIDs = append(sportUIDs, uid)
+			}
+		}
+
+		for _, query := range queries {
+			_, err = neo4j.Collect(tx.Run(query, params))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	db.NotifyChange(db.Change{UID: sourceUID.String(), Label: "Group", Deleted: true})
+	for _, uid := range sportUIDs {
+		db.NotifyChange(db.Change{UID: uid, Label: "Sport"})
+	}
+	return notifyChangesWithin(dbDriver, targetUID)
+}
+
+//notifyChangesWithin the group for the group itself and all groups and events below it, e.g. because the club they belong to changed
+func notifyChangesWithin(dbDriver neo4j.Driver, groupUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			"match (n)-[:%v|%v*0..]->(:Group {uid: $uid}) return distinct n.uid, labels(n)[0]",
+			EventHostedByGroupOrClub, GroupBelongsToGroupOrClub,
+		),
+		map[string]interface{}{"uid": groupUID.String()},
+	))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		uid, _ := record.GetByIndex(0).(string)
+		label, _ := record.GetByIndex(1).(string)
+		db.NotifyChange(db.Change{UID: uid, Label: label})
+	}
+	return nil
+}
+
+//checkGroupCanBeMovedBelow locks both nodes for the rest of the transaction and makes sure
+//the group doesn't end up below itself and its subtree doesn't get nested too deeply.
+//levelsAdded is how many levels the subtree of the group moves below parent, 1 for the group itself
+func checkGroupCanBeMovedBelow(tx neo4j.Transaction, groupUID, parentUID uuid.UUID, levelsAdded int64, parentCanBeClub bool) error {
+	records, err := neo4j.Collect(tx.Run(
+		fmt.Sprintf(
+			`
+			match (g:Group {uid: $uid}), (parent {uid: $parent_uid})
+			where parent:Group or ($parent_can_be_club and parent:Club)
+			set g._move_lock = true, parent._move_lock = true
+			remove g._move_lock, parent._move_lock
+			with g, parent
+			optional match cycle = (parent)-[:%[1]v*0..]->(g)
+			with g, parent, count(cycle) as cycles
+			optional match above = (parent)-[:%[1]v*0..]->()
+			with g, cycles, max(length(above)) as above
+			optional match below = (g)<-[:%[1]v*0..]-()
+			return cycles, above, max(length(below))
+			`,
+			GroupBelongsToGroupOrClub,
+		),
+		map[string]interface{}{"uid": groupUID.String(), "parent_uid": parentUID.String(), "parent_can_be_club": parentCanBeClub},
+	))
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return ErrGroupNotFound
+	}
+
+	cycles, _ := records[0].GetByIndex(0).(int64)
+	if cycles > 0 {
+		return ErrGroupCycle
+	}
+	// the parent is at depth above (a club is at depth 0), the deepest group below g ends up at depth above + levelsAdded + below
+	above, _ := records[0].GetByIndex(1).(int64)
+	below, _ := records[0].GetByIndex(2).(int64)
+	if above+levelsAdded+below > MaxGroupDepth {
+		return ErrGroupTooDeep
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//MaxGroupDepth is how deep groups are followed below clubs and groups, matching the depth admin rights are inherited through
+const MaxGroupDepth = 10
+
+//GroupTreeNode is a group together with the groups below it
+type GroupTreeNode struct {
+	Group
+	Groups []*GroupTreeNode `json:"groups"`
+}
+
+//ClubTree is a club with its nested groups
+type ClubTree struct {
+	Club
+	Groups []*GroupTreeNode `json:"groups"`
+}
+
+//Descendant is a group below another group
+type Descendant struct {
+	Group
+	ParentUID uuid.UUID `json:"parent_uid"`
+	Depth     int64     `json:"depth"`
+}
+
+//FindDescendants of the club or group up to the given depth, ordered by depth and name
+func FindDescendants(dbDriver neo4j.Driver, groupOrClub GroupOrClub, depth int) ([]*Descendant, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			`
+			match p = (n:Group)-[:%v*1..%d]->(:%v {uid: $uid})
+			with n, p order by length(p)
+			with n, collect(p)[0] as p
+			return properties(n), nodes(p)[1].uid, length(p)
+			order by length(p), n.name
+			`,
+			GroupBelongsToGroupOrClub,
+			clampGroupDepth(depth),
+			groupOrClub.NodeName(),
+		),
+		map[string]interface{}{"uid": groupOrClub.GetUID().String()},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	descendants := []*Descendant{}
+	for _, record := range records {
+		props, _ := record.GetByIndex(0).(map[string]interface{})
+		descendant := &Descendant{Group: *GroupFromProps(props)}
+		parentUID, _ := record.GetByIndex(1).(string)
+		descendant.ParentUID, _ = uuid.Parse(parentUID)
+		descendant.Depth, _ = record.GetByIndex(2).(int64)
+		descendants = append(descendants, descendant)
+	}
+	return descendants, nil
+}
+
+//FindClubTree returns the club with its groups nested up to the given depth
+func FindClubTree(dbDriver neo4j.Driver, club *Club, depth int) (*ClubTree, error) {
+	descendants, err := FindDescendants(dbDriver, club, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &ClubTree{Club: *club, Groups: []*GroupTreeNode{}}
+	nodes := map[uuid.UUID]*GroupTreeNode{}
+	// descendants are ordered by depth, so parents are always added before their children
+	for _, descendant := range descendants {
+		node := &GroupTreeNode{Group: descendant.Group, Groups: []*GroupTreeNode{}}
+		nodes[descendant.UID] = node
+		if descendant.ParentUID == club.UID {
+			tree.Groups = append(tree.Groups, node)
+		} else if parent, ok := nodes[descendant.ParentUID]; ok {
+			parent.Groups = append(parent.Groups, node)
+		}
+	}
+	return tree, nil
+}
+
+//FindAncestors of the group, starting with the club or group at the top, e.g. for breadcrumbs
+func FindAncestors(dbDriver neo4j.Driver, groupUID uuid.UUID) ([]GroupOrClubReference, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			`
+			match p = (:Group {uid: $uid})-[:%v*1..%d]->(n)
+			where n:Club or n:Group
+			with n, min(length(p)) as distance
+			return properties(n), labels(n)
+			order by distance desc
+			`,
+			GroupBelongsToGroupOrClub,
+			MaxGroupDepth,
+		),
+		map[string]interface{}{"uid": groupUID.String()},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	ancestors := []GroupOrClubReference{}
+	for _, record := range records {
+		if ancestor := groupOrClubFromRecord(record); ancestor != nil {
+			ancestors = append(ancestors, ReferenceTo(ancestor))
+		}
+	}
+	return ancestors, nil
+}
+
+func clampGroupDepth(depth int) int {
+	if depth < 1 || depth > MaxGroupDepth {
+		return MaxGroupDepth
+	}
+	return depth
+}
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+const (
+	//MembershipRoleMember is the role every member gets by default
+	MembershipRoleMember = "member"
+	//MembershipRoleCoach trains the members
+	MembershipRoleCoach = "coach"
+	//MembershipRoleTreasurer manages the finances
+	MembershipRoleTreasurer = "treasurer"
+	//MembershipRoleAdmin is part of the board
+	MembershipRoleAdmin = "admin"
+)
+
+//MembershipRoles that can be given to members
+var MembershipRoles = []string{MembershipRoleMember, MembershipRoleCoach, MembershipRoleTreasurer, MembershipRoleAdmin}
+
+const (
+	//MembershipRequested memberships wait for an admin to approve them
+	MembershipRequested = "requested"
+	//MembershipActive memberships were approved
+	MembershipActive = "active"
+)
+
+//Membership is stored on the MEMBER_OF relation between a user and a club or group
+type Membership struct {
+	Role        string    `json:"role" neo:"role"`
+	Status      string    `json:"status" neo:"status"`
+	RequestedAt time.Time `json:"requested_at" neo:"requested_at"`
+	JoinedAt    time.Time `json:"joined_at" neo:"joined_at"`
+}
+
+//Member of a club or group
+type Member struct {
+	PublicUserAttributes
+	Membership
+}
+
+//UserMembership is a membership as seen from the user
+type UserMembership struct {
+	GroupOrClubReference
+	Membership
+}
+
+//ValidMembershipRole is true for all MembershipRoles
+func ValidMembershipRole(role string) bool {
+	for _, validRole := range MembershipRoles {
+		if role == validRole {
+			return true
+		}
+	}
+	return false
+}
+
+//RequestMembership of the user in the club or group, returning the existing membership if there already is one
+func RequestMembership(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub) (*Membership, error) {
+	return writeMembership(
+		dbDriver,
+		groupOrClub,
+		fmt.Sprintf(
+			`
+			match (u:User {uid: $user_uid}), (n:%v {uid: $uid})
+			merge (u)-[r:%v]->(n)
+			on create set r.role = $role, r.status = $status, r.requested_at = $now
+			return properties(r)
+			`,
+			groupOrClub.NodeName(),
+			UserMemberOfGroupOrClub,
+		),
+		map[string]interface{}{
+			"user_uid": userUID.String(),
+			"role":     MembershipRoleMember,
+			"status":   MembershipRequested,
+			"now":      db.NeoDateTime(time.Now()),
+		},
+	)
+}
+
+//ApproveMembership request of the user with the given role, nil if there is no open request
+func ApproveMembership(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub, role string) (*Membership, error) {
+	return writeMembership(
+		dbDriver,
+		groupOrClub,
+		fmt.Sprintf(
+			`
+			match (u:User {uid: $user_uid})-[r:%v {status: $requested}]->(n:%v {uid: $uid})
+			set r.status = $active, r.role = $role, r.joined_at = $now
+			return properties(r)
+			`,
+			UserMemberOfGroupOrClub,
+			groupOrClub.NodeName(),
+		),
+		map[string]interface{}{
+			"user_uid":  userUID.String(),
+			"requested": MembershipRequested,
+			"active":    MembershipActive,
+			"role":      role,
+			"now":       db.NeoDateTime(time.Now()),
+		},
+	)
+}
+
+//ChangeMembershipRole of an active member, nil if the user isn't a member
+func ChangeMembershipRole(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub, role string) (*Membership, error) {
+	return writeMembership(
+		dbDriver,
+		groupOrClub,
+		fmt.Sprintf(
+			"match (u:User {uid: $user_uid})-[r:%v {status: $active}]->(n:%v {uid: $uid}) set r.role = $role return properties(r)",
+			UserMemberOfGroupOrClub,
+			groupOrClub.NodeName(),
+		),
+		map[string]interface{}{
+			"user_uid": userUID.String(),
+			"active":   MembershipActive,
+			"role":     role,
+		},
+	)
+}
+
+//RejectMembership request of the user, nil if there is no open request
+func RejectMembership(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub) (*Membership, error) {
+	return deleteMembership(dbDriver, userUID, groupOrClub, MembershipRequested)
+}
+
+//LeaveGroupOrClub ends the membership or withdraws the membership request of the user, nil if there was none
+func LeaveGroupOrClub(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub) (*Membership, error) {
+	return deleteMembership(dbDriver, userUID, groupOrClub, "")
+}
+
+//FindMembers of the club or group with the given membership status, ordered by name.
+//Returns at most limit members after skipping skip of them, together with the total number of members
+func FindMembers(dbDriver neo4j.Driver, groupOrClub GroupOrClub, status string, skip, limit int) ([]*Member, int64, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer dbSession.Close()
+
+	params := map[string]interface{}{"uid": groupOrClub.GetUID().String(), "status": status, "skip": skip, "limit": limit}
+	countRecord, err := neo4j.Single(dbSession.Run(
+		fmt.Sprintf("match (:User)-[r:%v {status: $status}]->(:%v {uid: $uid}) return count(r)", UserMemberOfGroupOrClub, groupOrClub.NodeName()),
+		params,
+	))
+	if err != nil {
+		return nil, 0, err
+	}
+	total, _ := countRecord.GetByIndex(0).(int64)
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			"match (u:User)-[r:%v {status: $status}]->(:%v {uid: $uid}) return properties(u), properties(r) order by u.name, u.email skip $skip limit $limit",
+			UserMemberOfGroupOrClub,
+			groupOrClub.NodeName(),
+		),
+		params,
+	))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	members := []*Member{}
+	for _, record := range records {
+		userProps, _ := record.GetByIndex(0).(map[string]interface{})
+		relationProps, _ := record.GetByIndex(1).(map[string]interface{})
+		member := &Member{PublicUserAttributes: UserFromProps(userProps).PublicAttributes()}
+		db.UnmarshalNeoFields(&member.Membership, relationProps)
+		members = append(members, member)
+	}
+	return members, total, nil
+}
+
+//FindMembershipsOfUser in clubs and groups, including open requests
+func FindMembershipsOfUser(dbDriver neo4j.Driver, userUID uuid.UUID) ([]*UserMembership, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf(
+			"match (:User {uid: $uid})-[r:%v]->(n) where n:Club or n:Group return properties(n), labels(n), properties(r) order by n.name",
+			UserMemberOfGroupOrClub,
+		),
+		map[string]interface{}{"uid": userUID.String()},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := []*UserMembership{}
+	for _, record := range records {
+		groupOrClub := groupOrClubFromRecord(record)
+		if groupOrClub == nil {
+			continue
+		}
+		membership := &UserMembership{GroupOrClubReference: ReferenceTo(groupOrClub)}
+		relationProps, _ := record.GetByIndex(2).(map[string]interface{})
+		db.UnmarshalNeoFields(&membership.Membership, relationProps)
+		memberships = append(memberships, membership)
+	}
+	return memberships, nil
+}
+
+func deleteMembership(dbDriver neo4j.Driver, userUID uuid.UUID, groupOrClub GroupOrClub, status string) (*Membership, error) {
+	return writeMembership(
+		dbDriver,
+		groupOrClub,
+		fmt.Sprintf(
+			`
+			match (u:User {uid: $user_uid})-[r:%v]->(n:%v {uid: $uid})
+			where $status = "" or r.status = $status
+			with r, properties(r) as props
+			delete r
+			return props
+			`,
+			UserMemberOfGroupOrClub,
+			groupOrClub.NodeName(),
+		),
+		map[string]interface{}{
+			"user_uid": userUID.String(),
+			"status":   status,
+		},
+	)
+}
+
+//writeMembership runs the query with the uid of the club or group as $uid and returns the MEMBER_OF relation it returns, nil if nothing matched.
+//The club or group is notified as changed, as its number of members is indexed
+func writeMembership(dbDriver neo4j.Driver, groupOrClub GroupOrClub, query string, params map[string]interface{}) (*Membership, error) {
+	params["uid"] = groupOrClub.GetUID().String()
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	props, ok := records[0].GetByIndex(0).(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	membership := &Membership{}
+	db.UnmarshalNeoFields(membership, props)
+	db.NotifyChange(db.Change{UID: groupOrClub.GetUID().String(), Label: groupOrClub.NodeName()})
+	return membership, nil
+}
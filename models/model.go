@@ -4,9 +4,11 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/alexmorten/events-api/db"
 	"github.com/alexmorten/events-api/utils"
 
 	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
 )
 
 //RelationLabel for neo4j labels
@@ -21,23 +23,60 @@ const (
 
 	//GroupBelongsToGroupOrClub group that belongs to a parent group or club
 	GroupBelongsToGroupOrClub = "BELONGS_TO"
+
+	//EventHostedByGroupOrClub club or group that organizes the event
+	EventHostedByGroupOrClub = "HOSTED_BY"
+
+	//UserMemberOfGroupOrClub the user is a member of or requested to join, role and status are stored on the relation
+	UserMemberOfGroupOrClub = "MEMBER_OF"
+
+	//UserAttendsEvent the user responded to, the response is stored as status on the relation
+	UserAttendsEvent = "ATTENDS"
+
+	//ClubOrGroupOffersSport the sports that can be done in a club or group
+	ClubOrGroupOffersSport = "OFFERS"
+
+	//EventIsSport the sports an event is about
+	EventIsSport = "IS_SPORT"
+
+	//EventOrClubAtVenue where an event takes place or a club is based
+	EventOrClubAtVenue = "AT"
+
+	//NodeTaggedWithTag events, clubs and groups tagged by a tag
+	NodeTaggedWithTag = "TAGGED"
 )
 
 //Model is the base for all models
 type Model struct {
 	UID       uuid.UUID `json:"uid" neo:"uid"`
 	CreatedAt time.Time `json:"created_at" neo:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" neo:"updated_at"`
 	created   bool
 }
 
 func newModel() Model {
+	now := time.Now()
 	return Model{
 		UID:       uuid.New(),
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		created:   true,
 	}
 }
 
+//Touch marks the model as updated now
+func (m *Model) Touch() {
+	m.UpdatedAt = time.Now()
+}
+
+//LastModified is the time of the last update, falling back to the creation for nodes saved before updated_at existed
+func (m *Model) LastModified() time.Time {
+	if m.UpdatedAt.IsZero() {
+		return m.CreatedAt
+	}
+	return m.UpdatedAt
+}
+
 //Created shows if the model was just created
 func (m *Model) Created() bool {
 	return m.created
@@ -56,6 +95,8 @@ func UpdateFrom(obj, updateFrom interface{}) {
 		if field.Kind() == reflect.Ptr {
 			if !field.IsNil() && field.Elem().Type() == objField.Type() && objField.CanSet() {
 				objField.Set(field.Elem())
+			} else if !field.IsNil() && field.Type() == objField.Type() && objField.CanSet() {
+				objField.Set(field)
 			}
 		} else {
 			if field.Type() == objField.Type() && objField.CanSet() {
@@ -64,3 +105,23 @@ func UpdateFrom(obj, updateFrom interface{}) {
 		}
 	})
 }
+
+//writeRelation runs a query that changes a relation from the node with $uid to the node with $to_uid.
+//The query returns the label of the node with $uid, which gets notified about the change
+func writeRelation(dbDriver neo4j.Driver, query string, uid, toUID uuid.UUID) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, map[string]interface{}{"uid": uid.String(), "to_uid": toUID.String()}))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		label, _ := record.GetByIndex(0).(string)
+		db.NotifyChange(db.Change{UID: uid.String(), Label: label})
+	}
+	return nil
+}
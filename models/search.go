@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/alexmorten/events-api/db"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//SearchableLabels are the labels of nodes that can be found through search, by the type name used in the api
+var SearchableLabels = map[string]string{
+	"club":  "Club",
+	"group": "Group",
+	"event": "Event",
+	"sport": "Sport",
+}
+
+//FindSearchables loads the clubs, groups, events and sports with the given uids, keyed by uid.
+//uids of other or deleted nodes are missing in the result
+func FindSearchables(dbDriver neo4j.Driver, uids []string) (map[string]db.Model, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		"match (n) where n.uid in $uids and (n:Club or n:Group or n:Event or n:Sport) return n.uid, properties(n), labels(n)",
+		map[string]interface{}{"uids": uids},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	searchables := map[string]db.Model{}
+	for _, record := range records {
+		uid, _ := record.GetByIndex(0).(string)
+		props, _ := record.GetByIndex(1).(map[string]interface{})
+		labels, _ := record.GetByIndex(2).([]interface{})
+		for _, label := range labels {
+			switch label {
+			case "Club":
+				searchables[uid] = ClubFromProps(props)
+			case "Group":
+				searchables[uid] = GroupFromProps(props)
+			case "Event":
+				searchables[uid] = EventFromProps(props)
+			case "Sport":
+				searchables[uid] = SportFromProps(props)
+			}
+		}
+	}
+	return searchables, nil
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"fmt"
+
 	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
 )
 
@@ -23,9 +26,9 @@ func NewSport() *Sport {
 	}
 }
 
-//FindSport with its uid
+//FindSport with its uid, the error is a db.NotFoundError if there is no sport with it
 func FindSport(dbDriver neo4j.Driver, SportUID string) (*Sport, error) {
-	props, err := db.FindNode(dbDriver, SportUID)
+	props, err := db.FindByUID(dbDriver, &Sport{}, SportUID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,3 +52,65 @@ func SportFromProps(props map[string]interface{}) *Sport {
 	db.UnmarshalNeoFields(sport, props)
 	return sport
 }
+
+//SportRelationOf nodes with the label, events are about sports while clubs and groups offer them
+func SportRelationOf(label string) string {
+	if label == "Event" {
+		return EventIsSport
+	}
+	return ClubOrGroupOffersSport
+}
+
+//FindSportsOf the event, club or group with the label, ordered by name
+func FindSportsOf(dbDriver neo4j.Driver, uid uuid.UUID, label string) ([]*Sport, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf("match (:%v {uid: $uid})-[:%v]->(s:Sport) return properties(s) order by s.name", label, SportRelationOf(label)),
+		map[string]interface{}{"uid": uid.String()},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	sports := []*Sport{}
+	for _, record := range records {
+		props, ok := record.GetByIndex(0).(map[string]interface{})
+		if ok {
+			sports = append(sports, SportFromProps(props))
+		}
+	}
+	return sports, nil
+}
+
+//AddSport to the event, club or group with the label, adding a sport twice doesn't change anything
+func AddSport(dbDriver neo4j.Driver, uid uuid.UUID, label string, sportUID uuid.UUID) error {
+	return writeSportRelation(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid}), (s:Sport {uid: $to_uid}) merge (n)-[:%v]->(s) return labels(n)[0]", label, SportRelationOf(label)),
+		uid, sportUID,
+	)
+}
+
+//RemoveSport from the event, club or group with the label
+func RemoveSport(dbDriver neo4j.Driver, uid uuid.UUID, label string, sportUID uuid.UUID) error {
+	return writeSportRelation(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid})-[r:%v]->(:Sport {uid: $to_uid}) delete r return labels(n)[0]", label, SportRelationOf(label)),
+		uid, sportUID,
+	)
+}
+
+func writeSportRelation(dbDriver neo4j.Driver, query string, uid, sportUID uuid.UUID) error {
+	err := writeRelation(dbDriver, query, uid, sportUID)
+	if err != nil {
+		return err
+	}
+	// the popularity of the sport changed as well
+	db.NotifyChange(db.Change{UID: sportUID.String(), Label: "Sport"})
+	return nil
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//maxTagNameLength in letters
+const maxTagNameLength = 50
+
+//ErrTagNameTaken is returned when a tag with the same normalized name already exists
+var ErrTagNameTaken = errors.New("a tag with this name already exists")
+
+//TagAttributes ...
+type TagAttributes struct {
+	Name string `json:"name" neo:"name"`
+}
+
+//Tag labels events, clubs and groups, tag names are unique after normalization
+type Tag struct {
+	Model
+	TagAttributes
+}
+
+//NewTag ...
+func NewTag() *Tag {
+	return &Tag{
+		Model: newModel(),
+	}
+}
+
+//FindTag with its uid, the error is a db.NotFoundError if there is no tag with it
+func FindTag(dbDriver neo4j.Driver, tagUID string) (*Tag, error) {
+	props, err := db.FindByUID(dbDriver, &Tag{}, tagUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return TagFromProps(props), nil
+}
+
+//FindTagsOf the event, club or group with the label, ordered by name
+func FindTagsOf(dbDriver neo4j.Driver, uid uuid.UUID, label string) ([]*Tag, error) {
+	return queryTags(
+		dbDriver,
+		fmt.Sprintf("match (:%v {uid: $uid})-[:%v]->(t:Tag) return properties(t) order by t.name", label, NodeTaggedWithTag),
+		map[string]interface{}{"uid": uid.String()},
+	)
+}
+
+func queryTags(dbDriver neo4j.Driver, query string, params map[string]interface{}) ([]*Tag, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*Tag{}
+	for _, record := range records {
+		props, ok := record.GetByIndex(0).(map[string]interface{})
+		if ok {
+			tags = append(tags, TagFromProps(props))
+		}
+	}
+	return tags, nil
+}
+
+//NodeName is the label of tag-nodes in the database
+func (t *Tag) NodeName() string {
+	return "Tag"
+}
+
+//TagFromProps tries to get struct fields from the neo4j record
+func TagFromProps(props map[string]interface{}) *Tag {
+	if props == nil {
+		return nil
+	}
+
+	tag := &Tag{}
+
+	db.UnmarshalNeoFields(tag, props)
+	return tag
+}
+
+//NormalizeTagName lowercases the name and collapses whitespace, so "Beach  Volleyball" and "beach volleyball" are the same tag
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+//Normalize the name of the tag
+func (a *TagAttributes) Normalize() {
+	a.Name = NormalizeTagName(a.Name)
+}
+
+//Validate the normalized name
+func (a *TagAttributes) Validate() error {
+	if a.Name == "" {
+		return errors.New("name can't be empty")
+	}
+	if len([]rune(a.Name)) > maxTagNameLength {
+		return fmt.Errorf("name can't be longer than %v letters", maxTagNameLength)
+	}
+	if strings.Contains(a.Name, ",") {
+		return errors.New("name can't contain commas")
+	}
+	return nil
+}
+
+//AddTag to the event, club or group with the label, adding a tag twice doesn't change anything
+func AddTag(dbDriver neo4j.Driver, uid uuid.UUID, label string, tagUID uuid.UUID) error {
+	return writeRelation(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid}), (t:Tag {uid: $to_uid}) merge (n)-[:%v]->(t) return labels(n)[0]", label, NodeTaggedWithTag),
+		uid, tagUID,
+	)
+}
+
+//RemoveTag from the event, club or group with the label
+func RemoveTag(dbDriver neo4j.Driver, uid uuid.UUID, label string, tagUID uuid.UUID) error {
+	return writeRelation(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid})-[r:%v]->(:Tag {uid: $to_uid}) delete r return labels(n)[0]", label, NodeTaggedWithTag),
+		uid, tagUID,
+	)
+}
@@ -0,0 +1,31 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NormalizeTagName(t *testing.T) {
+	assert.Equal(t, "beach volleyball", models.NormalizeTagName("  Beach \t Volleyball "))
+	assert.Equal(t, "über 40", models.NormalizeTagName("ÜBER 40"))
+	assert.Equal(t, "", models.NormalizeTagName("   "))
+}
+
+func Test_TagAttributesValidate(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"  Youth  Training ":    true,
+		"":                      false,
+		" ":                     false,
+		"indoor, outdoor":       false,
+		strings.Repeat("a", 50): true,
+		strings.Repeat("ä", 50): true,
+		strings.Repeat("a", 51): false,
+	} {
+		attributes := &models.TagAttributes{Name: name}
+		attributes.Normalize()
+		assert.Equal(t, valid, attributes.Validate() == nil, name)
+	}
+}
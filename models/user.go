@@ -28,6 +28,9 @@ type User struct {
 	UserID      string `json:"user_id" neo:"user_id"`
 	AvatarURL   string `json:"avatar_url" neo:"avatar_url"`
 	Location    string `json:"location" neo:"location"`
+
+	//CalendarFeedKey is part of the signature of the calendar feed token, changing it revokes the feed urls handed out before
+	CalendarFeedKey string `json:"-" neo:"calendar_feed_key"`
 }
 
 //PublicUserAttributes that can be shared with the users
@@ -62,9 +65,9 @@ func FindOrCreateUserByEmail(dbDriver neo4j.Driver, email string) (*User, error)
 	return createdUser, nil
 }
 
-//FindUser with its uid
+//FindUser with its uid, the error is a db.NotFoundError if there is no user with it
 func FindUser(dbDriver neo4j.Driver, UserUID string) (*User, error) {
-	props, err := db.FindNode(dbDriver, UserUID)
+	props, err := db.FindByUID(dbDriver, &User{}, UserUID)
 	if err != nil {
 		return nil, err
 	}
@@ -78,6 +81,7 @@ func FindUserByEmail(dbDriver neo4j.Driver, email string) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer session.Close()
 	result, err := session.Run("match (n:User {email: $email}) return properties(n)", map[string]interface{}{"email": email})
 	if err != nil {
 		return nil, err
@@ -113,18 +117,25 @@ func (u *User) NodeName() string {
 	return "User"
 }
 
-//UpdateFromGothUser updates the user from the provided goth.User
+//UpdateFromGothUser updates the user from the provided goth.User.
+//Profile fields users can edit themselves are only taken from the provider while they are empty
 func (u *User) UpdateFromGothUser(gothUser goth.User) {
 	u.Provider = gothUser.Provider
 	u.Email = gothUser.Email
-	u.Name = gothUser.Name
 	u.FirstName = gothUser.FirstName
 	u.LastName = gothUser.LastName
-	u.NickName = gothUser.NickName
-	u.Description = gothUser.Description
 	u.UserID = gothUser.UserID
-	u.AvatarURL = gothUser.AvatarURL
-	u.Location = gothUser.Location
+
+	setIfEmpty := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	setIfEmpty(&u.Name, gothUser.Name)
+	setIfEmpty(&u.NickName, gothUser.NickName)
+	setIfEmpty(&u.Description, gothUser.Description)
+	setIfEmpty(&u.AvatarURL, gothUser.AvatarURL)
+	setIfEmpty(&u.Location, gothUser.Location)
 }
 
 //PublicAttributes of user
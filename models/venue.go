@@ -0,0 +1,183 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//VenueAttributes ...
+type VenueAttributes struct {
+	Name     string    `json:"name" neo:"name"`
+	Address  string    `json:"address" neo:"address"`
+	Location *db.Point `json:"location" neo:"location"`
+}
+
+//Venue is a place where events take place and clubs are based
+type Venue struct {
+	Model
+	VenueAttributes
+}
+
+//NewVenue ...
+func NewVenue() *Venue {
+	return &Venue{
+		Model: newModel(),
+	}
+}
+
+//FindVenue with its uid, the error is a db.NotFoundError if there is no venue with it
+func FindVenue(dbDriver neo4j.Driver, venueUID string) (*Venue, error) {
+	props, err := db.FindByUID(dbDriver, &Venue{}, venueUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return VenueFromProps(props), nil
+}
+
+//NodeName is the label of venue-nodes in the database
+func (v *Venue) NodeName() string {
+	return "Venue"
+}
+
+//VenueFromProps tries to get struct fields from the neo4j record
+func VenueFromProps(props map[string]interface{}) *Venue {
+	if props == nil {
+		return nil
+	}
+
+	venue := &Venue{}
+
+	db.UnmarshalNeoFields(venue, props)
+	return venue
+}
+
+//Validate that the venue has a name and a location on earth
+func (a *VenueAttributes) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return errors.New("name can't be empty")
+	}
+	if a.Location == nil {
+		return errors.New("location can't be empty")
+	}
+	return a.Location.Validate()
+}
+
+//CanBeEditedBy the user who created the venue and admins
+func (v *Venue) CanBeEditedBy(dbDriver neo4j.Driver, userUID uuid.UUID) bool {
+	user, err := FindUser(dbDriver, userUID.String())
+	if err != nil || user == nil {
+		return false
+	}
+	if user.Admin {
+		return true
+	}
+
+	relationProps, err := db.FindRelation(dbDriver, v.UID.String(), userUID.String(), string(ModelCreatedByUser))
+	return err == nil && relationProps != nil
+}
+
+//FindVenueOf the event or club with the label, nil if it has none
+func FindVenueOf(dbDriver neo4j.Driver, uid uuid.UUID, label string) (*Venue, error) {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		fmt.Sprintf("match (:%v {uid: $uid})-[:%v]->(v:Venue) return properties(v)", label, EventOrClubAtVenue),
+		map[string]interface{}{"uid": uid.String()},
+	))
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	props, _ := records[0].GetByIndex(0).(map[string]interface{})
+	return VenueFromProps(props), nil
+}
+
+//SetVenue of the event or club with the label, replacing the venue it was at before
+func SetVenue(dbDriver neo4j.Driver, uid uuid.UUID, label string, venueUID uuid.UUID) error {
+	return writeVenue(
+		dbDriver,
+		fmt.Sprintf(
+			`
+			match (n:%[2]v {uid: $uid}), (v:Venue {uid: $venue_uid})
+			optional match (n)-[previous:%[1]v]->()
+			delete previous
+			merge (n)-[:%[1]v]->(v)
+			return labels(n)[0]
+			`,
+			EventOrClubAtVenue,
+			label,
+		),
+		map[string]interface{}{"uid": uid.String(), "venue_uid": venueUID.String()},
+	)
+}
+
+//RemoveVenue of the event or club with the label
+func RemoveVenue(dbDriver neo4j.Driver, uid uuid.UUID, label string) error {
+	return writeVenue(
+		dbDriver,
+		fmt.Sprintf("match (n:%v {uid: $uid}) optional match (n)-[r:%v]->() delete r return labels(n)[0]", label, EventOrClubAtVenue),
+		map[string]interface{}{"uid": uid.String()},
+	)
+}
+
+func writeVenue(dbDriver neo4j.Driver, query string, params map[string]interface{}) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(query, params))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		label, _ := record.GetByIndex(0).(string)
+		db.NotifyChange(db.Change{UID: params["uid"].(string), Label: label})
+	}
+	return nil
+}
+
+//DeleteVenue and tell the listeners that the events and clubs at it lost their location once it is deleted
+func DeleteVenue(dbDriver neo4j.Driver, venueUID uuid.UUID) error {
+	return db.Transact(dbDriver, func(tx *db.Tx) error {
+		// they can't be found after the venue is deleted, the changes are delivered after the commit
+		err := notifyChangesAtVenue(tx, venueUID)
+		if err != nil {
+			return err
+		}
+		return tx.DeleteNode(&Venue{}, venueUID.String())
+	})
+}
+
+//NotifyChangesAtVenue for the events and clubs at the venue, e.g. because its location changed
+func NotifyChangesAtVenue(dbDriver neo4j.Driver, venueUID uuid.UUID) error {
+	return db.ReadTransact(dbDriver, func(tx *db.Tx) error {
+		return notifyChangesAtVenue(tx, venueUID)
+	})
+}
+
+func notifyChangesAtVenue(tx *db.Tx, venueUID uuid.UUID) error {
+	records, err := neo4j.Collect(tx.Run(
+		fmt.Sprintf("match (n)-[:%v]->(:Venue {uid: $uid}) return n.uid, labels(n)[0]", EventOrClubAtVenue),
+		map[string]interface{}{"uid": venueUID.String()},
+	))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		uid, _ := record.GetByIndex(0).(string)
+		label, _ := record.GetByIndex(1).(string)
+		tx.NotifyChange(db.Change{UID: uid, Label: label})
+	}
+	return nil
+}
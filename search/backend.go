@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+const (
+	//BackendElasticsearch keeps the documents in elasticsearch, it is used if no backend is configured
+	BackendElasticsearch = "elasticsearch"
+	//BackendMemory keeps the documents in the memory of the server, useful for development and tests
+	BackendMemory = "memory"
+)
+
+//Backend stores the documents of nodes and answers search queries on them
+type Backend interface {
+	//EnsureIndex prepares the backend to store documents
+	EnsureIndex(ctx context.Context) error
+	//WriteDocuments stores the documents and removes the documents of deletedUIDs.
+	//failedUIDs are the uids of single writes that failed, err is set if the whole write failed
+	WriteDocuments(ctx context.Context, documents []Document, deletedUIDs []string) (failedUIDs []string, err error)
+	//SearchNodes fuzzily matches the name of nodes, highlighting the matching parts of the name with <em> tags
+	SearchNodes(ctx context.Context, query Query) (*Result, error)
+	//Suggest nodes for a prefix typed by a user, more popular nodes first
+	Suggest(ctx context.Context, prefix string, types []string, size int) ([]SuggestedNode, error)
+	//SearchEvents finds the events matching the term and filters and counts their sports and clubs
+	SearchEvents(ctx context.Context, query EventQuery) (*EventResult, error)
+}
+
+//VersionedBackend can build a new version of its index next to the one that is searched and switch to it, which is how Reindexer rebuilds it
+type VersionedBackend interface {
+	Backend
+	//CreateVersion of the index, the name has to be new
+	CreateVersion(ctx context.Context, version string) error
+	//WriteVersion writes the documents into a version that isn't searched yet
+	WriteVersion(ctx context.Context, version string, documents []Document) (failedUIDs []string, err error)
+	//SwitchVersion makes the version the one that is searched and written to, returning the versions that were before
+	SwitchVersion(ctx context.Context, version string) (previous []string, err error)
+	//DeleteVersions that aren't used anymore
+	DeleteVersions(ctx context.Context, versions []string) error
+	//IndexedDocuments of the searched version as they are stored, keyed by uid
+	IndexedDocuments(ctx context.Context, batchSize int) (map[string]map[string]interface{}, error)
+}
+
+var _ VersionedBackend = &Client{}
+var _ VersionedBackend = &MemoryBackend{}
+
+//IndexAll writes the documents of all searchable nodes in neo4j to the backend, returning how many were written
+func IndexAll(ctx context.Context, backend Backend, dbDriver neo4j.Driver, batchSize int) (int, error) {
+	err := backend.EnsureIndex(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	load := NeoDocumentLoader(dbDriver)
+	written := 0
+	err = forEachUIDBatch(dbDriver, batchSize, func(uids []string) error {
+		loaded, err := load(uids)
+		if err != nil {
+			return err
+		}
+		documents := []Document{}
+		for _, document := range loaded {
+			documents = append(documents, document)
+		}
+		failedUIDs, err := backend.WriteDocuments(ctx, documents, nil)
+		if err != nil {
+			return err
+		}
+		if len(failedUIDs) > 0 {
+			return fmt.Errorf("writing %d documents failed, e.g. %v", len(failedUIDs), failedUIDs[0])
+		}
+		written += len(documents)
+		return nil
+	})
+	return written, err
+}
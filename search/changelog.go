@@ -0,0 +1,110 @@
+package search
+
+import (
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//ChangeLog records the uids the indexer writes while a reindex runs, so that the reindex can write them again
+//after it switched to the new version. It also sees deletes and changes of relations, which leave no trace on the nodes
+type ChangeLog interface {
+	//Start recording for a new run
+	Start() (run string, err error)
+	//Record uids that changed, for every run that was started and not stopped yet
+	Record(uids []string) error
+	//Changes recorded for the run so far
+	Changes(run string) ([]string, error)
+	//Stop recording for the run and forget its changes
+	Stop(run string) error
+}
+
+//NeoChangeLog stores runs as (:ReindexRun) and their changes as (:ReindexChange) nodes in neo4j,
+//the indexer of every server records into it while the reindex command runs in its own process
+type NeoChangeLog struct {
+	dbDriver neo4j.Driver
+}
+
+//NewNeoChangeLog ...
+func NewNeoChangeLog(dbDriver neo4j.Driver) *NeoChangeLog {
+	return &NeoChangeLog{dbDriver: dbDriver}
+}
+
+//Start recording for a new run, runs older than a day are from reindexes that crashed and are removed
+func (l *NeoChangeLog) Start() (string, error) {
+	run := uuid.New().String()
+	err := l.run(
+		`
+		optional match (stale:ReindexRun) where stale.started_at < datetime() - duration({days: 1})
+		optional match (change:ReindexChange) where change.run = stale.id
+		detach delete stale, change
+		with count(*) as cleaned
+		create (:ReindexRun {id: $run, started_at: datetime()})
+		`,
+		map[string]interface{}{"run": run},
+	)
+	return run, err
+}
+
+//Record uids that changed for every running reindex, it does nothing while none runs
+func (l *NeoChangeLog) Record(uids []string) error {
+	return l.run(
+		`
+		match (r:ReindexRun)
+		unwind $uids as uid
+		merge (:ReindexChange {run: r.id, node_uid: uid})
+		`,
+		map[string]interface{}{"uids": uids},
+	)
+}
+
+//Changes recorded for the run so far
+func (l *NeoChangeLog) Changes(run string) ([]string, error) {
+	dbSession, err := l.dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		"match (c:ReindexChange {run: $run}) return c.node_uid",
+		map[string]interface{}{"run": run},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	uids := []string{}
+	for _, record := range records {
+		if uid, ok := record.GetByIndex(0).(string); ok {
+			uids = append(uids, uid)
+		}
+	}
+	return uids, nil
+}
+
+//Stop recording for the run and forget its changes
+func (l *NeoChangeLog) Stop(run string) error {
+	return l.run(
+		`
+		match (r:ReindexRun {id: $run})
+		optional match (c:ReindexChange {run: $run})
+		detach delete r, c
+		`,
+		map[string]interface{}{"run": run},
+	)
+}
+
+func (l *NeoChangeLog) run(query string, params map[string]interface{}) error {
+	dbSession, err := l.dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	result, err := dbSession.Run(query, params)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
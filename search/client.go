@@ -3,19 +3,58 @@ package search
 import (
 	"context"
 	"fmt"
-	"reflect"
+	"sync"
 
 	"github.com/olivere/elastic"
 )
 
-const nodeIndexName = "neo4j-index-node"
+//nodeAlias points to the current version of the index, all reads and writes go through it
+const nodeAlias = "nodes"
+
+//UnavailableError is returned when elasticsearch can't be reached
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("search is unavailable: %v", e.Err)
+}
+
+//IsUnavailable checks if the error is an UnavailableError
+func IsUnavailable(err error) bool {
+	_, ok := err.(*UnavailableError)
+	return ok
+}
 
 //Client wraps the elasticsearch client for ease of use
 type Client struct {
 	address string
+	mutex   sync.Mutex
 	*elastic.Client
 }
 
+//Query for nodes by name
+type Query struct {
+	Term string
+	//Labels the nodes may have, all nodes are searched if empty
+	Labels []string
+	From   int
+	Size   int
+}
+
+//Hit is a node matching a query
+type Hit struct {
+	UID        string
+	Score      float64
+	Highlights []string
+}
+
+//Result of a query, Total counts all hits, not only the ones returned
+type Result struct {
+	Total int64
+	Hits  []Hit
+}
+
 //NewClient connects to elasticsearch and returns a handler on success
 // error will be returned if no connection could be eastablished
 func NewClient(address string, lazy bool) (*Client, error) {
@@ -25,52 +64,77 @@ func NewClient(address string, lazy bool) (*Client, error) {
 	if lazy {
 		return client, nil
 	}
-	return client, client.ensureConnectionExists()
+	_, err := client.ensureConnectionExists()
+	return client, err
 }
 
-//FuzzyNameSearch through nodes with given label
-func (c *Client) FuzzyNameSearch(label, searchTerm string, iterator func(props map[string]interface{})) error {
-	if c.Client == nil {
-		err := c.ensureConnectionExists()
-		if err != nil {
-			return err
-		}
+//SearchNodes fuzzily matches the name of nodes, highlighting the matching parts of the name with <em> tags
+func (c *Client) SearchNodes(ctx context.Context, query Query) (*Result, error) {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
 	}
 
-	query := elastic.NewBoolQuery().Should(
-		elastic.NewMatchQuery("labels", label),
-		elastic.NewFuzzyQuery("name", searchTerm),
+	boolQuery := elastic.NewBoolQuery().Must(
+		elastic.NewMatchQuery("name", query.Term).Fuzziness("AUTO"),
 	)
+	if len(query.Labels) > 0 {
+		labelQuery := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+		for _, label := range query.Labels {
+			labelQuery.Should(elastic.NewMatchQuery("labels", label))
+		}
+		boolQuery.Filter(labelQuery)
+	}
 
-	searchResult, err := c.Search().Index(nodeIndexName).Query(query).Do(context.Background())
+	searchResult, err := client.Search().
+		Index(nodeAlias).
+		Query(boolQuery).
+		Highlight(elastic.NewHighlight().Field("name")).
+		FetchSource(false).
+		From(query.From).
+		Size(query.Size).
+		Do(ctx)
 	if err != nil {
-		return err
+		return nil, wrapUnavailable(err)
 	}
 
-	// searchResult is of type SearchResult and returns hits, suggestions,
-	// and all kinds of other information from Elasticsearch.
-	fmt.Printf("Query took %d milliseconds\n", searchResult.TookInMillis)
-
-	// Each is a utility function that iterates over hits in a search result.
-	// It makes sure you don't need to check for nil values in the response.
-	// However, it ignores errors in serialization. If you want full control
-	// over iterating the hits, see below.
-	var ttyp map[string]interface{}
-	for _, item := range searchResult.Each(reflect.TypeOf(ttyp)) {
-		props := item.(map[string]interface{})
-		iterator(props)
+	result := &Result{Hits: []Hit{}}
+	if searchResult.Hits == nil {
+		return result, nil
 	}
-
-	return nil
+	result.Total = searchResult.Hits.TotalHits
+	for _, searchHit := range searchResult.Hits.Hits {
+		hit := Hit{UID: searchHit.Id, Highlights: searchHit.Highlight["name"]}
+		if searchHit.Score != nil {
+			hit.Score = *searchHit.Score
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
 }
 
-func (c *Client) ensureConnectionExists() error {
+func (c *Client) ensureConnectionExists() (*elastic.Client, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.Client != nil {
+		return c.Client, nil
+	}
+
 	client, err := elastic.NewClient(
 		elastic.SetURL(c.address),
 		elastic.SetSniff(false),
 	)
-	if err == nil {
-		c.Client = client
+	if err != nil {
+		return nil, &UnavailableError{Err: err}
+	}
+	c.Client = client
+	return client, nil
+}
+
+//wrapUnavailable marks errors that happen because elasticsearch can't be reached
+func wrapUnavailable(err error) error {
+	if elastic.IsConnErr(err) || elastic.IsTimeout(err) {
+		return &UnavailableError{Err: err}
 	}
 	return err
 }
@@ -0,0 +1,57 @@
+package search_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexmorten/events-api/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SearchNodes(t *testing.T) {
+	var requestBody map[string]interface{}
+	elastic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes/_search" {
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits": {"total": 12, "max_score": 2.5, "hits": [
+			{"_id": "club-uid", "_score": 2.5, "highlight": {"name": ["<em>Rowing</em> Club"]}},
+			{"_id": "group-uid", "_score": 1.5}
+		]}}`))
+	}))
+	defer elastic.Close()
+
+	client, err := search.NewClient(elastic.URL, true)
+	require.NoError(t, err)
+	result, err := client.SearchNodes(context.Background(), search.Query{Term: "rowing", Labels: []string{"Club"}, From: 10, Size: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(12), result.Total)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, search.Hit{UID: "club-uid", Score: 2.5, Highlights: []string{"<em>Rowing</em> Club"}}, result.Hits[0])
+	assert.Equal(t, "group-uid", result.Hits[1].UID)
+	assert.Empty(t, result.Hits[1].Highlights)
+
+	assert.Equal(t, float64(10), requestBody["from"])
+	assert.Equal(t, float64(2), requestBody["size"])
+	assert.Contains(t, requestBody, "highlight")
+}
+
+func Test_SearchNodesWithoutElasticsearch(t *testing.T) {
+	elastic := httptest.NewServer(http.NotFoundHandler())
+	address := elastic.URL
+	elastic.Close()
+
+	client, err := search.NewClient(address, true)
+	require.NoError(t, err)
+	_, err = client.SearchNodes(context.Background(), search.Query{Term: "rowing", Size: 10})
+	assert.True(t, search.IsUnavailable(err))
+}
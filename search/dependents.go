@@ -0,0 +1,99 @@
+package search
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//DependentsLoader returns the uids of the nodes whose documents include something of the nodes with the given uids
+type DependentsLoader func(uids []string) ([]string, error)
+
+//NeoDependentsLoader loads the dependents of clubs, groups and sports from neo4j, see FindDependents
+func NeoDependentsLoader(dbDriver neo4j.Driver) DependentsLoader {
+	return func(uids []string) (dependents []string, err error) {
+		err = db.ReadTransact(dbDriver, func(tx *db.Tx) error {
+			changes, err := FindDependents(tx, uids)
+			if err != nil {
+				return err
+			}
+			dependents = []string{}
+			for _, change := range changes {
+				dependents = append(dependents, change.UID)
+			}
+			return nil
+		})
+		return dependents, err
+	}
+}
+
+//FindDependents of the clubs, groups and sports with the given uids, which are the nodes their names are indexed with:
+//the groups and events below a club or group and the clubs, groups and events of a sport
+func FindDependents(tx *db.Tx, uids []string) ([]db.Change, error) {
+	records, err := neo4j.Collect(tx.Run(
+		fmt.Sprintf(`
+		match (n) where n.uid in $uids and (n:Club or n:Group or n:Sport)
+		optional match (below)-[:%[1]v|%[2]v*1..%[3]d]->(n)
+		with n, collect(below) as below
+		optional match (of_sport)-[:%[4]v|%[5]v]->(n)
+		with below + collect(of_sport) as dependents
+		unwind dependents as dependent
+		return distinct dependent.uid, labels(dependent)[0]
+		`,
+			models.EventHostedByGroupOrClub, models.GroupBelongsToGroupOrClub, models.MaxGroupDepth+1,
+			models.EventIsSport, models.ClubOrGroupOffersSport,
+		),
+		map[string]interface{}{"uids": uids},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []db.Change{}
+	for _, record := range records {
+		uid, _ := record.GetByIndex(0).(string)
+		label, _ := record.GetByIndex(1).(string)
+		if uid != "" {
+			changes = append(changes, db.Change{UID: uid, Label: label})
+		}
+	}
+	return changes, nil
+}
+
+//NotifyDependents tells the listeners of the transaction that the dependents of the node changed,
+//nodes that are deleted have to do so before they are deleted because their dependents can't be found afterwards
+func NotifyDependents(tx *db.Tx, uid string) error {
+	changes, err := FindDependents(tx, []string{uid})
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		tx.NotifyChange(change)
+	}
+	return nil
+}
+
+//withDependents adds the dependents of the uids to them, the uids are indexed anyway if their dependents can't be loaded
+func (i *Indexer) withDependents(uids []string) []string {
+	if i.Dependents == nil {
+		return uids
+	}
+	dependents, err := i.Dependents(uids)
+	if err != nil {
+		log.Printf("search indexer can't load the dependents of %d nodes: %v", len(uids), err)
+		return uids
+	}
+
+	seen := map[string]bool{}
+	withDependents := []string{}
+	for _, uid := range append(append([]string{}, uids...), dependents...) {
+		if !seen[uid] {
+			seen[uid] = true
+			withDependents = append(withDependents, uid)
+		}
+	}
+	return withDependents
+}
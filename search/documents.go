@@ -0,0 +1,265 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//Document is what gets written to the index for a node
+type Document interface {
+	DocumentUID() string
+	base() *BaseDocument
+}
+
+//BaseDocument holds what is indexed for every node
+type BaseDocument struct {
+	UID    string   `json:"uid"`
+	Type   string   `json:"type"`
+	Labels []string `json:"labels"`
+	Name   string   `json:"name"`
+	//Popularity is the number of members of clubs and groups, attendees of events and clubs, groups and events of sports
+	Popularity int64      `json:"popularity"`
+	Suggest    Suggestion `json:"suggest"`
+}
+
+//Suggestion is the input of the completion suggester, more popular nodes are suggested first
+type Suggestion struct {
+	Input  []string `json:"input"`
+	Weight int64    `json:"weight"`
+}
+
+//DocumentUID is the id of the document in the index
+func (d *BaseDocument) DocumentUID() string {
+	return d.UID
+}
+
+func (d *BaseDocument) base() *BaseDocument {
+	return d
+}
+
+//ClubDocument is indexed for clubs
+type ClubDocument struct {
+	BaseDocument
+	Sports []string `json:"sports"`
+}
+
+//GroupDocument is indexed for groups
+type GroupDocument struct {
+	BaseDocument
+	ParentName string   `json:"parent_name"`
+	ClubUID    string   `json:"club_uid,omitempty"`
+	ClubName   string   `json:"club_name,omitempty"`
+	Sports     []string `json:"sports"`
+}
+
+//EventDocument is indexed for events
+type EventDocument struct {
+	BaseDocument
+	HostName   string     `json:"host_name,omitempty"`
+	ClubUID    string     `json:"club_uid,omitempty"`
+	ClubName   string     `json:"club_name,omitempty"`
+	Sports     []string   `json:"sports"`
+	SportUIDs  []string   `json:"sport_uids"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	AllDay     bool       `json:"all_day"`
+	SkillLevel string     `json:"skill_level,omitempty"`
+	PriceCents int        `json:"price_cents"`
+	Location   *GeoPoint  `json:"location,omitempty"`
+
+	//Recurring events take place until LastEndsAt, forever if it isn't set
+	Recurring  bool       `json:"recurring"`
+	LastEndsAt *time.Time `json:"last_ends_at,omitempty"`
+}
+
+//GeoPoint is a position on earth
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+//SportDocument is indexed for sports
+type SportDocument struct {
+	BaseDocument
+}
+
+//DocumentLoader builds the documents for the nodes with the given uids.
+//uids of nodes that don't exist (anymore) or aren't searched are missing in the result
+type DocumentLoader func(uids []string) (map[string]Document, error)
+
+//NeoDocumentLoader builds documents from the nodes in neo4j together with their host, club, sports and venue
+func NeoDocumentLoader(dbDriver neo4j.Driver) DocumentLoader {
+	return func(uids []string) (map[string]Document, error) {
+		dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+		if err != nil {
+			return nil, err
+		}
+		defer dbSession.Close()
+
+		records, err := neo4j.Collect(dbSession.Run(
+			fmt.Sprintf(`
+			match (n) where n.uid in $uids and (n:Club or n:Group or n:Event or n:Sport)
+			optional match (n)-[:%[1]v|%[2]v]->(host)
+			optional match (n)-[:%[1]v|%[2]v*1..%[3]d]->(club:Club)
+			optional match (n)-[:%[8]v]->(venue:Venue)
+			optional match (n)-[:%[4]v|%[5]v]->(sport:Sport)
+			with n, host, club, venue, collect(distinct sport.name) as sports, collect(distinct sport.uid) as sport_uids
+			optional match (n)<-[r:%[6]v|%[7]v|%[4]v|%[5]v]-(m)
+			where r.status in $counted_statuses or type(r) in ['%[4]v', '%[5]v']
+			return properties(n), labels(n), host.name, club.uid, club.name, sports, count(distinct m), sport_uids, venue.location
+			`,
+				models.EventHostedByGroupOrClub, models.GroupBelongsToGroupOrClub, models.MaxGroupDepth+1,
+				models.EventIsSport, models.ClubOrGroupOffersSport,
+				models.UserMemberOfGroupOrClub, models.UserAttendsEvent, models.EventOrClubAtVenue,
+			),
+			map[string]interface{}{
+				"uids":             uids,
+				"counted_statuses": []string{models.MembershipActive, models.AttendanceGoing},
+			},
+		))
+		if err != nil {
+			return nil, err
+		}
+
+		documents := map[string]Document{}
+		for _, record := range records {
+			document := documentFromRecord(record)
+			if document != nil {
+				documents[document.DocumentUID()] = document
+			}
+		}
+		return documents, nil
+	}
+}
+
+func documentFromRecord(record neo4j.Record) Document {
+	props, _ := record.GetByIndex(0).(map[string]interface{})
+	labelInterfaces, _ := record.GetByIndex(1).([]interface{})
+	hostName, _ := record.GetByIndex(2).(string)
+	clubUID, _ := record.GetByIndex(3).(string)
+	clubName, _ := record.GetByIndex(4).(string)
+	sports := []string{}
+	sportInterfaces, _ := record.GetByIndex(5).([]interface{})
+	for _, sport := range sportInterfaces {
+		if name, ok := sport.(string); ok {
+			sports = append(sports, name)
+		}
+	}
+	popularity, _ := record.GetByIndex(6).(int64)
+	sportUIDs := []string{}
+	sportUIDInterfaces, _ := record.GetByIndex(7).([]interface{})
+	for _, sportUID := range sportUIDInterfaces {
+		if uid, ok := sportUID.(string); ok {
+			sportUIDs = append(sportUIDs, uid)
+		}
+	}
+
+	labels := []string{}
+	for _, label := range labelInterfaces {
+		if labelString, ok := label.(string); ok {
+			labels = append(labels, labelString)
+		}
+	}
+
+	for _, label := range labels {
+		switch label {
+		case "Club":
+			club := models.ClubFromProps(props)
+			return &ClubDocument{
+				BaseDocument: baseDocument(club.UID.String(), "club", labels, club.Name, popularity),
+				Sports:       sports,
+			}
+		case "Group":
+			group := models.GroupFromProps(props)
+			return &GroupDocument{
+				BaseDocument: baseDocument(group.UID.String(), "group", labels, group.Name, popularity),
+				ParentName:   hostName,
+				ClubUID:      clubUID,
+				ClubName:     clubName,
+				Sports:       sports,
+			}
+		case "Event":
+			event := models.EventFromProps(props)
+			document := &EventDocument{
+				BaseDocument: baseDocument(event.UID.String(), "event", labels, event.Name, popularity),
+				HostName:     hostName,
+				ClubUID:      clubUID,
+				ClubName:     clubName,
+				Sports:       sports,
+				SportUIDs:    sportUIDs,
+				StartsAt:     timeOrNil(event.StartsAt),
+				EndsAt:       timeOrNil(event.EndsAt),
+				AllDay:       event.AllDay,
+				SkillLevel:   event.SkillLevel,
+				PriceCents:   event.PriceCents,
+				Recurring:    event.IsRecurring(),
+				LastEndsAt:   timeOrNil(event.RecurrenceEndsAt),
+			}
+			if location, ok := record.GetByIndex(8).(*neo4j.Point); ok {
+				point := db.PointFromNeo(location)
+				document.Location = &GeoPoint{Lat: point.Latitude, Lon: point.Longitude}
+			}
+			if !event.IsRecurring() {
+				document.LastEndsAt = document.EndsAt
+				if document.LastEndsAt == nil {
+					document.LastEndsAt = document.StartsAt
+				}
+			}
+			return document
+		case "Sport":
+			sport := models.SportFromProps(props)
+			return &SportDocument{
+				BaseDocument: baseDocument(sport.UID.String(), "sport", labels, sport.Name, popularity),
+			}
+		}
+	}
+	return nil
+}
+
+func baseDocument(uid, documentType string, labels []string, name string, popularity int64) BaseDocument {
+	return BaseDocument{
+		UID:        uid,
+		Type:       documentType,
+		Labels:     labels,
+		Name:       name,
+		Popularity: popularity,
+		Suggest:    Suggestion{Input: suggestionInputs(name), Weight: suggestionWeight(popularity)},
+	}
+}
+
+//maxSuggestionInputs limits how many of the words of long names can start a suggestion
+const maxSuggestionInputs = 5
+
+//suggestionInputs are the name and the rest of the name starting at each of its words, so "Rowing Club Berlin" is suggested for "club" too
+func suggestionInputs(name string) []string {
+	words := strings.Fields(name)
+	inputs := []string{}
+	for i := range words {
+		if i == maxSuggestionInputs {
+			break
+		}
+		inputs = append(inputs, strings.Join(words[i:], " "))
+	}
+	return inputs
+}
+
+//suggestionWeight has to fit into an int32 for elasticsearch
+func suggestionWeight(popularity int64) int64 {
+	if popularity > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return popularity
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
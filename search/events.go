@@ -0,0 +1,317 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+//maxFacets is the number of values counted per facet
+const maxFacets = 50
+
+//EventFilter narrows down the events of an EventQuery, zero values don't filter
+type EventFilter struct {
+	//SportUIDs and ClubUIDs match events that have one of the sports or belong to one of the clubs
+	SportUIDs []string
+	ClubUIDs  []string
+	//EndsAfter and StartsBefore match events whose time range overlaps the window. A series counts as a whole,
+	//from the start of its first to the end of its last occurrence, so it matches even if none of its occurrences falls into the window
+	EndsAfter    time.Time
+	StartsBefore time.Time
+	SkillLevels  []string
+	//MinPriceCents and MaxPriceCents are inclusive
+	MinPriceCents *int
+	MaxPriceCents *int
+	//Near matches events within RadiusMeters of the point
+	Near         *GeoPoint
+	RadiusMeters float64
+}
+
+//EventQuery for events by name and filters, all events matching the filter are found if Term is empty
+type EventQuery struct {
+	Term   string
+	Filter EventFilter
+	From   int
+	Size   int
+}
+
+//Facet is a value of a field and the number of events matching the query that have it
+type Facet struct {
+	Value string
+	Count int64
+}
+
+//EventResult is the Result of an EventQuery with the facets of all matching events.
+//Events are ordered by score if a term was given, by their start otherwise
+type EventResult struct {
+	Result
+	Sports []Facet
+	Clubs  []Facet
+}
+
+//SearchEvents finds the events matching the term and filters and counts their sports and clubs
+func (c *Client) SearchEvents(ctx context.Context, query EventQuery) (*EventResult, error) {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
+	}
+
+	boolQuery := elastic.NewBoolQuery().Filter(elastic.NewTermQuery("type", "event"))
+	if query.Term != "" {
+		boolQuery.Must(elastic.NewMatchQuery("name", query.Term).Fuzziness("AUTO"))
+	}
+	boolQuery.Filter(eventFilterQueries(query.Filter)...)
+
+	// the sport and club filters narrow down the hits after the query, so that each facet
+	// counts the events that match all filters except its own and the other values stay selectable
+	sportFilter, clubFilter := facetFilterQueries(query.Filter)
+	search := client.Search().
+		Index(nodeAlias).
+		Query(boolQuery).
+		PostFilter(elastic.NewBoolQuery().Filter(append(sportFilter, clubFilter...)...)).
+		Highlight(elastic.NewHighlight().Field("name")).
+		Aggregation("sports", facetAggregation("sport_uids", clubFilter)).
+		Aggregation("clubs", facetAggregation("club_uid", sportFilter)).
+		FetchSource(false).
+		From(query.From).
+		Size(query.Size)
+	if query.Term == "" {
+		search.Sort("starts_at", true)
+	}
+	searchResult, err := search.Do(ctx)
+	if err != nil {
+		return nil, wrapUnavailable(err)
+	}
+
+	result := &EventResult{Result: Result{Hits: []Hit{}}, Sports: []Facet{}, Clubs: []Facet{}}
+	if searchResult.Hits != nil {
+		result.Total = searchResult.Hits.TotalHits
+		for _, searchHit := range searchResult.Hits.Hits {
+			hit := Hit{UID: searchHit.Id, Highlights: searchHit.Highlight["name"]}
+			if searchHit.Score != nil {
+				hit.Score = *searchHit.Score
+			}
+			result.Hits = append(result.Hits, hit)
+		}
+	}
+	result.Sports = facetsFromAggregation(searchResult.Aggregations, "sports")
+	result.Clubs = facetsFromAggregation(searchResult.Aggregations, "clubs")
+	return result, nil
+}
+
+//eventFilterQueries of all filters except the facet filters, see facetFilterQueries
+func eventFilterQueries(filter EventFilter) []elastic.Query {
+	queries := []elastic.Query{}
+	if len(filter.SkillLevels) > 0 {
+		queries = append(queries, elastic.NewTermsQuery("skill_level", stringsToInterfaces(filter.SkillLevels)...))
+	}
+	if !filter.EndsAfter.IsZero() {
+		queries = append(queries, elastic.NewBoolQuery().MinimumNumberShouldMatch(1).Should(
+			elastic.NewRangeQuery("last_ends_at").Gte(filter.EndsAfter),
+			elastic.NewBoolQuery().
+				Filter(elastic.NewTermQuery("recurring", true)).
+				MustNot(elastic.NewExistsQuery("last_ends_at")),
+		))
+	}
+	if !filter.StartsBefore.IsZero() {
+		queries = append(queries, elastic.NewRangeQuery("starts_at").Lte(filter.StartsBefore))
+	}
+	if filter.MinPriceCents != nil || filter.MaxPriceCents != nil {
+		priceQuery := elastic.NewRangeQuery("price_cents")
+		if filter.MinPriceCents != nil {
+			priceQuery.Gte(*filter.MinPriceCents)
+		}
+		if filter.MaxPriceCents != nil {
+			priceQuery.Lte(*filter.MaxPriceCents)
+		}
+		queries = append(queries, priceQuery)
+	}
+	if filter.Near != nil {
+		queries = append(queries, elastic.NewGeoDistanceQuery("location").
+			Point(filter.Near.Lat, filter.Near.Lon).
+			Distance(fmt.Sprintf("%fm", filter.RadiusMeters)))
+	}
+	return queries
+}
+
+//facetFilterQueries of the sport and club filters, they are empty if the filter doesn't narrow down the value
+func facetFilterQueries(filter EventFilter) (sportFilter, clubFilter []elastic.Query) {
+	sportFilter, clubFilter = []elastic.Query{}, []elastic.Query{}
+	if len(filter.SportUIDs) > 0 {
+		sportFilter = append(sportFilter, elastic.NewTermsQuery("sport_uids", stringsToInterfaces(filter.SportUIDs)...))
+	}
+	if len(filter.ClubUIDs) > 0 {
+		clubFilter = append(clubFilter, elastic.NewTermsQuery("club_uid", stringsToInterfaces(filter.ClubUIDs)...))
+	}
+	return sportFilter, clubFilter
+}
+
+//facetAggregation counts the values of the field among the events matching the query and the filters of the other facets
+func facetAggregation(field string, otherFilters []elastic.Query) elastic.Aggregation {
+	return elastic.NewFilterAggregation().
+		Filter(elastic.NewBoolQuery().Filter(otherFilters...)).
+		SubAggregation("values", elastic.NewTermsAggregation().Field(field).Size(maxFacets))
+}
+
+func facetsFromAggregation(aggregations elastic.Aggregations, name string) []Facet {
+	facets := []Facet{}
+	filtered, ok := aggregations.Filter(name)
+	if !ok {
+		return facets
+	}
+	terms, ok := filtered.Terms("values")
+	if !ok {
+		return facets
+	}
+	for _, bucket := range terms.Buckets {
+		if value, ok := bucket.Key.(string); ok {
+			facets = append(facets, Facet{Value: value, Count: bucket.DocCount})
+		}
+	}
+	return facets
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	interfaces := []interface{}{}
+	for _, value := range values {
+		interfaces = append(interfaces, value)
+	}
+	return interfaces
+}
+
+//SearchEvents finds the events matching the term and filters and counts their sports and clubs
+func (b *MemoryBackend) SearchEvents(ctx context.Context, query EventQuery) (*EventResult, error) {
+	queryTokens := tokenize(query.Term)
+
+	b.mutex.RLock()
+	hits := []Hit{}
+	startsAt := map[string]time.Time{}
+	sportCounts := map[string]int64{}
+	clubCounts := map[string]int64{}
+	for uid, document := range b.documents {
+		event, ok := document.(*EventDocument)
+		if !ok || !query.Filter.matches(event) {
+			continue
+		}
+		hit := Hit{UID: uid}
+		if query.Term != "" {
+			score, highlight := matchName(queryTokens, event.Name)
+			if score == 0 {
+				continue
+			}
+			hit.Score = score
+			hit.Highlights = []string{highlight}
+		}
+		// like the post filter in elasticsearch, each facet counts the events that match all filters except its own
+		matchesSports, matchesClubs := query.Filter.matchesSports(event), query.Filter.matchesClubs(event)
+		if matchesClubs {
+			for _, sportUID := range event.SportUIDs {
+				sportCounts[sportUID]++
+			}
+		}
+		if matchesSports && event.ClubUID != "" {
+			clubCounts[event.ClubUID]++
+		}
+		if !matchesSports || !matchesClubs {
+			continue
+		}
+		hits = append(hits, hit)
+		if event.StartsAt != nil {
+			startsAt[uid] = *event.StartsAt
+		}
+	}
+	b.mutex.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !startsAt[hits[i].UID].Equal(startsAt[hits[j].UID]) {
+			return startsAt[hits[i].UID].Before(startsAt[hits[j].UID])
+		}
+		return hits[i].UID < hits[j].UID
+	})
+
+	result := &EventResult{
+		Result: Result{Total: int64(len(hits)), Hits: []Hit{}},
+		Sports: facetsFromCounts(sportCounts),
+		Clubs:  facetsFromCounts(clubCounts),
+	}
+	if query.From < len(hits) {
+		end := query.From + query.Size
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Hits = hits[query.From:end]
+	}
+	return result, nil
+}
+
+func (f *EventFilter) matchesSports(event *EventDocument) bool {
+	return len(f.SportUIDs) == 0 || containsAny(event.SportUIDs, f.SportUIDs)
+}
+
+func (f *EventFilter) matchesClubs(event *EventDocument) bool {
+	return len(f.ClubUIDs) == 0 || containsAny([]string{event.ClubUID}, f.ClubUIDs)
+}
+
+//matches checks all filters except the facet filters, see matchesSports and matchesClubs
+func (f *EventFilter) matches(event *EventDocument) bool {
+	if len(f.SkillLevels) > 0 && !containsAny([]string{event.SkillLevel}, f.SkillLevels) {
+		return false
+	}
+	if !f.EndsAfter.IsZero() {
+		unbounded := event.Recurring && event.LastEndsAt == nil
+		if !unbounded && (event.LastEndsAt == nil || event.LastEndsAt.Before(f.EndsAfter)) {
+			return false
+		}
+	}
+	if !f.StartsBefore.IsZero() && (event.StartsAt == nil || event.StartsAt.After(f.StartsBefore)) {
+		return false
+	}
+	if f.MinPriceCents != nil && event.PriceCents < *f.MinPriceCents {
+		return false
+	}
+	if f.MaxPriceCents != nil && event.PriceCents > *f.MaxPriceCents {
+		return false
+	}
+	if f.Near != nil && (event.Location == nil || distanceMeters(*f.Near, *event.Location) > f.RadiusMeters) {
+		return false
+	}
+	return true
+}
+
+//facetsFromCounts orders the facets like a terms aggregation, most frequent values first
+func facetsFromCounts(counts map[string]int64) []Facet {
+	facets := []Facet{}
+	for value, count := range counts {
+		facets = append(facets, Facet{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	if len(facets) > maxFacets {
+		facets = facets[:maxFacets]
+	}
+	return facets
+}
+
+//earthRadiusMeters is the mean radius elasticsearch uses for arc distances
+const earthRadiusMeters = 6371008.7714
+
+//distanceMeters between two points with the haversine formula
+func distanceMeters(a, b GeoPoint) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(b.Lat - a.Lat)
+	dLon := toRadians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(a.Lat))*math.Cos(toRadians(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,170 @@
+package search_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexmorten/events-api/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventDocument(uid, name string, startsAt time.Time, configure func(*search.EventDocument)) search.Document {
+	endsAt := startsAt.Add(2 * time.Hour)
+	document := &search.EventDocument{
+		BaseDocument: search.BaseDocument{UID: uid, Type: "event", Labels: []string{"Event"}, Name: name},
+		SportUIDs:    []string{},
+		StartsAt:     &startsAt,
+		EndsAt:       &endsAt,
+		LastEndsAt:   &endsAt,
+	}
+	if configure != nil {
+		configure(document)
+	}
+	return document
+}
+
+func Test_MemoryBackendSearchEvents(t *testing.T) {
+	day := time.Date(2019, 6, 1, 10, 0, 0, 0, time.UTC)
+	berlin := &search.GeoPoint{Lat: 52.52, Lon: 13.405}
+	potsdam := &search.GeoPoint{Lat: 52.3906, Lon: 13.0645}
+	backend := memoryBackendWith(t,
+		eventDocument("regatta", "Rowing Regatta", day.AddDate(0, 0, 2), func(e *search.EventDocument) {
+			e.SportUIDs = []string{"rowing"}
+			e.ClubUID = "wannsee"
+			e.SkillLevel = "advanced"
+			e.PriceCents = 1500
+			e.Location = potsdam
+		}),
+		eventDocument("training", "Rowing Training", day, func(e *search.EventDocument) {
+			e.SportUIDs = []string{"rowing", "fitness"}
+			e.ClubUID = "wannsee"
+			e.SkillLevel = "beginner"
+			e.Location = berlin
+			e.Recurring = true
+			e.LastEndsAt = nil
+		}),
+		eventDocument("tournament", "Chess Tournament", day.AddDate(0, 0, -10), func(e *search.EventDocument) {
+			e.SportUIDs = []string{"chess"}
+			e.ClubUID = "chess-club"
+			e.PriceCents = 500
+			e.Location = berlin
+		}),
+		clubDocument("wannsee", "Rowing Club Wannsee", 10),
+	)
+
+	searchEvents := func(query search.EventQuery) *search.EventResult {
+		if query.Size == 0 {
+			query.Size = 10
+		}
+		result, err := backend.SearchEvents(context.Background(), query)
+		require.NoError(t, err)
+		return result
+	}
+	uids := func(result *search.EventResult) []string {
+		uids := []string{}
+		for _, hit := range result.Hits {
+			uids = append(uids, hit.UID)
+		}
+		return uids
+	}
+
+	t.Run("without a term all events are found, earliest first", func(t *testing.T) {
+		result := searchEvents(search.EventQuery{})
+		assert.Equal(t, []string{"tournament", "training", "regatta"}, uids(result))
+		assert.Equal(t, []search.Facet{{Value: "rowing", Count: 2}, {Value: "chess", Count: 1}, {Value: "fitness", Count: 1}}, result.Sports)
+		assert.Equal(t, []search.Facet{{Value: "wannsee", Count: 2}, {Value: "chess-club", Count: 1}}, result.Clubs)
+	})
+
+	t.Run("facets count all events matching the query, not only the returned page", func(t *testing.T) {
+		result := searchEvents(search.EventQuery{Term: "rowign", Size: 1})
+		assert.Equal(t, int64(2), result.Total)
+		assert.Len(t, result.Hits, 1)
+		assert.Equal(t, []search.Facet{{Value: "rowing", Count: 2}, {Value: "fitness", Count: 1}}, result.Sports)
+	})
+
+	t.Run("facets count the events matching all filters except their own", func(t *testing.T) {
+		result := searchEvents(search.EventQuery{Filter: search.EventFilter{SportUIDs: []string{"chess"}, ClubUIDs: []string{"wannsee"}}})
+		assert.Empty(t, uids(result))
+		assert.Equal(t, []search.Facet{{Value: "rowing", Count: 2}, {Value: "fitness", Count: 1}}, result.Sports)
+		assert.Equal(t, []search.Facet{{Value: "chess-club", Count: 1}}, result.Clubs)
+
+		result = searchEvents(search.EventQuery{Filter: search.EventFilter{SportUIDs: []string{"fitness"}}})
+		assert.Equal(t, []string{"training"}, uids(result))
+		assert.Equal(t, []search.Facet{{Value: "rowing", Count: 2}, {Value: "chess", Count: 1}, {Value: "fitness", Count: 1}}, result.Sports)
+		assert.Equal(t, []search.Facet{{Value: "wannsee", Count: 1}}, result.Clubs)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		price := func(cents int) *int { return &cents }
+		for name, testCase := range map[string]struct {
+			filter search.EventFilter
+			uids   []string
+		}{
+			"sport":          {search.EventFilter{SportUIDs: []string{"fitness", "chess"}}, []string{"tournament", "training"}},
+			"club":           {search.EventFilter{ClubUIDs: []string{"chess-club"}}, []string{"tournament"}},
+			"skill level":    {search.EventFilter{SkillLevels: []string{"beginner", "intermediate"}}, []string{"training"}},
+			"min price":      {search.EventFilter{MinPriceCents: price(500)}, []string{"tournament", "regatta"}},
+			"max price":      {search.EventFilter{MaxPriceCents: price(500)}, []string{"tournament", "training"}},
+			"ends after":     {search.EventFilter{EndsAfter: day.AddDate(0, 0, 1)}, []string{"training", "regatta"}},
+			"starts before":  {search.EventFilter{StartsBefore: day}, []string{"tournament", "training"}},
+			"near":           {search.EventFilter{Near: berlin, RadiusMeters: 5000}, []string{"tournament", "training"}},
+			"near and wider": {search.EventFilter{Near: berlin, RadiusMeters: 30000}, []string{"tournament", "training", "regatta"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				assert.Equal(t, testCase.uids, uids(searchEvents(search.EventQuery{Filter: testCase.filter})))
+			})
+		}
+	})
+}
+
+func Test_SearchEvents(t *testing.T) {
+	var requestBody map[string]interface{}
+	elastic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes/_search" {
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"hits": {"total": 3, "hits": [{"_id": "event-uid", "_score": null}]},
+			"aggregations": {
+				"sports": {"doc_count": 4, "values": {"buckets": [{"key": "rowing", "doc_count": 3}, {"key": "fitness", "doc_count": 1}]}},
+				"clubs": {"doc_count": 3, "values": {"buckets": [{"key": "wannsee", "doc_count": 3}]}}
+			}
+		}`))
+	}))
+	defer elastic.Close()
+
+	client, err := search.NewClient(elastic.URL, true)
+	require.NoError(t, err)
+	minPrice := 0
+	result, err := client.SearchEvents(context.Background(), search.EventQuery{
+		Filter: search.EventFilter{
+			SportUIDs:     []string{"rowing"},
+			EndsAfter:     time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+			MinPriceCents: &minPrice,
+			Near:          &search.GeoPoint{Lat: 52.52, Lon: 13.405},
+			RadiusMeters:  10000,
+		},
+		Size: 10,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), result.Total)
+	assert.Equal(t, []search.Hit{{UID: "event-uid"}}, result.Hits)
+	assert.Equal(t, []search.Facet{{Value: "rowing", Count: 3}, {Value: "fitness", Count: 1}}, result.Sports)
+	assert.Equal(t, []search.Facet{{Value: "wannsee", Count: 3}}, result.Clubs)
+
+	assert.Contains(t, requestBody, "aggregations")
+	assert.Contains(t, requestBody, "sort")
+	filters := requestBody["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+	assert.Len(t, filters, 4)
+	assert.Contains(t, requestBody, "post_filter", "the sport filter doesn't narrow down the sport facet")
+}
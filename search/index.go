@@ -0,0 +1,220 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/olivere/elastic"
+)
+
+//documentType is the mapping type of all documents, elasticsearch 6 allows only one per index
+const documentType = "_doc"
+
+const nodeIndexBody = `{
+	"mappings": {
+		"_doc": {
+			"properties": {
+				"uid":          {"type": "keyword"},
+				"type":         {"type": "keyword"},
+				"labels":       {"type": "keyword"},
+				"name":         {"type": "text"},
+				"parent_name":  {"type": "text"},
+				"club_uid":     {"type": "keyword"},
+				"club_name":    {"type": "text"},
+				"host_name":    {"type": "text"},
+				"sports":       {"type": "keyword"},
+				"starts_at":    {"type": "date"},
+				"ends_at":      {"type": "date"},
+				"all_day":      {"type": "boolean"},
+				"sport_uids":   {"type": "keyword"},
+				"skill_level":  {"type": "keyword"},
+				"price_cents":  {"type": "integer"},
+				"location":     {"type": "geo_point"},
+				"recurring":    {"type": "boolean"},
+				"last_ends_at": {"type": "date"},
+				"popularity":   {"type": "long"},
+				"suggest": {
+					"type": "completion",
+					"contexts": [{"name": "type", "type": "category", "path": "type"}]
+				}
+			}
+		}
+	}
+}`
+
+//initialIndexVersion is used for the index that is created when there is none yet, reindexing creates newer versions
+const initialIndexVersion = "1"
+
+//versionedIndexName is the name of a version of the index, nodeAlias points to one of them
+func versionedIndexName(version string) string {
+	return fmt.Sprintf("%v-%v", nodeAlias, version)
+}
+
+//EnsureIndex creates the first version of the index together with its alias if there is no index yet
+func (c *Client) EnsureIndex(ctx context.Context) error {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return err
+	}
+
+	exists, err := client.IndexExists(nodeAlias).Do(ctx)
+	if err != nil {
+		return wrapUnavailable(err)
+	}
+	if exists {
+		return nil
+	}
+	indexName := versionedIndexName(initialIndexVersion)
+	_, err = client.CreateIndex(indexName).BodyString(nodeIndexBody).Do(ctx)
+	if err != nil && !elastic.IsStatusCode(err, http.StatusBadRequest) {
+		return wrapUnavailable(err)
+	}
+	// a bad request means someone else created it in the meantime, adding the alias twice doesn't hurt
+	_, err = client.Alias().Add(indexName, nodeAlias).Do(ctx)
+	return wrapUnavailable(err)
+}
+
+//WriteDocuments indexes the documents and deletes the documents of deletedUIDs in a single bulk request.
+//failedUIDs are the uids of single writes that failed, err is set if the whole request failed
+func (c *Client) WriteDocuments(ctx context.Context, documents []Document, deletedUIDs []string) (failedUIDs []string, err error) {
+	return c.writeDocuments(ctx, nodeAlias, documents, deletedUIDs)
+}
+
+//CreateVersion of the index as a new index next to the one the alias points to
+func (c *Client) CreateVersion(ctx context.Context, version string) error {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return err
+	}
+	_, err = client.CreateIndex(versionedIndexName(version)).BodyString(nodeIndexBody).Do(ctx)
+	return wrapUnavailable(err)
+}
+
+//WriteVersion writes the documents into the index of the version
+func (c *Client) WriteVersion(ctx context.Context, version string, documents []Document) (failedUIDs []string, err error) {
+	return c.writeDocuments(ctx, versionedIndexName(version), documents, nil)
+}
+
+//SwitchVersion points the alias to the index of the version in a single request, so that searches always find an index.
+//Returns the versions the alias pointed to before
+func (c *Client) SwitchVersion(ctx context.Context, version string) ([]string, error) {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := client.Aliases().Index("_all").Do(ctx)
+	if err != nil {
+		return nil, wrapUnavailable(err)
+	}
+
+	oldIndices := aliases.IndicesByAlias(nodeAlias)
+	aliasService := client.Alias().Add(versionedIndexName(version), nodeAlias)
+	for _, oldIndex := range oldIndices {
+		aliasService.Remove(oldIndex, nodeAlias)
+	}
+	if _, ok := aliases.Indices[nodeAlias]; ok {
+		// an index with the name of the alias is from before the index was versioned, it has to go for the alias to be added
+		aliasService.Action(elastic.NewAliasRemoveIndexAction(nodeAlias))
+	}
+	_, err = aliasService.Do(ctx)
+	if err != nil {
+		return nil, wrapUnavailable(err)
+	}
+
+	previous := []string{}
+	for _, oldIndex := range oldIndices {
+		previous = append(previous, strings.TrimPrefix(oldIndex, nodeAlias+"-"))
+	}
+	return previous, nil
+}
+
+//DeleteVersions deletes the indices of the versions
+func (c *Client) DeleteVersions(ctx context.Context, versions []string) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return err
+	}
+	indices := []string{}
+	for _, version := range versions {
+		indices = append(indices, versionedIndexName(version))
+	}
+	_, err = client.DeleteIndex(indices...).Do(ctx)
+	return wrapUnavailable(err)
+}
+
+//IndexedDocuments scrolls through the index the alias points to
+func (c *Client) IndexedDocuments(ctx context.Context, batchSize int) (map[string]map[string]interface{}, error) {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := map[string]map[string]interface{}{}
+	scroll := client.Scroll(nodeAlias).Size(batchSize)
+	defer scroll.Clear(context.Background())
+	for {
+		searchResult, err := scroll.Do(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, wrapUnavailable(err)
+		}
+		if searchResult.Hits == nil {
+			break
+		}
+		for _, hit := range searchResult.Hits.Hits {
+			source := map[string]interface{}{}
+			if hit.Source != nil {
+				json.Unmarshal(*hit.Source, &source)
+			}
+			indexed[hit.Id] = source
+		}
+	}
+	return indexed, nil
+}
+
+func (c *Client) writeDocuments(ctx context.Context, indexName string, documents []Document, deletedUIDs []string) (failedUIDs []string, err error) {
+	if len(documents) == 0 && len(deletedUIDs) == 0 {
+		return nil, nil
+	}
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
+	}
+
+	bulk := client.Bulk().Index(indexName).Type(documentType)
+	for _, document := range documents {
+		bulk.Add(elastic.NewBulkIndexRequest().Id(document.DocumentUID()).Doc(document))
+	}
+	for _, uid := range deletedUIDs {
+		bulk.Add(elastic.NewBulkDeleteRequest().Id(uid))
+	}
+
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return nil, wrapUnavailable(err)
+	}
+
+	failedUIDs = []string{}
+	for _, item := range response.Items {
+		for action, result := range item {
+			if result.Status >= 200 && result.Status < 300 {
+				continue
+			}
+			if action == "delete" && result.Status == http.StatusNotFound {
+				// there was nothing to delete
+				continue
+			}
+			failedUIDs = append(failedUIDs, result.Id)
+		}
+	}
+	return failedUIDs, nil
+}
@@ -0,0 +1,196 @@
+package search
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/models"
+)
+
+//Indexer keeps the search index in sync with the database.
+//Changed nodes are collected and written in bulk, writes that fail are retried from a durable queue
+type Indexer struct {
+	backend    Backend
+	load       DocumentLoader
+	queue      Queue
+	changes    chan string
+	indexReady bool
+	//readyMutex guards indexReady, Index can be called from Run and from outside at the same time
+	readyMutex sync.Mutex
+
+	//BatchSize is the maximum number of nodes written at once
+	BatchSize int
+	//FlushInterval is how long changes are collected before they are written
+	FlushInterval time.Duration
+	//RetryInterval is how often the queue is checked for writes to retry
+	RetryInterval time.Duration
+	//Dependents of changed nodes are indexed together with them, e.g. the events of a renamed club. They aren't loaded if it is nil
+	Dependents DependentsLoader
+	//ChangeLog records the uids that are written while a reindex runs, nothing is recorded if it is nil
+	ChangeLog ChangeLog
+}
+
+//NewIndexer with sensible defaults, call Run to start indexing
+func NewIndexer(backend Backend, load DocumentLoader, queue Queue) *Indexer {
+	return &Indexer{
+		backend:       backend,
+		load:          load,
+		queue:         queue,
+		changes:       make(chan string, 1000),
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		RetryInterval: 10 * time.Second,
+	}
+}
+
+//Notify the indexer about a changed node, it can be registered with db.OnChange.
+//It doesn't wait for the node to be indexed, if too many changes are waiting the node is put on the queue instead
+func (i *Indexer) Notify(change db.Change) {
+	if change.Label != "" && !isSearchable(change.Label) {
+		return
+	}
+	select {
+	case i.changes <- change.UID:
+	default:
+		if err := i.queue.Push([]string{change.UID}); err != nil {
+			log.Printf("search indexer lost change of %v: %v", change.UID, err)
+		}
+	}
+}
+
+//Run the indexer until ctx is done
+func (i *Indexer) Run(ctx context.Context) {
+	flushTicker := time.NewTicker(i.FlushInterval)
+	defer flushTicker.Stop()
+	retryTicker := time.NewTicker(i.RetryInterval)
+	defer retryTicker.Stop()
+
+	pending := map[string]bool{}
+	flush := func(ctx context.Context) {
+		if len(pending) == 0 {
+			return
+		}
+		uids := make([]string, 0, len(pending))
+		for uid := range pending {
+			uids = append(uids, uid)
+		}
+		pending = map[string]bool{}
+		i.Index(ctx, i.withDependents(uids))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// whatever can't be written anymore ends up on the queue
+			flush(context.Background())
+			return
+		case uid := <-i.changes:
+			pending[uid] = true
+			if len(pending) >= i.BatchSize {
+				flush(ctx)
+			}
+		case <-flushTicker.C:
+			flush(ctx)
+		case <-retryTicker.C:
+			i.retry(ctx)
+		}
+	}
+}
+
+//Index writes the current documents of the nodes with the given uids, nodes that don't exist anymore are removed from the index.
+//Writes that failed are put on the queue
+func (i *Indexer) Index(ctx context.Context, uids []string) error {
+	if i.ChangeLog != nil {
+		// before loading, so that a reindex that switches while this writes still writes the nodes again
+		if err := i.ChangeLog.Record(uids); err != nil {
+			log.Printf("search indexer can't record changes of %d nodes for running reindexes: %v", len(uids), err)
+		}
+	}
+	if err := i.ensureIndex(ctx); err != nil {
+		return i.retryLater(uids, err)
+	}
+
+	documents, err := i.load(uids)
+	if err != nil {
+		return i.retryLater(uids, err)
+	}
+	indexed := []Document{}
+	deleted := []string{}
+	for _, uid := range uids {
+		if document, ok := documents[uid]; ok {
+			indexed = append(indexed, document)
+		} else {
+			deleted = append(deleted, uid)
+		}
+	}
+
+	failedUIDs, err := i.backend.WriteDocuments(ctx, indexed, deleted)
+	if err != nil {
+		return i.retryLater(uids, err)
+	}
+	failed := map[string]bool{}
+	for _, uid := range failedUIDs {
+		failed[uid] = true
+	}
+	succeeded := []string{}
+	for _, uid := range uids {
+		if !failed[uid] {
+			succeeded = append(succeeded, uid)
+		}
+	}
+	if len(failedUIDs) > 0 {
+		if err := i.retryLater(failedUIDs, nil); err != nil {
+			return err
+		}
+	}
+	return i.queue.Done(succeeded)
+}
+
+//ensureIndex makes sure the index exists before the first write, it is checked again after it failed
+func (i *Indexer) ensureIndex(ctx context.Context) error {
+	i.readyMutex.Lock()
+	defer i.readyMutex.Unlock()
+	if i.indexReady {
+		return nil
+	}
+	if err := i.backend.EnsureIndex(ctx); err != nil {
+		return err
+	}
+	i.indexReady = true
+	return nil
+}
+
+//retry the writes that are due
+func (i *Indexer) retry(ctx context.Context) {
+	uids, err := i.queue.Due(i.BatchSize)
+	if err != nil {
+		log.Printf("search indexer can't read its queue: %v", err)
+		return
+	}
+	if len(uids) > 0 {
+		i.Index(ctx, uids)
+	}
+}
+
+func (i *Indexer) retryLater(uids []string, cause error) error {
+	if cause != nil {
+		log.Printf("search indexer will retry writing %d nodes: %v", len(uids), cause)
+	}
+	if err := i.queue.Push(uids); err != nil {
+		log.Printf("search indexer lost changes of %v: %v", uids, err)
+		return err
+	}
+	return cause
+}
+
+func isSearchable(label string) bool {
+	for _, searchableLabel := range models.SearchableLabels {
+		if label == searchableLabel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package search_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryQueue struct {
+	sync.Mutex
+	uids map[string]int
+}
+
+func (q *memoryQueue) Push(uids []string) error {
+	q.Lock()
+	defer q.Unlock()
+	for _, uid := range uids {
+		q.uids[uid]++
+	}
+	return nil
+}
+
+func (q *memoryQueue) Due(limit int) ([]string, error) {
+	q.Lock()
+	defer q.Unlock()
+	uids := []string{}
+	for uid := range q.uids {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	if len(uids) > limit {
+		uids = uids[:limit]
+	}
+	return uids, nil
+}
+
+func (q *memoryQueue) Done(uids []string) error {
+	q.Lock()
+	defer q.Unlock()
+	for _, uid := range uids {
+		delete(q.uids, uid)
+	}
+	return nil
+}
+
+//fakeBulkEndpoint answers bulk requests, writes of documents with a uid in failing fail
+func fakeBulkEndpoint(t *testing.T, failing map[string]bool, actions chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes/_doc/_bulk" {
+			return
+		}
+		items := []map[string]map[string]interface{}{}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			line := map[string]map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			for action, meta := range line {
+				if action != "index" && action != "delete" {
+					continue
+				}
+				if action == "index" {
+					scanner.Scan()
+				}
+				uid := meta["_id"].(string)
+				status := 200
+				if failing[uid] {
+					status = 429
+				}
+				items = append(items, map[string]map[string]interface{}{action: {"_id": uid, "status": status}})
+				actions <- action + " " + uid
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": len(failing) > 0, "items": items})
+	}))
+}
+
+func drain(actions chan string) []string {
+	drained := []string{}
+	for {
+		select {
+		case action := <-actions:
+			drained = append(drained, action)
+		default:
+			sort.Strings(drained)
+			return drained
+		}
+	}
+}
+
+func Test_Indexer(t *testing.T) {
+	documents := map[string]search.Document{
+		"club":  &search.ClubDocument{BaseDocument: search.BaseDocument{UID: "club", Type: "club", Name: "Rowing Club"}},
+		"event": &search.EventDocument{BaseDocument: search.BaseDocument{UID: "event", Type: "event", Name: "Regatta"}},
+	}
+	loader := func(uids []string) (map[string]search.Document, error) {
+		loaded := map[string]search.Document{}
+		for _, uid := range uids {
+			if document, ok := documents[uid]; ok {
+				loaded[uid] = document
+			}
+		}
+		return loaded, nil
+	}
+
+	t.Run("documents are written in bulk and deleted nodes are removed", func(t *testing.T) {
+		actions := make(chan string, 10)
+		elastic := fakeBulkEndpoint(t, map[string]bool{}, actions)
+		defer elastic.Close()
+		client, _ := search.NewClient(elastic.URL, true)
+		queue := &memoryQueue{uids: map[string]int{"club": 1}}
+
+		indexer := search.NewIndexer(client, loader, queue)
+		require.NoError(t, indexer.Index(context.Background(), []string{"club", "event", "deleted"}))
+		assert.Equal(t, []string{"delete deleted", "index club", "index event"}, drain(actions))
+		assert.Empty(t, queue.uids)
+	})
+
+	t.Run("failed writes are put on the queue and retried", func(t *testing.T) {
+		actions := make(chan string, 10)
+		failing := map[string]bool{"event": true}
+		elastic := fakeBulkEndpoint(t, failing, actions)
+		defer elastic.Close()
+		client, _ := search.NewClient(elastic.URL, true)
+		queue := &memoryQueue{uids: map[string]int{}}
+
+		indexer := search.NewIndexer(client, loader, queue)
+		require.NoError(t, indexer.Index(context.Background(), []string{"club", "event"}))
+		drain(actions)
+		assert.Equal(t, map[string]int{"event": 1}, queue.uids)
+
+		delete(failing, "event")
+		due, _ := queue.Due(10)
+		require.NoError(t, indexer.Index(context.Background(), due))
+		assert.Equal(t, []string{"index event"}, drain(actions))
+		assert.Empty(t, queue.uids)
+	})
+
+	t.Run("everything is queued while elasticsearch is down", func(t *testing.T) {
+		elastic := httptest.NewServer(http.NotFoundHandler())
+		address := elastic.URL
+		elastic.Close()
+		client, _ := search.NewClient(address, true)
+		queue := &memoryQueue{uids: map[string]int{}}
+
+		indexer := search.NewIndexer(client, loader, queue)
+		err := indexer.Index(context.Background(), []string{"club", "event"})
+		assert.True(t, search.IsUnavailable(err))
+		assert.Equal(t, map[string]int{"club": 1, "event": 1}, queue.uids)
+	})
+
+	t.Run("notified changes are collected and written", func(t *testing.T) {
+		actions := make(chan string, 10)
+		elastic := fakeBulkEndpoint(t, map[string]bool{}, actions)
+		defer elastic.Close()
+		client, _ := search.NewClient(elastic.URL, true)
+
+		indexer := search.NewIndexer(client, loader, &memoryQueue{uids: map[string]int{}})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go indexer.Run(ctx)
+
+		indexer.Notify(db.Change{UID: "some-user", Label: "User"})
+		indexer.Notify(db.Change{UID: "club", Label: "Club"})
+		indexer.Notify(db.Change{UID: "club"})
+		assert.Equal(t, "index club", <-actions)
+		assert.False(t, strings.Contains(strings.Join(drain(actions), ","), "some-user"))
+	})
+
+	t.Run("dependents of changed nodes are written with them", func(t *testing.T) {
+		actions := make(chan string, 10)
+		elastic := fakeBulkEndpoint(t, map[string]bool{}, actions)
+		defer elastic.Close()
+		client, _ := search.NewClient(elastic.URL, true)
+
+		indexer := search.NewIndexer(client, loader, &memoryQueue{uids: map[string]int{}})
+		indexer.Dependents = func(uids []string) ([]string, error) {
+			assert.Equal(t, []string{"club"}, uids)
+			return []string{"event"}, nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go indexer.Run(ctx)
+
+		indexer.Notify(db.Change{UID: "club", Label: "Club"})
+		written := []string{<-actions, <-actions}
+		sort.Strings(written)
+		assert.Equal(t, []string{"index club", "index event"}, written)
+	})
+}
@@ -0,0 +1,318 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//MemoryBackend keeps documents in memory and answers queries like elasticsearch does,
+//the scores are only an approximation of the ones elasticsearch calculates
+type MemoryBackend struct {
+	mutex sync.RWMutex
+	//documents of the current version, which is searched and written to
+	documents map[string]Document
+	current   string
+	versions  map[string]map[string]Document
+}
+
+//NewMemoryBackend without any documents
+func NewMemoryBackend() *MemoryBackend {
+	documents := map[string]Document{}
+	return &MemoryBackend{
+		documents: documents,
+		current:   initialIndexVersion,
+		versions:  map[string]map[string]Document{initialIndexVersion: documents},
+	}
+}
+
+//EnsureIndex has nothing to prepare
+func (b *MemoryBackend) EnsureIndex(ctx context.Context) error {
+	return nil
+}
+
+//WriteDocuments stores the documents and removes the documents of deletedUIDs, it never fails
+func (b *MemoryBackend) WriteDocuments(ctx context.Context, documents []Document, deletedUIDs []string) ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, document := range documents {
+		b.documents[document.DocumentUID()] = document
+	}
+	for _, uid := range deletedUIDs {
+		delete(b.documents, uid)
+	}
+	return []string{}, nil
+}
+
+//CreateVersion with no documents
+func (b *MemoryBackend) CreateVersion(ctx context.Context, version string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.versions[version]; ok {
+		return fmt.Errorf("version %v already exists", version)
+	}
+	b.versions[version] = map[string]Document{}
+	return nil
+}
+
+//WriteVersion stores the documents in the version
+func (b *MemoryBackend) WriteVersion(ctx context.Context, version string, documents []Document) ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	versionDocuments, ok := b.versions[version]
+	if !ok {
+		return nil, fmt.Errorf("there is no version %v", version)
+	}
+	for _, document := range documents {
+		versionDocuments[document.DocumentUID()] = document
+	}
+	return []string{}, nil
+}
+
+//SwitchVersion makes the version the current one
+func (b *MemoryBackend) SwitchVersion(ctx context.Context, version string) ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	documents, ok := b.versions[version]
+	if !ok {
+		return nil, fmt.Errorf("there is no version %v", version)
+	}
+	previous := []string{b.current}
+	b.current = version
+	b.documents = documents
+	return previous, nil
+}
+
+//DeleteVersions other than the current one
+func (b *MemoryBackend) DeleteVersions(ctx context.Context, versions []string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, version := range versions {
+		if version == b.current {
+			return fmt.Errorf("version %v is used", version)
+		}
+		delete(b.versions, version)
+	}
+	return nil
+}
+
+//IndexedDocuments of the current version, converted to JSON and back like elasticsearch stores them
+func (b *MemoryBackend) IndexedDocuments(ctx context.Context, batchSize int) (map[string]map[string]interface{}, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	indexed := map[string]map[string]interface{}{}
+	for uid, document := range b.documents {
+		marshalled, err := json.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		source := map[string]interface{}{}
+		if err := json.Unmarshal(marshalled, &source); err != nil {
+			return nil, err
+		}
+		indexed[uid] = source
+	}
+	return indexed, nil
+}
+
+//SearchNodes fuzzily matches the words of the name, allowing one typo in words of 3 to 5 letters and two in longer words
+func (b *MemoryBackend) SearchNodes(ctx context.Context, query Query) (*Result, error) {
+	queryTokens := tokenize(query.Term)
+
+	b.mutex.RLock()
+	hits := []Hit{}
+	for uid, document := range b.documents {
+		base := document.base()
+		if len(query.Labels) > 0 && !containsAny(base.Labels, query.Labels) {
+			continue
+		}
+		score, highlight := matchName(queryTokens, base.Name)
+		if score > 0 {
+			hits = append(hits, Hit{UID: uid, Score: score, Highlights: []string{highlight}})
+		}
+	}
+	b.mutex.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].UID < hits[j].UID
+	})
+
+	result := &Result{Total: int64(len(hits)), Hits: []Hit{}}
+	if query.From < len(hits) {
+		end := query.From + query.Size
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Hits = hits[query.From:end]
+	}
+	return result, nil
+}
+
+//Suggest nodes whose name or one of its words starts with the prefix, ordered by popularity
+func (b *MemoryBackend) Suggest(ctx context.Context, prefix string, types []string, size int) ([]SuggestedNode, error) {
+	prefix = strings.ToLower(prefix)
+
+	b.mutex.RLock()
+	suggestions := []SuggestedNode{}
+	for uid, document := range b.documents {
+		base := document.base()
+		if len(types) > 0 && !containsAny([]string{base.Type}, types) {
+			continue
+		}
+		for _, input := range base.Suggest.Input {
+			if strings.HasPrefix(strings.ToLower(input), prefix) {
+				suggestions = append(suggestions, SuggestedNode{UID: uid, Type: base.Type, Name: base.Name, Score: float64(base.Suggest.Weight)})
+				break
+			}
+		}
+	}
+	b.mutex.RUnlock()
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].Name != suggestions[j].Name {
+			return suggestions[i].Name < suggestions[j].Name
+		}
+		return suggestions[i].UID < suggestions[j].UID
+	})
+	if len(suggestions) > size {
+		suggestions = suggestions[:size]
+	}
+	return suggestions, nil
+}
+
+//matchName scores how well the query matches the name and highlights the words of the name that matched.
+//Like a match query every matching word of the query adds to the score, shorter names score higher
+func matchName(queryTokens []string, name string) (float64, string) {
+	nameTokens := tokenize(name)
+	if len(nameTokens) == 0 {
+		return 0, ""
+	}
+
+	matched := map[int]bool{}
+	score := 0.0
+	for _, queryToken := range queryTokens {
+		best := 0.0
+		for i, nameToken := range nameTokens {
+			distance := editDistance(queryToken, nameToken)
+			if distance > allowedEdits(queryToken) {
+				continue
+			}
+			matched[i] = true
+			similarity := 1 - float64(distance)/float64(len([]rune(queryToken)))
+			if similarity > best {
+				best = similarity
+			}
+		}
+		score += best
+	}
+	if score == 0 {
+		return 0, ""
+	}
+	return score / math.Sqrt(float64(len(nameTokens))), highlight(name, matched)
+}
+
+//allowedEdits like fuzziness AUTO in elasticsearch
+func allowedEdits(token string) int {
+	length := len([]rune(token))
+	switch {
+	case length < 3:
+		return 0
+	case length < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+//tokenize lowercases the text and splits it into words, like the standard analyzer
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+//highlight wraps the words of the name with the given indexes in <em> tags
+func highlight(name string, matched map[int]bool) string {
+	highlighted := strings.Builder{}
+	word := strings.Builder{}
+	index := 0
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		if matched[index] {
+			highlighted.WriteString("<em>" + word.String() + "</em>")
+		} else {
+			highlighted.WriteString(word.String())
+		}
+		word.Reset()
+		index++
+	}
+	for _, r := range name {
+		if isSeparator(r) {
+			flush()
+			highlighted.WriteRune(r)
+		} else {
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return highlighted.String()
+}
+
+//editDistance counts the insertions, deletions, substitutions and transpositions of neighbouring letters that turn a into b
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, value := range values {
+		for _, w := range wanted {
+			if value == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexmorten/events-api/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryBackendWith(t *testing.T, documents ...search.Document) *search.MemoryBackend {
+	backend := search.NewMemoryBackend()
+	failedUIDs, err := backend.WriteDocuments(context.Background(), documents, nil)
+	require.NoError(t, err)
+	require.Empty(t, failedUIDs)
+	return backend
+}
+
+func clubDocument(uid, name string, popularity int64) search.Document {
+	return &search.ClubDocument{BaseDocument: search.BaseDocument{
+		UID: uid, Type: "club", Labels: []string{"Club"}, Name: name,
+		Suggest: search.Suggestion{Input: []string{name}, Weight: popularity},
+	}}
+}
+
+func sportDocument(uid, name string, popularity int64) search.Document {
+	return &search.SportDocument{BaseDocument: search.BaseDocument{
+		UID: uid, Type: "sport", Labels: []string{"Sport"}, Name: name,
+		Suggest: search.Suggestion{Input: []string{name}, Weight: popularity},
+	}}
+}
+
+func Test_MemoryBackendSearchNodes(t *testing.T) {
+	backend := memoryBackendWith(t,
+		clubDocument("rowing-club", "Rowing Club", 10),
+		clubDocument("berlin-rowing", "Berliner Rowing-Club Wannsee", 5),
+		clubDocument("chess", "Chess Club", 3),
+		sportDocument("rowing", "Rowing", 2),
+	)
+	find := func(query search.Query) []string {
+		result, err := backend.SearchNodes(context.Background(), query)
+		require.NoError(t, err)
+		uids := []string{}
+		for _, hit := range result.Hits {
+			uids = append(uids, hit.UID)
+		}
+		return uids
+	}
+
+	t.Run("words are matched with typos, shorter names first", func(t *testing.T) {
+		assert.Equal(t, []string{"rowing", "rowing-club", "berlin-rowing"}, find(search.Query{Term: "rowign", Size: 10}))
+		assert.Equal(t, []string{"rowing"}, find(search.Query{Term: "rwoingg", Labels: []string{"Sport"}, Size: 10}))
+		assert.Empty(t, find(search.Query{Term: "rugby", Size: 10}))
+		assert.Empty(t, find(search.Query{Term: "ab", Size: 10}))
+	})
+
+	t.Run("labels restrict the results", func(t *testing.T) {
+		assert.Equal(t, []string{"rowing-club", "berlin-rowing"}, find(search.Query{Term: "rowing", Labels: []string{"Club"}, Size: 10}))
+	})
+
+	t.Run("results are paginated", func(t *testing.T) {
+		result, err := backend.SearchNodes(context.Background(), search.Query{Term: "rowing club", From: 1, Size: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), result.Total)
+		require.Len(t, result.Hits, 2)
+		assert.Equal(t, []search.Hit{}, mustSearch(t, backend, search.Query{Term: "rowing", From: 10, Size: 2}).Hits)
+	})
+
+	t.Run("matching words are highlighted", func(t *testing.T) {
+		result := mustSearch(t, backend, search.Query{Term: "rowing wansee", Labels: []string{"Club"}, Size: 10})
+		require.Len(t, result.Hits, 2)
+		assert.Equal(t, []string{"Berliner <em>Rowing</em>-Club <em>Wannsee</em>"}, result.Hits[0].Highlights)
+	})
+
+	t.Run("deleted documents aren't found", func(t *testing.T) {
+		_, err := backend.WriteDocuments(context.Background(), nil, []string{"chess"})
+		require.NoError(t, err)
+		assert.Empty(t, find(search.Query{Term: "chess", Size: 10}))
+	})
+}
+
+func mustSearch(t *testing.T, backend search.Backend, query search.Query) *search.Result {
+	result, err := backend.SearchNodes(context.Background(), query)
+	require.NoError(t, err)
+	return result
+}
+
+func Test_MemoryBackendSuggest(t *testing.T) {
+	backend := memoryBackendWith(t,
+		clubDocument("small", "Rowing Club", 10),
+		clubDocument("big", "Rowing Team", 50),
+		clubDocument("chess", "Chess Club", 100),
+		sportDocument("rowing", "Rowing", 20),
+	)
+
+	suggestions, err := backend.Suggest(context.Background(), "ROW", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []search.SuggestedNode{
+		{UID: "big", Type: "club", Name: "Rowing Team", Score: 50},
+		{UID: "rowing", Type: "sport", Name: "Rowing", Score: 20},
+		{UID: "small", Type: "club", Name: "Rowing Club", Score: 10},
+	}, suggestions)
+
+	suggestions, err = backend.Suggest(context.Background(), "row", []string{"club"}, 1)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "big", suggestions[0].UID)
+}
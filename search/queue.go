@@ -0,0 +1,90 @@
+package search
+
+import (
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//maxRetryBackoffExponent limits the time between retries to 2^12 seconds (a bit more than an hour)
+const maxRetryBackoffExponent = 12
+
+//Queue keeps the uids of nodes that couldn't be indexed until they were indexed successfully
+type Queue interface {
+	//Push uids that failed to be indexed, each push doubles the time until they are due again
+	Push(uids []string) error
+	//Due returns up to limit uids that should be retried now
+	Due(limit int) ([]string, error)
+	//Done removes uids that were indexed from the queue
+	Done(uids []string) error
+}
+
+//NeoQueue stores the queue as (:PendingIndexWrite) nodes in neo4j, so it survives restarts
+type NeoQueue struct {
+	dbDriver neo4j.Driver
+}
+
+//NewNeoQueue ...
+func NewNeoQueue(dbDriver neo4j.Driver) *NeoQueue {
+	return &NeoQueue{dbDriver: dbDriver}
+}
+
+//Push uids that failed to be indexed
+func (q *NeoQueue) Push(uids []string) error {
+	return q.run(
+		`
+		unwind $uids as uid
+		merge (p:PendingIndexWrite {node_uid: uid})
+		on create set p.attempts = 0
+		set p.attempts = p.attempts + 1
+		set p.next_attempt_at = datetime() + duration({seconds: toInteger(2 ^ (case when p.attempts > $max_exponent then $max_exponent else p.attempts end))})
+		`,
+		map[string]interface{}{"uids": uids, "max_exponent": maxRetryBackoffExponent},
+	)
+}
+
+//Due returns up to limit uids that should be retried now
+func (q *NeoQueue) Due(limit int) ([]string, error) {
+	dbSession, err := q.dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return nil, err
+	}
+	defer dbSession.Close()
+
+	records, err := neo4j.Collect(dbSession.Run(
+		"match (p:PendingIndexWrite) where p.next_attempt_at <= datetime() return p.node_uid order by p.next_attempt_at limit $limit",
+		map[string]interface{}{"limit": limit},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	uids := []string{}
+	for _, record := range records {
+		if uid, ok := record.GetByIndex(0).(string); ok {
+			uids = append(uids, uid)
+		}
+	}
+	return uids, nil
+}
+
+//Done removes uids that were indexed from the queue
+func (q *NeoQueue) Done(uids []string) error {
+	return q.run(
+		"match (p:PendingIndexWrite) where p.node_uid in $uids delete p",
+		map[string]interface{}{"uids": uids},
+	)
+}
+
+func (q *NeoQueue) run(query string, params map[string]interface{}) error {
+	dbSession, err := q.dbDriver.Session(neo4j.AccessModeWrite)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	result, err := dbSession.Run(query, params)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
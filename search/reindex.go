@@ -0,0 +1,282 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexmorten/events-api/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
+)
+
+//Reindexer rebuilds the index from neo4j and compares both
+type Reindexer struct {
+	backend  VersionedBackend
+	dbDriver neo4j.Driver
+	load     DocumentLoader
+
+	//BatchSize is how many nodes are read and written at once
+	BatchSize int
+	//ChangeLog has the nodes the indexers of the servers wrote while the reindex ran
+	ChangeLog ChangeLog
+}
+
+//ReindexResult describes a finished reindex
+type ReindexResult struct {
+	Version        string
+	IndexName      string
+	Documents      int
+	RemovedIndices []string
+}
+
+//VerifyReport lists the uids of nodes whose document doesn't match
+type VerifyReport struct {
+	Checked int
+	//MissingInIndex are nodes without document
+	MissingInIndex []string
+	//MissingInDatabase are documents of nodes that don't exist
+	MissingInDatabase []string
+	//Stale are documents that differ from what would be indexed for the node now
+	Stale []string
+}
+
+//Consistent is true if neo4j and the index match
+func (r *VerifyReport) Consistent() bool {
+	return len(r.MissingInIndex) == 0 && len(r.MissingInDatabase) == 0 && len(r.Stale) == 0
+}
+
+//NewReindexer ...
+func NewReindexer(backend VersionedBackend, dbDriver neo4j.Driver) *Reindexer {
+	return &Reindexer{
+		backend:   backend,
+		dbDriver:  dbDriver,
+		load:      NeoDocumentLoader(dbDriver),
+		BatchSize: 1000,
+		ChangeLog: NewNeoChangeLog(dbDriver),
+	}
+}
+
+//Reindex writes the documents of all nodes into a new version of the index and then switches to it.
+//Nodes the indexers wrote while it ran, including deleted ones, are written again after the switch.
+//Older versions of the index are deleted unless keepOld is set
+func (r *Reindexer) Reindex(ctx context.Context, keepOld bool) (*ReindexResult, error) {
+	run, err := r.ChangeLog.Start()
+	if err != nil {
+		return nil, err
+	}
+	defer r.ChangeLog.Stop(run)
+
+	version := newIndexVersion()
+	result := &ReindexResult{Version: version, IndexName: versionedIndexName(version)}
+	err = r.backend.CreateVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.forEachBatch(func(uids []string) error {
+		documents, err := r.loadDocuments(uids)
+		if err != nil {
+			return err
+		}
+		failedUIDs, err := r.backend.WriteVersion(ctx, version, documents)
+		if err != nil {
+			return err
+		}
+		if len(failedUIDs) > 0 {
+			return fmt.Errorf("writing %d documents failed, e.g. %v", len(failedUIDs), failedUIDs[0])
+		}
+		result.Documents += len(documents)
+		return nil
+	})
+	if err != nil {
+		r.backend.DeleteVersions(context.Background(), []string{version})
+		return nil, err
+	}
+
+	previous, err := r.backend.SwitchVersion(ctx, version)
+	if err != nil {
+		r.backend.DeleteVersions(context.Background(), []string{version})
+		return nil, err
+	}
+
+	err = r.catchUp(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("searches use %v now but nodes that changed during reindexing couldn't be written: %v", result.IndexName, err)
+	}
+
+	if !keepOld && len(previous) > 0 {
+		err = r.backend.DeleteVersions(ctx, previous)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range previous {
+			result.RemovedIndices = append(result.RemovedIndices, versionedIndexName(version))
+		}
+	}
+	return result, nil
+}
+
+//newIndexVersion is unique even for reindexes started in the same second, and sorts by when they started
+func newIndexVersion() string {
+	return fmt.Sprintf("%d-%v", time.Now().Unix(), strings.Split(uuid.New().String(), "-")[0])
+}
+
+//catchUp writes the nodes the change log recorded for the run again, into the version that is used now
+func (r *Reindexer) catchUp(ctx context.Context, run string) error {
+	uids, err := r.ChangeLog.Changes(run)
+	if err != nil {
+		return err
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	documents, err := r.load(uids)
+	if err != nil {
+		return err
+	}
+	indexed := []Document{}
+	deleted := []string{}
+	for _, uid := range uids {
+		if document, ok := documents[uid]; ok {
+			indexed = append(indexed, document)
+		} else {
+			deleted = append(deleted, uid)
+		}
+	}
+	failedUIDs, err := r.backend.WriteDocuments(ctx, indexed, deleted)
+	if err != nil {
+		return err
+	}
+	if len(failedUIDs) > 0 {
+		return fmt.Errorf("writing %d documents failed, e.g. %v", len(failedUIDs), failedUIDs[0])
+	}
+	return nil
+}
+
+//Verify compares the documents in the index with the nodes in neo4j
+func (r *Reindexer) Verify(ctx context.Context) (*VerifyReport, error) {
+	indexed, err := r.backend.IndexedDocuments(ctx, r.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{MissingInIndex: []string{}, MissingInDatabase: []string{}, Stale: []string{}}
+	err = r.forEachBatch(func(uids []string) error {
+		documents, err := r.load(uids)
+		if err != nil {
+			return err
+		}
+		for uid, document := range documents {
+			report.Checked++
+			source, ok := indexed[uid]
+			if !ok {
+				report.MissingInIndex = append(report.MissingInIndex, uid)
+				continue
+			}
+			delete(indexed, uid)
+			same, err := documentMatches(document, source)
+			if err != nil {
+				return err
+			}
+			if !same {
+				report.Stale = append(report.Stale, uid)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for uid := range indexed {
+		report.MissingInDatabase = append(report.MissingInDatabase, uid)
+	}
+
+	sort.Strings(report.MissingInIndex)
+	sort.Strings(report.MissingInDatabase)
+	sort.Strings(report.Stale)
+	return report, nil
+}
+
+//forEachBatch of uids of searchable nodes, label by label
+func (r *Reindexer) forEachBatch(f func(uids []string) error) error {
+	return forEachUIDBatch(r.dbDriver, r.BatchSize, f)
+}
+
+//forEachUIDBatch of at most batchSize uids of searchable nodes in neo4j, label by label
+func forEachUIDBatch(dbDriver neo4j.Driver, batchSize int, f func(uids []string) error) error {
+	dbSession, err := dbDriver.Session(neo4j.AccessModeRead)
+	if err != nil {
+		return err
+	}
+	defer dbSession.Close()
+
+	for _, label := range searchableLabels() {
+		after := ""
+		for {
+			records, err := neo4j.Collect(dbSession.Run(
+				fmt.Sprintf("match (n:%v) where n.uid > $after return n.uid order by n.uid limit $limit", label),
+				map[string]interface{}{"after": after, "limit": batchSize},
+			))
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 {
+				break
+			}
+
+			uids := []string{}
+			for _, record := range records {
+				if uid, ok := record.GetByIndex(0).(string); ok {
+					uids = append(uids, uid)
+				}
+			}
+			err = f(uids)
+			if err != nil {
+				return err
+			}
+			after = uids[len(uids)-1]
+		}
+	}
+	return nil
+}
+
+func (r *Reindexer) loadDocuments(uids []string) ([]Document, error) {
+	loaded, err := r.load(uids)
+	if err != nil {
+		return nil, err
+	}
+	documents := []Document{}
+	for _, document := range loaded {
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+//documentMatches the source of a document read from the index
+func documentMatches(document Document, source map[string]interface{}) (bool, error) {
+	marshalled, err := json.Marshal(document)
+	if err != nil {
+		return false, err
+	}
+	expected := map[string]interface{}{}
+	err = json.Unmarshal(marshalled, &expected)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(expected, source), nil
+}
+
+func searchableLabels() []string {
+	labels := []string{}
+	for _, label := range models.SearchableLabels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/olivere/elastic"
+)
+
+const suggesterName = "name"
+
+//SuggestedNode is a node whose name (or one of its words) starts with the prefix that was asked for
+type SuggestedNode struct {
+	UID   string
+	Type  string
+	Name  string
+	Score float64
+}
+
+//Suggest nodes for a prefix typed by a user, more popular nodes first.
+//types restricts the suggestions to clubs, groups, events or sports, all of them are suggested if it is empty
+func (c *Client) Suggest(ctx context.Context, prefix string, types []string, size int) ([]SuggestedNode, error) {
+	client, err := c.ensureConnectionExists()
+	if err != nil {
+		return nil, err
+	}
+
+	suggester := elastic.NewCompletionSuggester(suggesterName).
+		Field("suggest").
+		Prefix(prefix).
+		Size(size)
+	if len(types) > 0 {
+		suggester.ContextQuery(elastic.NewSuggesterCategoryQuery("type", types...))
+	}
+
+	searchResult, err := client.Search().
+		Index(nodeAlias).
+		Suggester(suggester).
+		FetchSourceContext(elastic.NewFetchSourceContext(true).Include("type", "name")).
+		Do(ctx)
+	if err != nil {
+		return nil, wrapUnavailable(err)
+	}
+
+	suggestions := []SuggestedNode{}
+	for _, suggestion := range searchResult.Suggest[suggesterName] {
+		for _, option := range suggestion.Options {
+			source := struct {
+				Type string `json:"type"`
+				Name string `json:"name"`
+			}{}
+			if option.Source != nil {
+				json.Unmarshal(*option.Source, &source)
+			}
+			suggestions = append(suggestions, SuggestedNode{
+				UID:   option.Id,
+				Type:  source.Type,
+				Name:  source.Name,
+				Score: option.ScoreUnderscore,
+			})
+		}
+	}
+	return suggestions, nil
+}
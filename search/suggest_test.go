@@ -0,0 +1,52 @@
+package search_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexmorten/events-api/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Suggest(t *testing.T) {
+	var requestBody map[string]interface{}
+	elastic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes/_search" {
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &requestBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"suggest": {"name": [{"text": "row", "offset": 0, "length": 3, "options": [
+			{"text": "Rowing Club", "_id": "club-uid", "_score": 120, "_source": {"type": "club", "name": "Rowing Club"}},
+			{"text": "Rowing", "_id": "sport-uid", "_score": 12, "_source": {"type": "sport", "name": "Rowing"}}
+		]}]}}`))
+	}))
+	defer elastic.Close()
+
+	client, err := search.NewClient(elastic.URL, true)
+	require.NoError(t, err)
+	suggestions, err := client.Suggest(context.Background(), "row", []string{"club", "sport"}, 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, []search.SuggestedNode{
+		{UID: "club-uid", Type: "club", Name: "Rowing Club", Score: 120},
+		{UID: "sport-uid", Type: "sport", Name: "Rowing", Score: 12},
+	}, suggestions)
+
+	completion := requestBody["suggest"].(map[string]interface{})["name"].(map[string]interface{})
+	assert.Equal(t, "row", completion["prefix"])
+	options := completion["completion"].(map[string]interface{})
+	assert.Equal(t, "suggest", options["field"])
+	assert.Equal(t, float64(5), options["size"])
+	// the elastic client keeps the categories in a map, so their order varies
+	assert.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"context": "club"},
+		map[string]interface{}{"context": "sport"},
+	}, options["contexts"].(map[string]interface{})["type"])
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"github.com/alexmorten/events-api/search"
 
 	"github.com/alexmorten/events-api/db"
+	"github.com/alexmorten/events-api/migrations"
 
 	cors "github.com/rs/cors/wrapper/gin"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/alexmorten/events-api/actions"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/neo4j"
 )
 
 //Server is the outer most shell of the application
@@ -26,6 +29,10 @@ import (
 type Server struct {
 	config ServerConfig
 	Engine *gin.Engine
+
+	//stop the background work of the server and stop listening for changes
+	cancel        context.CancelFunc
+	stopListening func()
 }
 
 //ServerConfig contains all configuration for the Server
@@ -34,15 +41,20 @@ type ServerConfig struct {
 	Neo4jAddress          string
 	ElasticsearchAddress  string
 	LazyInitializeElastic bool
+	//SearchBackend is search.BackendElasticsearch (used if empty) or search.BackendMemory
+	SearchBackend string
+	//SkipMigrations leaves applying migrations to cmd/migrate instead of applying the pending ones on start
+	SkipMigrations bool
 }
 
-//DefaultServerConfig ...
+//DefaultServerConfig searches in memory, so it works without elasticsearch
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
 		Port:                  3000,
 		Neo4jAddress:          "bolt://localhost:7687",
 		ElasticsearchAddress:  "http://0.0.0.0:9200",
 		LazyInitializeElastic: true,
+		SearchBackend:         search.BackendMemory,
 	}
 }
 
@@ -53,14 +65,28 @@ func NewServer(config ServerConfig) *Server {
 	}
 }
 
-//Init the Server
-func (s *Server) Init() {
+//Init the Server, it fails if the pending migrations can't be applied
+func (s *Server) Init() error {
 	dbDriver := db.Driver(s.config.Neo4jAddress)
-	db.MustCreateConstraints(dbDriver)
-
-	searchClient := s.mustCreateSearchClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	searchBackend := s.createSearchBackend(ctx, dbDriver)
+	indexer := search.NewIndexer(searchBackend, search.NeoDocumentLoader(dbDriver), search.NewNeoQueue(dbDriver))
+	indexer.Dependents = search.NeoDependentsLoader(dbDriver)
+	indexer.ChangeLog = search.NewNeoChangeLog(dbDriver)
+	s.stopListening = db.OnChange(indexer.Notify)
+	go indexer.Run(ctx)
+
+	// after the indexer listens for changes, so that the nodes data migrations change are indexed again
+	if !s.config.SkipMigrations {
+		if err := db.Migrate(dbDriver, migrations.All); err != nil {
+			s.Close()
+			return fmt.Errorf("applying migrations: %v", err)
+		}
+	}
 
-	actionHandler := actions.NewActionHandler(dbDriver, searchClient)
+	actionHandler := actions.NewActionHandler(dbDriver, searchBackend)
 
 	s.Engine = gin.Default()
 	s.Engine.Use(cors.AllowAll())
@@ -70,6 +96,23 @@ func (s *Server) Init() {
 	actionHandler.RegisterGroupRoutes(rootGroup.Group("groups"))
 	actionHandler.RegisterEventRoutes(rootGroup.Group("events"))
 	actionHandler.RegisterSportRoutes(rootGroup.Group("sports"))
+	actionHandler.RegisterVenueRoutes(rootGroup.Group("venues"))
+	actionHandler.RegisterTagRoutes(rootGroup.Group("tags"))
+	actionHandler.RegisterCalendarRoutes(rootGroup.Group("calendars"))
+	actionHandler.RegisterUserRoutes(rootGroup.Group("users"))
+	actionHandler.RegisterMeRoutes(rootGroup.Group("me"))
+	actionHandler.RegisterSearchRoutes(rootGroup.Group("search"))
+	return nil
+}
+
+//Close stops indexing and the other background work of the server, it has to be initialized again before it is used
+func (s *Server) Close() {
+	if s.stopListening != nil {
+		s.stopListening()
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
 }
 
 //Run the Server
@@ -109,12 +152,28 @@ func jwtHandler(c *gin.Context) {
 	c.Next()
 }
 
-func (s *Server) mustCreateSearchClient() *search.Client {
-	client, err := search.NewClient(s.config.ElasticsearchAddress, s.config.LazyInitializeElastic)
-	if err != nil {
-		panic(err)
+//createSearchBackend doesn't fail if elasticsearch is down, the client tries to connect again when it is used.
+//The memory backend starts empty and is filled with all nodes in the background
+func (s *Server) createSearchBackend(ctx context.Context, dbDriver neo4j.Driver) search.Backend {
+	switch s.config.SearchBackend {
+	case search.BackendMemory:
+		backend := search.NewMemoryBackend()
+		go func() {
+			_, err := search.IndexAll(ctx, backend, dbDriver, 1000)
+			if err != nil {
+				log.Println(err)
+			}
+		}()
+		return backend
+	case search.BackendElasticsearch, "":
+		client, err := search.NewClient(s.config.ElasticsearchAddress, s.config.LazyInitializeElastic)
+		if err != nil {
+			log.Println(err)
+		}
+		return client
+	default:
+		panic(fmt.Sprintf("unknown search backend %q", s.config.SearchBackend))
 	}
-	return client
 }
 
 func tokenFromBearer(bearer string) string {
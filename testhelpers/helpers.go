@@ -16,7 +16,8 @@ import (
 func Clear(dbDriver neo4j.Driver) {
 	sess, err := dbDriver.Session(neo4j.AccessModeWrite)
 	panicOnErr(err)
-	_, err = sess.Run("match(n) detach delete n", nil)
+	defer sess.Close()
+	_, err = neo4j.Collect(sess.Run("match(n) detach delete n", nil))
 	panicOnErr(err)
 }
 